@@ -8,7 +8,6 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/google/go-github/v54/github"
@@ -21,6 +20,8 @@ import (
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/bot/logger"
 	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/flow"
+
+	"github.com/mattermost/mattermost-plugin-github/server/plugin/graphql"
 )
 
 const (
@@ -36,6 +37,7 @@ type OAuthState struct {
 	UserID         string `json:"user_id"`
 	Token          string `json:"token"`
 	PrivateAllowed bool   `json:"private_allowed"`
+	Instance       string `json:"instance,omitempty"`
 }
 
 type APIErrorResponse struct {
@@ -53,6 +55,7 @@ type PRDetails struct {
 	Number             int                         `json:"number"`
 	Status             string                      `json:"status"`
 	Mergeable          bool                        `json:"mergeable"`
+	ReviewDecision     string                      `json:"reviewDecision"`
 	RequestedReviewers []*string                   `json:"requestedReviewers"`
 	Reviews            []*github.PullRequestReview `json:"reviews"`
 }
@@ -63,10 +66,13 @@ type FilteredNotification struct {
 }
 
 type SidebarContent struct {
-	PRs         []*github.Issue         `json:"prs"`
-	Reviews     []*github.Issue         `json:"reviews"`
-	Assignments []*github.Issue         `json:"assignments"`
-	Unreads     []*FilteredNotification `json:"unreads"`
+	PRs              []*graphql.LHSItem      `json:"prs"`
+	Reviews          []*graphql.LHSItem      `json:"reviews"`
+	Assignments      []*graphql.LHSItem      `json:"assignments"`
+	Unreads          []*FilteredNotification `json:"unreads"`
+	PRsTotal         int                     `json:"prs_total"`
+	ReviewsTotal     int                     `json:"reviews_total"`
+	AssignmentsTotal int                     `json:"assignments_total"`
 }
 
 type Context struct {
@@ -138,6 +144,7 @@ func (p *Plugin) initializeAPI() {
 	apiRouter.Use(p.checkConfigured)
 
 	p.router.HandleFunc("/webhook", p.handleWebhook).Methods(http.MethodPost)
+	p.router.HandleFunc("/webhook/{instance}", p.handleWebhook).Methods(http.MethodPost)
 
 	oauthRouter.HandleFunc("/connect", p.checkAuth(p.attachContext(p.connectUserToGitHub), ResponseTypePlain)).Methods(http.MethodGet)
 	oauthRouter.HandleFunc("/complete", p.checkAuth(p.attachContext(p.completeConnectUserToGitHub), ResponseTypePlain)).Methods(http.MethodGet)
@@ -154,11 +161,17 @@ func (p *Plugin) initializeAPI() {
 	apiRouter.HandleFunc("/labels", p.checkAuth(p.attachUserContext(p.getLabels), ResponseTypePlain)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/milestones", p.checkAuth(p.attachUserContext(p.getMilestones), ResponseTypePlain)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/assignees", p.checkAuth(p.attachUserContext(p.getAssignees), ResponseTypePlain)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/autocomplete/labels", p.checkAuth(p.attachUserContext(p.getLabelsAutocomplete), ResponseTypeJSON)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/autocomplete/assignees", p.checkAuth(p.attachUserContext(p.getAssigneesAutocomplete), ResponseTypeJSON)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/autocomplete/milestones", p.checkAuth(p.attachUserContext(p.getMilestonesAutocomplete), ResponseTypeJSON)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/repositories", p.checkAuth(p.attachUserContext(p.getRepositories), ResponseTypePlain)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/channel-repo", p.checkAuth(p.attachUserContext(p.getChannelRepo), ResponseTypePlain)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/settings", p.checkAuth(p.attachUserContext(p.updateSettings), ResponseTypePlain)).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/issue", p.checkAuth(p.attachUserContext(p.getIssueByNumber), ResponseTypePlain)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/pr", p.checkAuth(p.attachUserContext(p.getPrByNumber), ResponseTypePlain)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/lhs-content", p.checkAuth(p.attachUserContext(p.getSidebarContent), ResponseTypePlain)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/post-action", p.checkAuth(p.attachContext(p.handlePostAction), ResponseTypeJSON)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/post-action/label", p.checkAuth(p.attachContext(p.handlePostActionAddLabel), ResponseTypeJSON)).Methods(http.MethodPost)
 
 	apiRouter.HandleFunc("/config", checkPluginRequest(p.getConfig)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/token", checkPluginRequest(p.getToken)).Methods(http.MethodGet)
@@ -288,15 +301,23 @@ func (p *Plugin) connectUserToGitHub(c *Context, w http.ResponseWriter, r *http.
 		privateAllowed = true
 	}
 
-	conf := p.getOAuthConfig(privateAllowed)
+	instance := r.URL.Query().Get("instance")
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conf := p.getOAuthConfigWithConfig(config, privateAllowed)
 
 	state := OAuthState{
 		UserID:         c.UserID,
 		Token:          model.NewId()[:15],
 		PrivateAllowed: privateAllowed,
+		Instance:       instance,
 	}
 
-	_, err := p.store.Set(githubOauthKey+state.Token, state, pluginapi.SetExpiry(TokenTTL))
+	_, err = p.store.Set(githubOauthKey+state.Token, state, pluginapi.SetExpiry(TokenTTL))
 	if err != nil {
 		http.Error(w, "error setting stored state", http.StatusBadRequest)
 		return
@@ -382,7 +403,14 @@ func (p *Plugin) completeConnectUserToGitHub(c *Context, w http.ResponseWriter,
 		return
 	}
 
-	conf := p.getOAuthConfig(state.PrivateAllowed)
+	config, err := p.getConfiguration().forInstance(state.Instance)
+	if err != nil {
+		rErr = err
+		http.Error(w, rErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conf := p.getOAuthConfigWithConfig(config, state.PrivateAllowed)
 
 	ctx, cancel := context.WithTimeout(context.Background(), oauthCompleteTimeout)
 	defer cancel()
@@ -396,45 +424,87 @@ func (p *Plugin) completeConnectUserToGitHub(c *Context, w http.ResponseWriter,
 		return
 	}
 
-	githubClient := p.githubConnectToken(*tok)
-	gitUser, _, err := githubClient.Users.Get(ctx, "")
+	if rErr = p.connectGitHubAccount(c, tok, state.Instance, state.PrivateAllowed); rErr != nil {
+		http.Error(w, rErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	html := `
+			<!DOCTYPE html>
+			<html>
+			<head>
+			<script>
+			window.close();
+			</script>
+			</head>
+			<body>
+			<p>Completed connecting to GitHub. Please close this window.</p>
+			</body>
+			</html>
+			`
+
+	w.Header().Set("Content-Type", "text/html")
+	_, err = w.Write([]byte(html))
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to write HTML response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// connectGitHubAccount stores the token of the user connecting their GitHub account and welcomes them.
+func (p *Plugin) connectGitHubAccount(c *Context, tok *oauth2.Token, instance string, privateAllowed bool) error {
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		return err
+	}
+
+	githubClient := p.githubConnectTokenWithConfig(*tok, config)
+	gitUser, _, err := githubClient.Users.Get(c.Ctx, "")
 	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to get authenticated GitHub user")
 
-		rErr = errors.Wrap(err, "failed to get authenticated GitHub user")
-		http.Error(w, rErr.Error(), http.StatusInternalServerError)
-		return
+		return errors.Wrap(err, "failed to get authenticated GitHub user")
 	}
 
 	// track the successful connection
 	p.TrackUserEvent("account_connected", c.UserID, nil)
 
 	userInfo := &GitHubUserInfo{
-		UserID:         state.UserID,
+		UserID:         c.UserID,
 		Token:          tok,
 		GitHubUsername: gitUser.GetLogin(),
 		LastToDoPostAt: model.GetMillis(),
 		Settings: &UserSettings{
 			SidebarButtons: settingButtonsTeam,
-			DailyReminder:  true,
 			Notifications:  true,
 		},
-		AllowedPrivateRepos:   state.PrivateAllowed,
+		AllowedPrivateRepos:   privateAllowed,
 		MM34646ResetTokenDone: true,
+		Instance:              instance,
 	}
 
 	if err = p.storeGitHubUserInfo(userInfo); err != nil {
 		c.Log.WithError(err).Warnf("Failed to store GitHub user info")
 
-		rErr = errors.Wrap(err, "Unable to connect user to GitHub")
-		http.Error(w, rErr.Error(), http.StatusInternalServerError)
-		return
+		return errors.Wrap(err, "Unable to connect user to GitHub")
+	}
+
+	// notify the user again if the new token can't be refreshed.
+	if err = p.store.Delete(getTokenRefreshFailedKey(c.UserID, instance)); err != nil {
+		c.Log.WithError(err).Warnf("Failed to delete the token refresh failure")
 	}
 
-	if err = p.storeGitHubToUserIDMapping(gitUser.GetLogin(), state.UserID); err != nil {
+	if err = p.storeGitHubToUserIDMapping(gitUser.GetLogin(), instance, c.UserID); err != nil {
 		c.Log.WithError(err).Warnf("Failed to store GitHub user info mapping")
 	}
 
+	// the setup flow and the webapp only use the default instance.
+	if instance != "" {
+		p.CreateBotDMPost(c.UserID, fmt.Sprintf("You've connected your Mattermost account to [%s](%s) on the GitHub instance %s.", gitUser.GetLogin(), gitUser.GetHTMLURL(), instance), "custom_git_welcome")
+		return nil
+	}
+
 	flow := p.flowManager.setupFlow.ForUser(c.UserID)
 
 	stepName, err := flow.GetCurrentStep()
@@ -459,8 +529,8 @@ func (p *Plugin) completeConnectUserToGitHub(c *Context, w http.ResponseWriter,
 		message := fmt.Sprintf("#### Welcome to the Mattermost GitHub Plugin!\n"+
 			"You've connected your Mattermost account to [%s](%s) on GitHub. Read about the features of this plugin below:\n\n"+
 			"##### Daily Reminders\n"+
-			"The first time you log in each day, you'll get a post right here letting you know what messages you need to read and what pull requests are awaiting your review.\n"+
-			"Turn off reminders with `/github settings reminders off`.\n\n"+
+			"Turn on reminders with `/github settings reminders on` to get a post right here every weekday at 09:00 in your timezone, letting you know what messages you need to read and what pull requests are awaiting your review.\n"+
+			"Change the time with `/github settings reminders at 09:30 weekdays`, or turn off reminders with `/github settings reminders off`.\n\n"+
 			"##### Notifications\n"+
 			"When someone mentions you, requests your review, comments on or modifies one of your pull requests/issues, or assigns you, you'll get a post here about it.\n"+
 			"Turn off notifications with `/github settings notifications off`.\n\n"+
@@ -476,10 +546,9 @@ func (p *Plugin) completeConnectUserToGitHub(c *Context, w http.ResponseWriter,
 			"##### Slash Commands\n"+
 			commandHelp, gitUser.GetLogin(), gitUser.GetHTMLURL())
 
-		p.CreateBotDMPost(state.UserID, message, "custom_git_welcome")
+		p.CreateBotDMPost(c.UserID, message, "custom_git_welcome")
 	}
 
-	config := p.getConfiguration()
 	orgList := p.configuration.getOrganizations()
 	p.client.Frontend.PublishWebSocketEvent(
 		wsEventConnect,
@@ -491,30 +560,10 @@ func (p *Plugin) completeConnectUserToGitHub(c *Context, w http.ResponseWriter,
 			"organizations":       orgList,
 			"configuration":       config.ClientConfiguration(),
 		},
-		&model.WebsocketBroadcast{UserId: state.UserID},
+		&model.WebsocketBroadcast{UserId: c.UserID},
 	)
 
-	html := `
-			<!DOCTYPE html>
-			<html>
-			<head>
-			<script>
-			window.close();
-			</script>
-			</head>
-			<body>
-			<p>Completed connecting to GitHub. Please close this window.</p>
-			</body>
-			</html>
-			`
-
-	w.Header().Set("Content-Type", "text/html")
-	_, err = w.Write([]byte(html))
-	if err != nil {
-		c.Log.WithError(err).Warnf("Failed to write HTML response")
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
+	return nil
 }
 
 func (p *Plugin) getGitHubUser(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -594,30 +643,6 @@ func (p *Plugin) getConnected(c *Context, w http.ResponseWriter, r *http.Request
 	resp.GitHubClientID = config.GitHubOAuthClientID
 	resp.UserSettings = info.Settings
 
-	if info.Settings.DailyReminder && r.URL.Query().Get("reminder") == "true" {
-		lastPostAt := info.LastToDoPostAt
-
-		var timezone *time.Location
-		offset, _ := strconv.Atoi(r.Header.Get("X-Timezone-Offset"))
-		timezone = time.FixedZone("local", -60*offset)
-
-		// Post to do message if it's the next day and been more than an hour since the last post
-		now := model.GetMillis()
-		nt := time.Unix(now/1000, 0).In(timezone)
-		lt := time.Unix(lastPostAt/1000, 0).In(timezone)
-		if nt.Sub(lt).Hours() >= 1 && (nt.Day() != lt.Day() || nt.Month() != lt.Month() || nt.Year() != lt.Year()) {
-			if p.HasUnreads(info) {
-				if err := p.PostToDo(info, c.UserID); err != nil {
-					c.Log.WithError(err).Warnf("Failed to create GitHub todo message")
-				}
-				info.LastToDoPostAt = now
-				if err := p.storeGitHubUserInfo(info); err != nil {
-					c.Log.WithError(err).Warnf("Failed to store github info for new user")
-				}
-			}
-		}
-	}
-
 	privateRepoStoreKey := info.UserID + githubPrivateRepoKey
 	if config.EnablePrivateRepo && !info.AllowedPrivateRepos {
 		var val []byte
@@ -678,17 +703,9 @@ func (p *Plugin) getUnreadsData(c *UserContext) []*FilteredNotification {
 			continue
 		}
 
-		issueURL := n.GetSubject().GetURL()
-		issueNumIndex := strings.LastIndex(issueURL, "/")
-		issueNum := issueURL[issueNumIndex+1:]
-		subjectURL := n.GetSubject().GetURL()
-		if n.GetSubject().GetLatestCommentURL() != "" {
-			subjectURL = n.GetSubject().GetLatestCommentURL()
-		}
-
 		filteredNotifications = append(filteredNotifications, &FilteredNotification{
 			Notification: *n,
-			HTMLURL:      fixGithubNotificationSubjectURL(subjectURL, issueNum),
+			HTMLURL:      getNotificationHTMLURL(n),
 		})
 	}
 
@@ -696,8 +713,6 @@ func (p *Plugin) getUnreadsData(c *UserContext) []*FilteredNotification {
 }
 
 func (p *Plugin) getPrsDetails(c *UserContext, w http.ResponseWriter, r *http.Request) {
-	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
-
 	var prList []*PRDetails
 	if err := json.NewDecoder(r.Body).Decode(&prList); err != nil {
 		c.Log.WithError(err).Warnf("Error decoding PRDetails JSON body")
@@ -705,89 +720,54 @@ func (p *Plugin) getPrsDetails(c *UserContext, w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	prDetails := make([]*PRDetails, len(prList))
-	var wg sync.WaitGroup
-	for i, pr := range prList {
-		i := i
-		pr := pr
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			prDetail := p.fetchPRDetails(c, githubClient, pr.URL, pr.Number)
-			prDetails[i] = prDetail
-		}()
+	config, err := p.getConfiguration().forInstance(c.GHInfo.Instance)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to get the configuration of the GitHub instance")
+		p.writeAPIError(w, &APIErrorResponse{ID: "", Message: "Failed to get the configuration of the GitHub instance.", StatusCode: http.StatusInternalServerError})
+		return
 	}
 
-	wg.Wait()
-
-	p.writeJSON(w, prDetails)
-}
-
-func (p *Plugin) fetchPRDetails(c *UserContext, client *github.Client, prURL string, prNumber int) *PRDetails {
-	var status string
-	var mergeable bool
-	// Initialize to a non-nil slice to simplify JSON handling semantics
-	requestedReviewers := []*string{}
-	reviewsList := []*github.PullRequestReview{}
-
-	repoOwner, repoName := getRepoOwnerAndNameFromURL(prURL)
+	graphQLClient := p.graphQLConnect(c.GHInfo)
+	if graphQLClient == nil {
+		p.writeAPIError(w, &APIErrorResponse{ID: "", Message: "Failed to connect to GitHub.", StatusCode: http.StatusInternalServerError})
+		return
+	}
 
-	var wg sync.WaitGroup
+	// the webapp identifies the pull requests by their repository API URL, GraphQL by their web URL.
+	prURLs := make([]string, len(prList))
+	for i, pr := range prList {
+		repoOwner, repoName := getRepoOwnerAndNameFromURL(pr.URL)
+		prURLs[i] = fmt.Sprintf("%s%s/%s/pull/%d", config.getBaseURL(), repoOwner, repoName, pr.Number)
+	}
 
-	// Fetch reviews
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		fetchedReviews, err := fetchReviews(c, client, repoOwner, repoName, prNumber)
-		if err != nil {
-			c.Log.WithError(err).Warnf("Failed to fetch reviews for PR details")
-			return
-		}
-		reviewsList = fetchedReviews
-	}()
+	// the pull requests whose details couldn't be fetched are still listed, without their details.
+	details, err := graphQLClient.GetPRDetails(c.Ctx, prURLs)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to fetch PR details")
+	}
 
-	// Fetch reviewers and status
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		prInfo, _, err := client.PullRequests.Get(c.Ctx, repoOwner, repoName, prNumber)
-		if err != nil {
-			c.Log.WithError(err).Warnf("Failed to fetch PR for PR details")
-			return
+	prDetails := make([]*PRDetails, len(prList))
+	for i, pr := range prList {
+		prDetails[i] = &PRDetails{
+			URL:    pr.URL,
+			Number: pr.Number,
+			// Initialize to non-nil slices to simplify JSON handling semantics
+			RequestedReviewers: []*string{},
+			Reviews:            []*github.PullRequestReview{},
 		}
 
-		mergeable = prInfo.GetMergeable()
-
-		for _, v := range prInfo.RequestedReviewers {
-			requestedReviewers = append(requestedReviewers, v.Login)
-		}
-		statuses, _, err := client.Repositories.GetCombinedStatus(c.Ctx, repoOwner, repoName, prInfo.GetHead().GetSHA(), nil)
-		if err != nil {
-			c.Log.WithError(err).Warnf("Failed to fetch combined status")
-			return
+		if details[i] == nil {
+			continue
 		}
-		status = *statuses.State
-	}()
 
-	wg.Wait()
-	return &PRDetails{
-		URL:                prURL,
-		Number:             prNumber,
-		Status:             status,
-		Mergeable:          mergeable,
-		RequestedReviewers: requestedReviewers,
-		Reviews:            reviewsList,
+		prDetails[i].Status = details[i].Status
+		prDetails[i].Mergeable = details[i].Mergeable
+		prDetails[i].ReviewDecision = details[i].ReviewDecision
+		prDetails[i].RequestedReviewers = details[i].RequestedReviewers
+		prDetails[i].Reviews = details[i].Reviews
 	}
-}
-
-func fetchReviews(c *UserContext, client *github.Client, repoOwner string, repoName string, number int) ([]*github.PullRequestReview, error) {
-	reviewsList, _, err := client.PullRequests.ListReviews(c.Ctx, repoOwner, repoName, number, nil)
 
-	if err != nil {
-		return []*github.PullRequestReview{}, errors.Wrap(err, "could not list reviews")
-	}
-
-	return reviewsList, nil
+	p.writeJSON(w, prDetails)
 }
 
 func getRepoOwnerAndNameFromURL(url string) (string, string) {
@@ -915,7 +895,7 @@ func (p *Plugin) createIssueComment(c *UserContext, w http.ResponseWriter, r *ht
 		if rawResponse != nil {
 			statusCode = rawResponse.StatusCode
 		}
-		p.writeAPIError(w, &APIErrorResponse{ID: "", Message: "failed to create an issue comment: " + getFailReason(statusCode, req.Repo, currentUsername), StatusCode: statusCode})
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{ID: "", Message: "failed to create an issue comment: " + getFailReason(statusCode, req.Repo, currentUsername), StatusCode: statusCode})
 		return
 	}
 
@@ -942,28 +922,25 @@ func (p *Plugin) createIssueComment(c *UserContext, w http.ResponseWriter, r *ht
 	p.writeJSON(w, result)
 }
 
-func (p *Plugin) getLHSData(c *UserContext) (reviewResp []*github.Issue, assignmentResp []*github.Issue, openPRResp []*github.Issue, err error) {
+func (p *Plugin) getSidebarData(c *UserContext) (*SidebarContent, error) {
 	graphQLClient := p.graphQLConnect(c.GHInfo)
-
-	reviewResp, assignmentResp, openPRResp, err = graphQLClient.GetLHSData(c.Context.Ctx)
-	if err != nil {
-		return []*github.Issue{}, []*github.Issue{}, []*github.Issue{}, err
+	if graphQLClient == nil {
+		return nil, errors.New("failed to create the GraphQL client")
 	}
 
-	return reviewResp, assignmentResp, openPRResp, nil
-}
-
-func (p *Plugin) getSidebarData(c *UserContext) (*SidebarContent, error) {
-	reviewResp, assignmentResp, openPRResp, err := p.getLHSData(c)
+	data, err := graphQLClient.GetLHSData(c.Context.Ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	return &SidebarContent{
-		PRs:         openPRResp,
-		Assignments: assignmentResp,
-		Reviews:     reviewResp,
-		Unreads:     p.getUnreadsData(c),
+		PRs:              data.OpenPRs,
+		Assignments:      data.Assignments,
+		Reviews:          data.Reviews,
+		Unreads:          p.getUnreadsData(c),
+		PRsTotal:         data.OpenPRsTotal,
+		ReviewsTotal:     data.ReviewsTotal,
+		AssignmentsTotal: data.AssignmentsTotal,
 	}, nil
 }
 
@@ -978,13 +955,10 @@ func (p *Plugin) getSidebarContent(c *UserContext, w http.ResponseWriter, r *htt
 }
 
 func (p *Plugin) postToDo(c *UserContext, w http.ResponseWriter, r *http.Request) {
-	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
-	username := c.GHInfo.GitHubUsername
-
-	text, err := p.GetToDo(c.Ctx, username, githubClient)
+	text, err := p.GetToDo(c.Ctx, c.GHInfo)
 	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to get Todos")
-		p.writeAPIError(w, &APIErrorResponse{ID: "", Message: "Encountered an error getting the to do items.", StatusCode: http.StatusUnauthorized})
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{ID: "", Message: "Encountered an error getting the to do items.", StatusCode: http.StatusUnauthorized})
 		return
 	}
 
@@ -1010,16 +984,25 @@ func (p *Plugin) updateSettings(c *UserContext, w http.ResponseWriter, r *http.R
 		return
 	}
 
-	info := c.GHInfo
-	info.Settings = settings
-
-	if err := p.storeGitHubUserInfo(info); err != nil {
+	err := p.updateGitHubUserInfo(c.UserID, c.GHInfo.Instance, func(info *GitHubUserInfo) {
+		info.Settings = settings
+	})
+	if err != nil {
 		c.Log.WithError(err).Warnf("Failed to store GitHub user info")
 		http.Error(w, "Encountered error updating settings", http.StatusInternalServerError)
 		return
 	}
 
-	p.writeJSON(w, info.Settings)
+	// the daily reminders only use the default instance.
+	if c.GHInfo.Instance == "" {
+		if err = p.setUserIndexed(reminderUsersKey, c.UserID, settings.DailyReminder); err != nil {
+			c.Log.WithError(err).Warnf("Failed to update the users of the daily reminders")
+			http.Error(w, "Encountered error updating settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	p.writeJSON(w, settings)
 }
 
 func (p *Plugin) getIssueByNumber(c *UserContext, w http.ResponseWriter, r *http.Request) {
@@ -1054,7 +1037,7 @@ func (p *Plugin) getIssueByNumber(c *UserContext, w http.ResponseWriter, r *http
 			"repo":   repo,
 			"number": numberInt,
 		}).Debugf("Could not get issue")
-		p.writeAPIError(w, &APIErrorResponse{Message: "Could not get issue", StatusCode: http.StatusInternalServerError})
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Could not get issue", StatusCode: http.StatusInternalServerError})
 		return
 	}
 	if result.Body != nil {
@@ -1097,7 +1080,7 @@ func (p *Plugin) getPrByNumber(c *UserContext, w http.ResponseWriter, r *http.Re
 			"repo":   repo,
 			"number": numberInt,
 		}).Debugf("Could not get pull request")
-		p.writeAPIError(w, &APIErrorResponse{Message: "Could not get pull request", StatusCode: http.StatusInternalServerError})
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Could not get pull request", StatusCode: http.StatusInternalServerError})
 		return
 	}
 	if result.Body != nil {
@@ -1113,16 +1096,61 @@ func (p *Plugin) getLabels(c *UserContext, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
+	allLabels, err := listRepoLabels(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list labels")
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Failed to fetch labels", StatusCode: http.StatusInternalServerError})
+		return
+	}
+
+	p.writeJSON(w, allLabels)
+}
+
+func (p *Plugin) getAssignees(c *UserContext, w http.ResponseWriter, r *http.Request) {
+	owner, repo, err := parseRepo(r.URL.Query().Get("repo"))
+	if err != nil {
+		p.writeAPIError(w, &APIErrorResponse{Message: err.Error(), StatusCode: http.StatusBadRequest})
+		return
+	}
+
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
+	allAssignees, err := listRepoAssignees(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list assignees")
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Failed to fetch assignees", StatusCode: http.StatusInternalServerError})
+		return
+	}
+
+	p.writeJSON(w, allAssignees)
+}
+
+func (p *Plugin) getMilestones(c *UserContext, w http.ResponseWriter, r *http.Request) {
+	owner, repo, err := parseRepo(r.URL.Query().Get("repo"))
+	if err != nil {
+		p.writeAPIError(w, &APIErrorResponse{Message: err.Error(), StatusCode: http.StatusBadRequest})
+		return
+	}
+
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
+	allMilestones, err := listRepoMilestones(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list milestones")
+		p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Failed to fetch milestones", StatusCode: http.StatusInternalServerError})
+		return
+	}
+
+	p.writeJSON(w, allMilestones)
+}
+
+func listRepoLabels(ctx context.Context, githubClient *github.Client, owner, repo string) ([]*github.Label, error) {
 	var allLabels []*github.Label
 	opt := github.ListOptions{PerPage: 50}
 
 	for {
-		labels, resp, err := githubClient.Issues.ListLabels(c.Ctx, owner, repo, &opt)
+		labels, resp, err := githubClient.Issues.ListLabels(ctx, owner, repo, &opt)
 		if err != nil {
-			c.Log.WithError(err).Warnf("Failed to list labels")
-			p.writeAPIError(w, &APIErrorResponse{Message: "Failed to fetch labels", StatusCode: http.StatusInternalServerError})
-			return
+			return nil, err
 		}
 		allLabels = append(allLabels, labels...)
 		if resp.NextPage == 0 {
@@ -1131,26 +1159,17 @@ func (p *Plugin) getLabels(c *UserContext, w http.ResponseWriter, r *http.Reques
 		opt.Page = resp.NextPage
 	}
 
-	p.writeJSON(w, allLabels)
+	return allLabels, nil
 }
 
-func (p *Plugin) getAssignees(c *UserContext, w http.ResponseWriter, r *http.Request) {
-	owner, repo, err := parseRepo(r.URL.Query().Get("repo"))
-	if err != nil {
-		p.writeAPIError(w, &APIErrorResponse{Message: err.Error(), StatusCode: http.StatusBadRequest})
-		return
-	}
-
-	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
+func listRepoAssignees(ctx context.Context, githubClient *github.Client, owner, repo string) ([]*github.User, error) {
 	var allAssignees []*github.User
 	opt := github.ListOptions{PerPage: 50}
 
 	for {
-		assignees, resp, err := githubClient.Issues.ListAssignees(c.Ctx, owner, repo, &opt)
+		assignees, resp, err := githubClient.Issues.ListAssignees(ctx, owner, repo, &opt)
 		if err != nil {
-			c.Log.WithError(err).Warnf("Failed to list assignees")
-			p.writeAPIError(w, &APIErrorResponse{Message: "Failed to fetch assignees", StatusCode: http.StatusInternalServerError})
-			return
+			return nil, err
 		}
 		allAssignees = append(allAssignees, assignees...)
 		if resp.NextPage == 0 {
@@ -1159,26 +1178,17 @@ func (p *Plugin) getAssignees(c *UserContext, w http.ResponseWriter, r *http.Req
 		opt.Page = resp.NextPage
 	}
 
-	p.writeJSON(w, allAssignees)
+	return allAssignees, nil
 }
 
-func (p *Plugin) getMilestones(c *UserContext, w http.ResponseWriter, r *http.Request) {
-	owner, repo, err := parseRepo(r.URL.Query().Get("repo"))
-	if err != nil {
-		p.writeAPIError(w, &APIErrorResponse{Message: err.Error(), StatusCode: http.StatusBadRequest})
-		return
-	}
-
-	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
+func listRepoMilestones(ctx context.Context, githubClient *github.Client, owner, repo string) ([]*github.Milestone, error) {
 	var allMilestones []*github.Milestone
 	opt := github.ListOptions{PerPage: 50}
 
 	for {
-		milestones, resp, err := githubClient.Issues.ListMilestones(c.Ctx, owner, repo, &github.MilestoneListOptions{ListOptions: opt})
+		milestones, resp, err := githubClient.Issues.ListMilestones(ctx, owner, repo, &github.MilestoneListOptions{ListOptions: opt})
 		if err != nil {
-			c.Log.WithError(err).Warnf("Failed to list milestones")
-			p.writeAPIError(w, &APIErrorResponse{Message: "Failed to fetch milestones", StatusCode: http.StatusInternalServerError})
-			return
+			return nil, err
 		}
 		allMilestones = append(allMilestones, milestones...)
 		if resp.NextPage == 0 {
@@ -1187,7 +1197,118 @@ func (p *Plugin) getMilestones(c *UserContext, w http.ResponseWriter, r *http.Re
 		opt.Page = resp.NextPage
 	}
 
-	p.writeJSON(w, allMilestones)
+	return allMilestones, nil
+}
+
+// getAutocompleteRepo returns the repository referenced by the slash command typed so far,
+// falling back to the default repository of the channel.
+func (p *Plugin) getAutocompleteRepo(r *http.Request) (owner, repo string, err error) {
+	for _, field := range strings.Fields(r.URL.Query().Get("parsed")) {
+		if owner, repo, _, err = parseIssueReference(field); err == nil {
+			return owner, repo, nil
+		}
+	}
+
+	defaultRepo, err := p.getChannelDefaultRepo(r.URL.Query().Get("channel_id"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return parseRepo(defaultRepo)
+}
+
+// filterAutocompleteItems returns the items matching the text the user is typing.
+func filterAutocompleteItems(items []model.AutocompleteListItem, userInput string) []model.AutocompleteListItem {
+	userInput = strings.ToLower(strings.Trim(userInput, `"`))
+	filtered := []model.AutocompleteListItem{}
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(strings.Trim(item.Item, `"`)), userInput) || strings.Contains(strings.ToLower(item.HelpText), userInput) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered
+}
+
+func (p *Plugin) getLabelsAutocomplete(c *UserContext, w http.ResponseWriter, r *http.Request) {
+	items := []model.AutocompleteListItem{}
+	owner, repo, err := p.getAutocompleteRepo(r)
+	if err != nil {
+		p.writeJSON(w, items)
+		return
+	}
+
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
+	labels, err := listRepoLabels(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list labels")
+		p.writeJSON(w, items)
+		return
+	}
+
+	for _, label := range labels {
+		name := label.GetName()
+		if strings.Contains(name, " ") {
+			name = strconv.Quote(name)
+		}
+		items = append(items, model.AutocompleteListItem{
+			Item:     name,
+			HelpText: label.GetDescription(),
+		})
+	}
+
+	p.writeJSON(w, filterAutocompleteItems(items, r.URL.Query().Get("user_input")))
+}
+
+func (p *Plugin) getAssigneesAutocomplete(c *UserContext, w http.ResponseWriter, r *http.Request) {
+	items := []model.AutocompleteListItem{}
+	owner, repo, err := p.getAutocompleteRepo(r)
+	if err != nil {
+		p.writeJSON(w, items)
+		return
+	}
+
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
+	assignees, err := listRepoAssignees(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list assignees")
+		p.writeJSON(w, items)
+		return
+	}
+
+	for _, assignee := range assignees {
+		items = append(items, model.AutocompleteListItem{
+			Item: assignee.GetLogin(),
+		})
+	}
+
+	p.writeJSON(w, filterAutocompleteItems(items, r.URL.Query().Get("user_input")))
+}
+
+func (p *Plugin) getMilestonesAutocomplete(c *UserContext, w http.ResponseWriter, r *http.Request) {
+	items := []model.AutocompleteListItem{}
+	owner, repo, err := p.getAutocompleteRepo(r)
+	if err != nil {
+		p.writeJSON(w, items)
+		return
+	}
+
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
+	milestones, err := listRepoMilestones(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to list milestones")
+		p.writeJSON(w, items)
+		return
+	}
+
+	for _, milestone := range milestones {
+		items = append(items, model.AutocompleteListItem{
+			Item:     strconv.Itoa(milestone.GetNumber()),
+			HelpText: milestone.GetTitle(),
+		})
+	}
+
+	p.writeJSON(w, filterAutocompleteItems(items, r.URL.Query().Get("user_input")))
 }
 
 func getRepositoryList(c context.Context, userName string, githubClient *github.Client, opt github.ListOptions) ([]*github.Repository, error) {
@@ -1228,19 +1349,19 @@ func getRepositoryListByOrg(c context.Context, org string, githubClient *github.
 }
 
 func (p *Plugin) getRepositories(c *UserContext, w http.ResponseWriter, r *http.Request) {
-	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
+	githubClient := p.githubConnectUserWithCache(c.Context.Ctx, c.GHInfo)
 	org := p.getConfiguration().GitHubOrg
 
 	var allRepos []*github.Repository
 	var err error
 
-	opt := github.ListOptions{PerPage: 50}
+	opt := github.ListOptions{PerPage: 100}
 
 	if org == "" {
 		allRepos, err = getRepositoryList(c.Ctx, "", githubClient, opt)
 		if err != nil {
 			c.Log.WithError(err).Warnf("Failed to list repositories")
-			p.writeAPIError(w, &APIErrorResponse{Message: "Failed to fetch repositories", StatusCode: http.StatusInternalServerError})
+			p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Failed to fetch repositories", StatusCode: http.StatusInternalServerError})
 			return
 		}
 	} else {
@@ -1252,12 +1373,12 @@ func (p *Plugin) getRepositories(c *UserContext, w http.ResponseWriter, r *http.
 					orgRepos, err = getRepositoryList(c.Ctx, org, githubClient, opt)
 					if err != nil {
 						c.Log.WithError(err).Warnf("Failed to list repositories", "Organization", org)
-						p.writeAPIError(w, &APIErrorResponse{Message: "Failed to fetch repositories", StatusCode: http.StatusInternalServerError})
+						p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Failed to fetch repositories", StatusCode: http.StatusInternalServerError})
 						return
 					}
 				} else {
 					c.Log.WithError(err).Warnf("Failed to list repositories", "Organization", org)
-					p.writeAPIError(w, &APIErrorResponse{Message: "Failed to fetch repositories", StatusCode: http.StatusInternalServerError})
+					p.writeGitHubAPIError(w, c.GHInfo, err, &APIErrorResponse{Message: "Failed to fetch repositories", StatusCode: http.StatusInternalServerError})
 					return
 				}
 			}
@@ -1285,6 +1406,24 @@ func (p *Plugin) getRepositories(c *UserContext, w http.ResponseWriter, r *http.
 	p.writeJSON(w, resp)
 }
 
+// getChannelRepo returns the default repository of a channel, used to preselect it when creating an issue.
+func (p *Plugin) getChannelRepo(c *UserContext, w http.ResponseWriter, r *http.Request) {
+	channelID := r.URL.Query().Get("channel_id")
+	if !p.client.User.HasPermissionToChannel(c.UserID, channelID, model.PermissionReadChannel) {
+		p.writeAPIError(w, &APIErrorResponse{Message: "You don't have access to this channel.", StatusCode: http.StatusForbidden})
+		return
+	}
+
+	repo, err := p.getChannelDefaultRepo(channelID)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to get the channel default repository")
+		p.writeAPIError(w, &APIErrorResponse{Message: "Failed to get the default repository of the channel.", StatusCode: http.StatusInternalServerError})
+		return
+	}
+
+	p.writeJSON(w, map[string]string{"repo": repo})
+}
+
 func (p *Plugin) createIssue(c *UserContext, w http.ResponseWriter, r *http.Request) {
 	type IssueRequest struct {
 		Title     string   `json:"title"`
@@ -1378,6 +1517,11 @@ func (p *Plugin) createIssue(c *UserContext, w http.ResponseWriter, r *http.Requ
 	githubClient := p.githubConnectUser(c.Context.Ctx, c.GHInfo)
 	result, resp, err := githubClient.Issues.Create(c.Ctx, owner, repoName, ghIssue)
 	if err != nil {
+		if errors.Is(err, errGitHubRateLimited) {
+			p.writeGitHubAPIError(w, c.GHInfo, err, nil)
+			return
+		}
+
 		if resp != nil && resp.Response.StatusCode == http.StatusGone {
 			p.writeAPIError(w, &APIErrorResponse{ID: "", Message: "Issues are disabled on this repository.", StatusCode: http.StatusMethodNotAllowed})
 			return
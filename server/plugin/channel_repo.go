@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// getChannelDefaultRepo returns the repository used by commands run in the channel when none is given,
+// or an empty string if no default repository was set.
+func (p *Plugin) getChannelDefaultRepo(channelID string) (string, error) {
+	var repo []byte
+	if err := p.store.Get(channelID+channelDefaultRepoKey, &repo); err != nil {
+		return "", errors.Wrap(err, "failed to get the channel default repository")
+	}
+
+	return string(repo), nil
+}
+
+func (p *Plugin) setChannelDefaultRepo(channelID, repo string) error {
+	if _, err := p.store.Set(channelID+channelDefaultRepoKey, []byte(repo)); err != nil {
+		return errors.Wrap(err, "failed to store the channel default repository")
+	}
+
+	return nil
+}
+
+func (p *Plugin) deleteChannelDefaultRepo(channelID string) error {
+	if err := p.store.Delete(channelID + channelDefaultRepoKey); err != nil {
+		return errors.Wrap(err, "failed to delete the channel default repository")
+	}
+
+	return nil
+}
+
+// resolveIssueReference parses an issue or pull request reference. Short references like #123 are resolved
+// against the default repository of the channel.
+func (p *Plugin) resolveIssueReference(channelID, ref string) (owner, repo string, number int, err error) {
+	numberPart := strings.TrimPrefix(strings.TrimSpace(ref), "#")
+	if _, convErr := strconv.Atoi(numberPart); convErr != nil {
+		return parseIssueReference(ref)
+	}
+
+	defaultRepo, err := p.getChannelDefaultRepo(channelID)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if defaultRepo == "" {
+		return "", "", 0, errors.New("this channel has no default repository, set one with `/github channel set-repo owner/repo` or use a full reference like owner/repo#123")
+	}
+
+	return parseIssueReference(defaultRepo + "#" + numberPart)
+}
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestResolveIssueReference(t *testing.T) {
+	p := NewPlugin()
+	p.store = &pluginapi.MemoryStore{}
+	require.NoError(t, p.setChannelDefaultRepo("channel1", "mattermost/mattermost-server"))
+
+	tests := []struct {
+		name          string
+		channelID     string
+		ref           string
+		wantOwner     string
+		wantRepo      string
+		wantNumber    int
+		expectedError bool
+	}{
+		{
+			name:       "full reference",
+			channelID:  "channel2",
+			ref:        "mattermost/mattermost-plugin-github#12",
+			wantOwner:  "mattermost",
+			wantRepo:   "mattermost-plugin-github",
+			wantNumber: 12,
+		},
+		{
+			name:       "short reference with a default repository",
+			channelID:  "channel1",
+			ref:        "#42",
+			wantOwner:  "mattermost",
+			wantRepo:   "mattermost-server",
+			wantNumber: 42,
+		},
+		{
+			name:       "number with a default repository",
+			channelID:  "channel1",
+			ref:        "42",
+			wantOwner:  "mattermost",
+			wantRepo:   "mattermost-server",
+			wantNumber: 42,
+		},
+		{
+			name:          "short reference without a default repository",
+			channelID:     "channel2",
+			ref:           "#42",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			owner, repo, number, err := p.resolveIssueReference(tc.channelID, tc.ref)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantOwner, owner)
+			assert.Equal(t, tc.wantRepo, repo)
+			assert.Equal(t, tc.wantNumber, number)
+		})
+	}
+
+	require.NoError(t, p.deleteChannelDefaultRepo("channel1"))
+	repo, err := p.getChannelDefaultRepo("channel1")
+	require.NoError(t, err)
+	assert.Empty(t, repo)
+}
+
+func TestHandleChannelSetRepo(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{GitHubOrg: "mattermost"})
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("HasPermissionToChannel", "admin", "channel1", model.PermissionManageChannelRoles).Return(true)
+	api.On("HasPermissionToChannel", "member", "channel1", model.PermissionManageChannelRoles).Return(false)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	userInfo := &GitHubUserInfo{UserID: "member", GitHubUsername: "gh-user"}
+
+	message := p.handleChannel(nil, &model.CommandArgs{UserId: "member", ChannelId: "channel1"}, []string{"set-repo", "mattermost/mattermost-server"}, userInfo)
+	assert.Equal(t, "Only the channel admins can change the default repository of the channel.", message)
+
+	message = p.handleChannel(nil, &model.CommandArgs{UserId: "member", ChannelId: "channel1"}, []string{"unset-repo"}, userInfo)
+	assert.Equal(t, "Only the channel admins can change the default repository of the channel.", message)
+
+	message = p.handleChannel(nil, &model.CommandArgs{UserId: "admin", ChannelId: "channel1"}, []string{"set-repo", "other/repo"}, userInfo)
+	assert.Contains(t, message, "Repository `other/repo` isn't supported")
+
+	repo, err := p.getChannelDefaultRepo("channel1")
+	require.NoError(t, err)
+	assert.Empty(t, repo)
+}
@@ -3,7 +3,10 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 	"unicode"
 
 	"github.com/google/go-github/v54/github"
@@ -54,6 +57,9 @@ var validFeatures = map[string]bool{
 
 type Features string
 
+// defaultSubscriptionFeatures are the features of a subscription made without the features flag.
+const defaultSubscriptionFeatures Features = "pulls,issues,creates,deletes"
+
 func (features Features) String() string {
 	return string(features)
 }
@@ -110,6 +116,31 @@ func checkFeatureConflict(fs []string) (bool, []string) {
 	return true, nil
 }
 
+// checkFeatures returns the message telling the user why the features can't be subscribed to, or an empty
+// message if they can.
+func checkFeatures(features Features) string {
+	fs := features.ToSlice()
+
+	ok, conflictingFs := checkFeatureConflict(fs)
+	if !ok {
+		if len(conflictingFs) == 2 {
+			return fmt.Sprintf("Feature list cannot contain both %s and %s", conflictingFs[0], conflictingFs[1])
+		}
+		return fmt.Sprintf("Conflicting feature(s) provided: %s", strings.Join(conflictingFs, ","))
+	}
+
+	ok, ifs := validateFeatures(fs)
+	if !ok {
+		msg := fmt.Sprintf("Invalid feature(s) provided: %s", strings.Join(ifs, ","))
+		if len(ifs) == 0 {
+			msg = "Feature list must have \"pulls\", \"issues\" or \"issue_creations\" when using a label."
+		}
+		return msg
+	}
+
+	return ""
+}
+
 func (p *Plugin) getCommand(config *Configuration) (*model.Command, error) {
 	iconData, err := command.GetIconData(&p.client.System, "assets/icon-bg.svg")
 	if err != nil {
@@ -119,7 +150,7 @@ func (p *Plugin) getCommand(config *Configuration) (*model.Command, error) {
 	return &model.Command{
 		Trigger:              "github",
 		AutoComplete:         true,
-		AutoCompleteDesc:     "Available commands: connect, disconnect, todo, subscriptions, issue, me, mute, settings, help, about",
+		AutoCompleteDesc:     "Available commands: connect, disconnect, todo, subscriptions, watch, issue, pr, channel, me, mute, settings, help, about",
 		AutoCompleteHint:     "[command]",
 		AutocompleteData:     getAutocompleteData(config),
 		AutocompleteIconData: iconData,
@@ -373,7 +404,7 @@ func (p *Plugin) checkIfConfiguredWebhookExists(ctx context.Context, githubClien
 
 func (p *Plugin) handleSubscribesAdd(_ *plugin.Context, args *model.CommandArgs, parameters []string, userInfo *GitHubUserInfo) string {
 	const errorNoWebhookFound = "\n**Note:** No webhook was found for this repository or organization. To create one, enter the following slash command `/github setup webhook`"
-	subscriptionEvents := Features("pulls,issues,creates,deletes")
+	subscriptionEvents := defaultSubscriptionFeatures
 	if len(parameters) == 0 {
 		return "Please specify a repository."
 	}
@@ -405,25 +436,26 @@ func (p *Plugin) handleSubscribesAdd(_ *plugin.Context, args *model.CommandArgs,
 			}
 		}
 
-		fs := subscriptionEvents.ToSlice()
-
-		ok, conflictingFs := checkFeatureConflict(fs)
+		if msg := checkFeatures(subscriptionEvents); msg != "" {
+			return msg
+		}
+	}
 
-		if !ok {
-			if len(conflictingFs) == 2 {
-				return fmt.Sprintf("Feature list cannot contain both %s and %s", conflictingFs[0], conflictingFs[1])
-			}
-			return fmt.Sprintf("Conflicting feature(s) provided: %s", strings.Join(conflictingFs, ","))
+	// subscriptions to an additional GitHub instance are made with the account connected to that instance.
+	if flags.Instance != "" {
+		instanceConfig, err := config.forInstance(flags.Instance)
+		if err != nil {
+			return fmt.Sprintf("Unknown GitHub instance `%s`.", flags.Instance)
 		}
 
-		ok, ifs := validateFeatures(fs)
-		if !ok {
-			msg := fmt.Sprintf("Invalid feature(s) provided: %s", strings.Join(ifs, ","))
-			if len(ifs) == 0 {
-				msg = "Feature list must have \"pulls\", \"issues\" or \"issue_creations\" when using a label."
-			}
-			return msg
+		instanceInfo, apiErr := p.getGitHubUserInfoForInstance(args.UserId, flags.Instance)
+		if apiErr != nil {
+			return fmt.Sprintf("You must connect your account to the `%s` GitHub instance first with `/github connect%s`.", flags.Instance, getInstanceCommandSuffix(flags.Instance))
 		}
+
+		config = instanceConfig
+		baseURL = config.getBaseURL()
+		userInfo = instanceInfo
 	}
 
 	ctx := context.Background()
@@ -434,7 +466,7 @@ func (p *Plugin) handleSubscribesAdd(_ *plugin.Context, args *model.CommandArgs,
 	}
 
 	owner, repo := parseOwnerAndRepo(parameters[0], baseURL)
-	previousSubscribedEvents, err := p.getSubscribedFeatures(args.ChannelId, owner, repo)
+	previousSubscribedEvents, err := p.getSubscribedFeatures(args.ChannelId, owner, repo, flags.Instance)
 	if err != nil {
 		return errors.Wrap(err, "failed to get the subscribed events").Error()
 	}
@@ -461,6 +493,14 @@ func (p *Plugin) handleSubscribesAdd(_ *plugin.Context, args *model.CommandArgs,
 
 		subOrgMsg := fmt.Sprintf("Successfully subscribed to organization %s.", owner)
 
+		// the events of a GitHub App are delivered to its own webhook, which only needs the app to be installed.
+		if config.IsGitHubAppConfigured() {
+			if note := p.getAppInstallationNote(ctx, owner, ""); note != "" {
+				return note
+			}
+			return subOrgMsg
+		}
+
 		found, foundErr := p.checkIfConfiguredWebhookExists(ctx, githubClient, repo, owner)
 		if foundErr != nil {
 			if strings.Contains(foundErr.Error(), "404 Not Found") {
@@ -501,6 +541,13 @@ func (p *Plugin) handleSubscribesAdd(_ *plugin.Context, args *model.CommandArgs,
 		return fmt.Sprintf("%s\nError creating the public post: %s", msg, err.Error())
 	}
 
+	if config.IsGitHubAppConfigured() {
+		if note := p.getAppInstallationNote(ctx, owner, repo); note != "" {
+			return note
+		}
+		return msg
+	}
+
 	found, err := p.checkIfConfiguredWebhookExists(ctx, githubClient, repo, owner)
 	if err != nil {
 		if strings.Contains(err.Error(), "404 Not Found") {
@@ -517,7 +564,7 @@ func (p *Plugin) handleSubscribesAdd(_ *plugin.Context, args *model.CommandArgs,
 	return msg
 }
 
-func (p *Plugin) getSubscribedFeatures(channelID, owner, repo string) (Features, error) {
+func (p *Plugin) getSubscribedFeatures(channelID, owner, repo, instance string) (Features, error) {
 	var previousFeatures Features
 	subs, err := p.GetSubscriptionsByChannel(channelID)
 	if err != nil {
@@ -530,7 +577,7 @@ func (p *Plugin) getSubscribedFeatures(channelID, owner, repo string) (Features,
 			fullRepoName = owner + "/" + repo
 		}
 
-		if sub.Repository == fullRepoName {
+		if sub.Repository == fullRepoName && sub.Flags.Instance == instance {
 			previousFeatures = sub.Features
 			return previousFeatures, nil
 		}
@@ -539,12 +586,21 @@ func (p *Plugin) getSubscribedFeatures(channelID, owner, repo string) (Features,
 	return previousFeatures, nil
 }
 func (p *Plugin) handleUnsubscribe(_ *plugin.Context, args *model.CommandArgs, parameters []string, _ *GitHubUserInfo) string {
+	instance, parameters, err := parseInstanceFlag(parameters)
+	if err != nil {
+		return err.Error()
+	}
+
 	if len(parameters) == 0 {
 		return "Please specify a repository."
 	}
 
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		return fmt.Sprintf("Unknown GitHub instance `%s`.", instance)
+	}
+
 	repo := parameters[0]
-	config := p.getConfiguration()
 	owner, repo := parseOwnerAndRepo(repo, config.getBaseURL())
 	if owner == "" && repo == "" {
 		return "invalid repository"
@@ -552,7 +608,7 @@ func (p *Plugin) handleUnsubscribe(_ *plugin.Context, args *model.CommandArgs, p
 
 	owner = strings.ToLower(owner)
 	repo = strings.ToLower(repo)
-	if err := p.Unsubscribe(args.ChannelId, repo, owner); err != nil {
+	if err := p.Unsubscribe(args.ChannelId, repo, owner, instance); err != nil {
 		p.client.Log.Warn("Failed to unsubscribe", "repo", repo, "error", err.Error())
 		return "Encountered an error trying to unsubscribe. Please try again."
 	}
@@ -586,15 +642,24 @@ func (p *Plugin) handleUnsubscribe(_ *plugin.Context, args *model.CommandArgs, p
 	return ""
 }
 
-func (p *Plugin) handleDisconnect(_ *plugin.Context, args *model.CommandArgs, _ []string, _ *GitHubUserInfo) string {
-	p.disconnectGitHubAccount(args.UserId)
+func (p *Plugin) handleDisconnect(_ *plugin.Context, args *model.CommandArgs, parameters []string, _ *GitHubUserInfo) string {
+	instance, _, err := parseInstanceFlag(parameters)
+	if err != nil {
+		return err.Error()
+	}
+	if err := p.disconnectGitHubAccountForInstance(args.UserId, instance); err != nil {
+		p.client.Log.Warn("Failed to disconnect the GitHub account", "userID", args.UserId, "instance", instance, "error", err.Error())
+		return "Failed to disconnect your GitHub account."
+	}
+	if instance != "" {
+		return fmt.Sprintf("Disconnected your GitHub account on the `%s` instance.", instance)
+	}
+
 	return "Disconnected your GitHub account."
 }
 
 func (p *Plugin) handleTodo(_ *plugin.Context, _ *model.CommandArgs, _ []string, userInfo *GitHubUserInfo) string {
-	githubClient := p.githubConnectUser(context.Background(), userInfo)
-
-	text, err := p.GetToDo(context.Background(), userInfo.GitHubUsername, githubClient)
+	text, err := p.GetToDo(context.Background(), userInfo)
 	if err != nil {
 		p.client.Log.Warn("Failed get get Todos", "error", err.Error())
 		return "Encountered an error getting your to do items."
@@ -611,6 +676,23 @@ func (p *Plugin) handleMe(_ *plugin.Context, _ *model.CommandArgs, _ []string, u
 	}
 
 	text := fmt.Sprintf("You are connected to GitHub as:\n# [![image](%s =40x40)](%s) [%s](%s)", gitUser.GetAvatarURL(), gitUser.GetHTMLURL(), gitUser.GetLogin(), gitUser.GetHTMLURL())
+
+	// the rate limit endpoint doesn't count against the rate limit.
+	limits, _, err := githubClient.RateLimits(context.Background())
+	if err != nil {
+		p.client.Log.Warn("Failed to get the rate limits", "userID", userInfo.UserID, "error", err.Error())
+		return text
+	}
+
+	location := time.UTC
+	if user, err := p.client.User.Get(userInfo.UserID); err == nil {
+		location = user.GetTimezoneLocation()
+	}
+
+	text += "\n##### Rate Limits\n"
+	text += formatRateLimit("API requests", limits.GetCore(), location)
+	text += formatRateLimit("Search requests", limits.GetSearch(), location)
+	text += formatRateLimit("GraphQL requests", limits.GetGraphQL(), location)
 	return text
 }
 
@@ -653,8 +735,38 @@ func (p *Plugin) handleSettings(_ *plugin.Context, _ *model.CommandArgs, paramet
 		case settingOnChange:
 			userInfo.Settings.DailyReminder = true
 			userInfo.Settings.DailyReminderOnChange = true
+		case settingAt:
+			if len(parameters) < 3 || len(parameters) > 4 {
+				return "Please specify the time of the reminder, e.g. `/github settings reminders at 09:30 weekdays`."
+			}
+
+			if _, err := time.Parse(reminderTimeLayout, parameters[2]); err != nil {
+				return "Invalid time. Please use the 24-hour format HH:MM, e.g. `09:30`."
+			}
+
+			days := reminderDaysWeekdays
+			if len(parameters) == 4 {
+				days = strings.ToLower(parameters[3])
+				if _, err := parseReminderDays(days); err != nil {
+					return "Invalid days. Accepted values are: \"daily\", \"weekdays\" or a list of days like \"mon,wed,fri\"."
+				}
+			}
+
+			userInfo.Settings.DailyReminder = true
+			userInfo.Settings.ReminderTime = parameters[2]
+			userInfo.Settings.ReminderDays = days
 		default:
-			return "Invalid value. Accepted values are: \"on\" or \"off\" or \"on-change\" ."
+			return "Invalid value. Accepted values are: \"on\" or \"off\" or \"on-change\" or \"at\"."
+		}
+	case settingToDo:
+		message, updated := p.updateToDoSettings(userInfo.Settings, parameters[1:])
+		if !updated {
+			return message
+		}
+	case settingInbox:
+		message, updated := p.updateInboxSettings(userInfo.UserID, userInfo.Settings, parameters[1:])
+		if !updated {
+			return message
 		}
 	default:
 		return "Unknown setting " + setting
@@ -662,7 +774,7 @@ func (p *Plugin) handleSettings(_ *plugin.Context, _ *model.CommandArgs, paramet
 
 	if setting == settingNotifications {
 		if userInfo.Settings.Notifications {
-			err := p.storeGitHubToUserIDMapping(userInfo.GitHubUsername, userInfo.UserID)
+			err := p.storeGitHubToUserIDMapping(userInfo.GitHubUsername, userInfo.Instance, userInfo.UserID)
 			if err != nil {
 				p.client.Log.Warn("Failed to store GitHub to userID mapping",
 					"userID", userInfo.UserID,
@@ -670,7 +782,7 @@ func (p *Plugin) handleSettings(_ *plugin.Context, _ *model.CommandArgs, paramet
 					"error", err.Error())
 			}
 		} else {
-			err := p.store.Delete(userInfo.GitHubUsername + githubUsernameKey)
+			err := p.store.Delete(getGitHubUsernameKey(userInfo.GitHubUsername, userInfo.Instance))
 			if err != nil {
 				p.client.Log.Warn("Failed to delete GitHub to userID mapping",
 					"userID", userInfo.UserID,
@@ -680,27 +792,384 @@ func (p *Plugin) handleSettings(_ *plugin.Context, _ *model.CommandArgs, paramet
 		}
 	}
 
-	err := p.storeGitHubUserInfo(userInfo)
+	settings := userInfo.Settings
+	err := p.updateGitHubUserInfo(userInfo.UserID, userInfo.Instance, func(info *GitHubUserInfo) {
+		info.Settings = settings
+	})
 	if err != nil {
 		p.client.Log.Warn("Failed to store github user info", "error", err.Error())
 		return "Failed to store settings"
 	}
 
+	if setting == settingReminders {
+		if err := p.setUserIndexed(reminderUsersKey, userInfo.UserID, settings.DailyReminder); err != nil {
+			p.client.Log.Warn("Failed to update the users of the daily reminders", "userID", userInfo.UserID, "error", err.Error())
+			return "Failed to store settings"
+		}
+	}
+
 	return "Settings updated."
 }
 
 func (p *Plugin) handleIssue(_ *plugin.Context, args *model.CommandArgs, parameters []string, userInfo *GitHubUserInfo) string {
 	if len(parameters) == 0 {
-		return "Invalid issue command. Available command is 'create'."
+		return "Invalid issue command. Available commands are 'create', 'view', 'close', 'reopen', 'comment', 'assign', 'label', 'unlabel' and 'milestone'."
 	}
 
 	command := parameters[0]
 	parameters = parameters[1:]
 
-	switch {
-	case command == "create":
+	if command == "create" {
 		p.openIssueCreateModal(args.UserId, args.ChannelId, strings.Join(parameters, " "))
 		return ""
+	}
+
+	if len(parameters) == 0 {
+		return fmt.Sprintf("Please provide an issue reference like `owner/repo#123`: `/github issue %s owner/repo#123`", command)
+	}
+
+	ref := parameters[0]
+	parameters = parameters[1:]
+
+	owner, repo, number, err := p.resolveIssueReference(args.ChannelId, ref)
+	if err != nil {
+		return fmt.Sprintf("Invalid issue reference `%s`: %s.", ref, err.Error())
+	}
+
+	ctx := context.Background()
+	githubClient := p.githubConnectUser(ctx, userInfo)
+	fullName := fullNameFromOwnerAndRepo(owner, repo)
+	issueLink := fmt.Sprintf("[%s#%d](%s%s/issues/%d)", fullName, number, p.getConfiguration().getBaseURL(), fullName, number)
+
+	var message string
+	switch command {
+	case "view":
+		return p.handleIssueView(ctx, githubClient, owner, repo, number, userInfo)
+	case "close":
+		_, _, err = githubClient.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{State: github.String("closed")})
+		message = fmt.Sprintf("You closed the issue %s.", issueLink)
+	case "reopen":
+		_, _, err = githubClient.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{State: github.String("open")})
+		message = fmt.Sprintf("You reopened the issue %s.", issueLink)
+	case "comment":
+		body := strings.Join(parameters, " ")
+		if body == "" {
+			return "Please provide a comment."
+		}
+		_, _, err = githubClient.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: github.String(body)})
+		message = fmt.Sprintf("You commented on the issue %s.", issueLink)
+	case "assign":
+		assignees := unquoteParameters(parameters)
+		if len(assignees) == 0 {
+			return "Please provide at least one GitHub username to assign."
+		}
+		_, _, err = githubClient.Issues.AddAssignees(ctx, owner, repo, number, assignees)
+		message = fmt.Sprintf("Assigned %s to the issue %s.", strings.Join(assignees, ", "), issueLink)
+	case "label":
+		labels := unquoteParameters(parameters)
+		if len(labels) == 0 {
+			return "Please provide at least one label to add."
+		}
+		_, _, err = githubClient.Issues.AddLabelsToIssue(ctx, owner, repo, number, labels)
+		message = fmt.Sprintf("Added the labels `%s` to the issue %s.", strings.Join(labels, "`, `"), issueLink)
+	case "unlabel":
+		labels := unquoteParameters(parameters)
+		if len(labels) == 0 {
+			return "Please provide at least one label to remove."
+		}
+		// the labels are removed one by one, the user is told which ones couldn't be removed.
+		var removed, failed []string
+		for _, label := range labels {
+			if _, removeErr := githubClient.Issues.RemoveLabelForIssue(ctx, owner, repo, number, label); removeErr != nil {
+				p.client.Log.Warn("Failed to remove the label", "ref", ref, "label", label, "error", removeErr.Error())
+				failed = append(failed, label)
+				err = removeErr
+				continue
+			}
+			removed = append(removed, label)
+		}
+		if len(removed) == 0 {
+			break
+		}
+		message = fmt.Sprintf("Removed the labels `%s` from the issue %s.", strings.Join(removed, "`, `"), issueLink)
+		if len(failed) > 0 {
+			message += fmt.Sprintf(" Failed to remove the labels `%s`: %s", strings.Join(failed, "`, `"), getActionFailReason(err, fullName, userInfo.GitHubUsername))
+			err = nil
+		}
+	case "milestone":
+		milestone, parseErr := parseMilestoneParameter(parameters)
+		if parseErr != nil {
+			return parseErr.Error()
+		}
+		if milestone == 0 {
+			_, _, err = githubClient.Issues.RemoveMilestone(ctx, owner, repo, number)
+			message = fmt.Sprintf("Removed the milestone from the issue %s.", issueLink)
+		} else {
+			_, _, err = githubClient.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{Milestone: &milestone})
+			message = fmt.Sprintf("Set the milestone of the issue %s.", issueLink)
+		}
+	default:
+		return fmt.Sprintf("Unknown subcommand %v", command)
+	}
+
+	if err != nil {
+		p.client.Log.Warn("Failed to run issue command", "command", command, "ref", ref, "error", err.Error())
+		return fmt.Sprintf("Failed to %s the issue %s: %s", command, issueLink, getActionFailReason(err, fullName, userInfo.GitHubUsername))
+	}
+
+	return message
+}
+
+func (p *Plugin) handleIssueView(ctx context.Context, githubClient *github.Client, owner, repo string, number int, userInfo *GitHubUserInfo) string {
+	issue, _, err := githubClient.Issues.Get(ctx, owner, repo, number)
+	if err != nil {
+		fullName := fullNameFromOwnerAndRepo(owner, repo)
+		p.client.Log.Warn("Failed to get issue", "repo", fullName, "number", number, "error", err.Error())
+		return fmt.Sprintf("Failed to get the issue %s#%d: %s", fullName, number, getActionFailReason(err, fullName, userInfo.GitHubUsername))
+	}
+
+	text := fmt.Sprintf("#### [%s](%s)\n", issue.GetTitle(), issue.GetHTMLURL())
+	text += fmt.Sprintf("##### %s#%d\n", fullNameFromOwnerAndRepo(owner, repo), issue.GetNumber())
+	text += fmt.Sprintf("**State:** %s | **Author:** [%s](%s) | **Comments:** %d\n", issue.GetState(), issue.GetUser().GetLogin(), issue.GetUser().GetHTMLURL(), issue.GetComments())
+
+	if len(issue.Assignees) > 0 {
+		assignees := []string{}
+		for _, assignee := range issue.Assignees {
+			assignees = append(assignees, assignee.GetLogin())
+		}
+		text += fmt.Sprintf("**Assignees:** %s\n", strings.Join(assignees, ", "))
+	}
+
+	if len(issue.Labels) > 0 {
+		labels := []string{}
+		for _, label := range issue.Labels {
+			labels = append(labels, "`"+label.GetName()+"`")
+		}
+		text += fmt.Sprintf("**Labels:** %s\n", strings.Join(labels, " "))
+	}
+
+	if issue.Milestone != nil {
+		text += fmt.Sprintf("**Milestone:** %s\n", issue.GetMilestone().GetTitle())
+	}
+
+	return text
+}
+
+// unquoteParameters strips the double quotes kept by parseCommand around parameters containing whitespaces.
+func unquoteParameters(parameters []string) []string {
+	unquoted := []string{}
+	for _, parameter := range parameters {
+		if parameter = strings.Trim(parameter, `"`); parameter != "" {
+			unquoted = append(unquoted, parameter)
+		}
+	}
+
+	return unquoted
+}
+
+// parseMilestoneParameter returns the milestone number given to the issue milestone command, or 0 if it should be removed.
+func parseMilestoneParameter(parameters []string) (int, error) {
+	if len(parameters) != 1 {
+		return 0, errors.New("Please provide a single milestone number, or `none` to remove the milestone.")
+	}
+
+	if parameters[0] == "none" {
+		return 0, nil
+	}
+
+	milestone, err := strconv.Atoi(parameters[0])
+	if err != nil || milestone <= 0 {
+		return 0, errors.Errorf("Invalid milestone number %s.", parameters[0])
+	}
+
+	return milestone, nil
+}
+
+func (p *Plugin) handlePR(_ *plugin.Context, args *model.CommandArgs, parameters []string, userInfo *GitHubUserInfo) string {
+	if len(parameters) < 2 {
+		return "Invalid pr command. Available commands are 'view', 'approve', 'request-changes', 'comment', 'merge' and 'close', followed by a pull request reference like `owner/repo#123`."
+	}
+
+	command := parameters[0]
+	ref := parameters[1]
+	parameters = parameters[2:]
+
+	owner, repo, number, err := p.resolveIssueReference(args.ChannelId, ref)
+	if err != nil {
+		return fmt.Sprintf("Invalid pull request reference `%s`: %s.", ref, err.Error())
+	}
+
+	ctx := context.Background()
+	githubClient := p.githubConnectUser(ctx, userInfo)
+	fullName := fullNameFromOwnerAndRepo(owner, repo)
+	prLink := fmt.Sprintf("[%s#%d](%s%s/pull/%d)", fullName, number, p.getConfiguration().getBaseURL(), fullName, number)
+
+	var message string
+	switch command {
+	case "view":
+		return p.handlePRView(ctx, githubClient, owner, repo, number, userInfo)
+	case "approve":
+		review := &github.PullRequestReviewRequest{Event: github.String("APPROVE")}
+		if body := strings.Join(parameters, " "); body != "" {
+			review.Body = github.String(body)
+		}
+		_, _, err = githubClient.PullRequests.CreateReview(ctx, owner, repo, number, review)
+		message = fmt.Sprintf("You approved the pull request %s.", prLink)
+	case "request-changes":
+		body := strings.Join(parameters, " ")
+		if body == "" {
+			return "Please provide a message explaining the requested changes."
+		}
+		_, _, err = githubClient.PullRequests.CreateReview(ctx, owner, repo, number, &github.PullRequestReviewRequest{Event: github.String("REQUEST_CHANGES"), Body: github.String(body)})
+		message = fmt.Sprintf("You requested changes on the pull request %s.", prLink)
+	case "comment":
+		body := strings.Join(parameters, " ")
+		if body == "" {
+			return "Please provide a comment."
+		}
+		_, _, err = githubClient.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: github.String(body)})
+		message = fmt.Sprintf("You commented on the pull request %s.", prLink)
+	case "merge":
+		mergeMethod, parseErr := parseMergeMethod(parameters)
+		if parseErr != nil {
+			return parseErr.Error()
+		}
+		var result *github.PullRequestMergeResult
+		result, _, err = githubClient.PullRequests.Merge(ctx, owner, repo, number, "", &github.PullRequestOptions{MergeMethod: mergeMethod})
+		if err == nil && !result.GetMerged() {
+			return fmt.Sprintf("The pull request %s could not be merged: %s", prLink, result.GetMessage())
+		}
+		message = fmt.Sprintf("You merged the pull request %s.", prLink)
+	case "close":
+		_, _, err = githubClient.PullRequests.Edit(ctx, owner, repo, number, &github.PullRequest{State: github.String("closed")})
+		message = fmt.Sprintf("You closed the pull request %s.", prLink)
+	default:
+		return fmt.Sprintf("Unknown subcommand %v", command)
+	}
+
+	if err != nil {
+		p.client.Log.Warn("Failed to run pr command", "command", command, "ref", ref, "error", err.Error())
+		return fmt.Sprintf("Failed to %s the pull request %s: %s", command, prLink, getActionFailReason(err, fullName, userInfo.GitHubUsername))
+	}
+
+	return message
+}
+
+func (p *Plugin) handlePRView(ctx context.Context, githubClient *github.Client, owner, repo string, number int, userInfo *GitHubUserInfo) string {
+	pr, _, err := githubClient.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		fullName := fullNameFromOwnerAndRepo(owner, repo)
+		p.client.Log.Warn("Failed to get pull request", "repo", fullName, "number", number, "error", err.Error())
+		return fmt.Sprintf("Failed to get the pull request %s#%d: %s", fullName, number, getActionFailReason(err, fullName, userInfo.GitHubUsername))
+	}
+
+	state := pr.GetState()
+	switch {
+	case pr.GetMerged():
+		state = "merged"
+	case pr.GetDraft():
+		state = "draft"
+	}
+
+	text := fmt.Sprintf("#### [%s](%s)\n", pr.GetTitle(), pr.GetHTMLURL())
+	text += fmt.Sprintf("##### %s#%d\n", pr.GetBase().GetRepo().GetFullName(), pr.GetNumber())
+	text += fmt.Sprintf("**State:** %s | **Author:** [%s](%s) | `%s` :arrow_left: `%s`\n", state, pr.GetUser().GetLogin(), pr.GetUser().GetHTMLURL(), pr.GetBase().GetRef(), pr.GetHead().GetRef())
+	text += fmt.Sprintf("**Changes:** %d commits, %d files, +%d -%d", pr.GetCommits(), pr.GetChangedFiles(), pr.GetAdditions(), pr.GetDeletions())
+	if pr.GetState() == "open" && pr.Mergeable != nil {
+		text += fmt.Sprintf(" | **Mergeable:** %t", pr.GetMergeable())
+	}
+	text += "\n"
+
+	if len(pr.RequestedReviewers) > 0 {
+		reviewers := []string{}
+		for _, reviewer := range pr.RequestedReviewers {
+			reviewers = append(reviewers, reviewer.GetLogin())
+		}
+		text += fmt.Sprintf("**Requested reviewers:** %s\n", strings.Join(reviewers, ", "))
+	}
+
+	return text
+}
+
+// parseMergeMethod returns the merge method given with the --method flag, if any.
+func parseMergeMethod(parameters []string) (string, error) {
+	if len(parameters) == 0 {
+		return "", nil
+	}
+
+	if len(parameters) != 2 || parseFlag(parameters[0]) != "method" || !isFlag(parameters[0]) {
+		return "", errors.New("Please use the correct format for the merge method: --method <merge|squash|rebase>")
+	}
+
+	switch parameters[1] {
+	case "merge", "squash", "rebase":
+		return parameters[1], nil
+	default:
+		return "", errors.Errorf("Invalid merge method %s. Accepted values are: \"merge\", \"squash\" or \"rebase\".", parameters[1])
+	}
+}
+
+func (p *Plugin) handleChannel(_ *plugin.Context, args *model.CommandArgs, parameters []string, userInfo *GitHubUserInfo) string {
+	if len(parameters) == 0 {
+		return "Invalid channel command. Available commands are 'set-repo', 'unset-repo' and 'show-repo'."
+	}
+
+	command := parameters[0]
+	parameters = parameters[1:]
+
+	// the default repository is shared by the members of the channel.
+	if (command == "set-repo" || command == "unset-repo") && !p.client.User.HasPermissionToChannel(args.UserId, args.ChannelId, model.PermissionManageChannelRoles) {
+		return "Only the channel admins can change the default repository of the channel."
+	}
+
+	switch command {
+	case "set-repo":
+		if len(parameters) != 1 {
+			return "Please specify a repository: `/github channel set-repo owner/repo`"
+		}
+
+		owner, repo, err := parseRepo(parameters[0])
+		if err != nil {
+			return err.Error()
+		}
+
+		if err = p.checkOrg(owner); err != nil {
+			return fmt.Sprintf("Repository `%s` isn't supported: %s.", fullNameFromOwnerAndRepo(owner, repo), err.Error())
+		}
+
+		ctx := context.Background()
+		githubClient := p.githubConnectUser(ctx, userInfo)
+		ghRepo, _, err := githubClient.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			fullName := fullNameFromOwnerAndRepo(owner, repo)
+			p.client.Log.Warn("Failed to get repository", "repo", fullName, "error", err.Error())
+			return fmt.Sprintf("Failed to get the repository %s: %s", fullName, getActionFailReason(err, fullName, userInfo.GitHubUsername))
+		}
+
+		if err = p.setChannelDefaultRepo(args.ChannelId, ghRepo.GetFullName()); err != nil {
+			p.client.Log.Warn("Failed to set the channel default repository", "error", err.Error())
+			return "Failed to set the default repository of the channel."
+		}
+
+		return fmt.Sprintf("The default repository of this channel is now [%s](%s). Commands run in this channel can use short references like `#123`.", ghRepo.GetFullName(), ghRepo.GetHTMLURL())
+	case "unset-repo":
+		if err := p.deleteChannelDefaultRepo(args.ChannelId); err != nil {
+			p.client.Log.Warn("Failed to delete the channel default repository", "error", err.Error())
+			return "Failed to unset the default repository of the channel."
+		}
+
+		return "This channel no longer has a default repository."
+	case "show-repo":
+		repo, err := p.getChannelDefaultRepo(args.ChannelId)
+		if err != nil {
+			p.client.Log.Warn("Failed to get the channel default repository", "error", err.Error())
+			return "Failed to get the default repository of the channel."
+		}
+		if repo == "" {
+			return "This channel has no default repository. Set one with `/github channel set-repo owner/repo`."
+		}
+
+		return fmt.Sprintf("The default repository of this channel is %s.", repo)
 	default:
 		return fmt.Sprintf("Unknown subcommand %v", command)
 	}
@@ -731,6 +1200,12 @@ func (p *Plugin) handleSetup(c *plugin.Context, args *model.CommandArgs, paramet
 			err = p.flowManager.StartWebhookWizard(userID)
 		case command == "announcement":
 			err = p.flowManager.StartAnnouncementWizard(userID)
+		case command == "rotate-encryption-key":
+			var message string
+			message, err = p.startEncryptionKeyRotation(userID)
+			if err == nil {
+				return message
+			}
 		default:
 			return fmt.Sprintf("Unknown subcommand %v", command)
 		}
@@ -810,6 +1285,23 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 			return &model.CommandResponse{}, nil
 		}
 
+		instance, parameters, err := parseInstanceFlag(parameters)
+		if err != nil {
+			p.postCommandResponse(args, err.Error())
+			return &model.CommandResponse{}, nil
+		}
+		if _, err = config.forInstance(instance); err != nil {
+			p.postCommandResponse(args, fmt.Sprintf("Unknown GitHub instance `%s`.", instance))
+			return &model.CommandResponse{}, nil
+		}
+
+		// the device flow doesn't need the browser to be redirected back to Mattermost.
+		useDeviceFlow := false
+		if len(parameters) > 0 && parameters[len(parameters)-1] == "device" {
+			useDeviceFlow = true
+			parameters = parameters[:len(parameters)-1]
+		}
+
 		privateAllowed := p.getConfiguration().ConnectToPrivateByDefault
 		if len(parameters) > 0 {
 			if privateAllowed {
@@ -833,6 +1325,24 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 			}
 			qparams = "?private=true"
 		}
+		if instance != "" {
+			if qparams == "" {
+				qparams = "?instance=" + instance
+			} else {
+				qparams += "&instance=" + instance
+			}
+		}
+
+		if useDeviceFlow {
+			if err := p.startDeviceConnect(args.UserId, instance, privateAllowed); err != nil {
+				p.client.Log.Warn("Failed to start the device flow", "error", err.Error())
+				p.postCommandResponse(args, "Encountered an error connecting to GitHub with a device code. Please make sure the device flow is enabled for the GitHub application.")
+				return &model.CommandResponse{}, nil
+			}
+
+			p.postCommandResponse(args, "A code to connect your GitHub account was sent to you in a direct message.")
+			return &model.CommandResponse{}, nil
+		}
 
 		msg := fmt.Sprintf("[Click here to link your GitHub account.](%s/plugins/%s/oauth/connect%s)", *siteURL, Manifest.Id, qparams)
 		p.postCommandResponse(args, msg)
@@ -850,7 +1360,14 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	}
 
 	if f, ok := p.CommandHandlers[action]; ok {
+		info.rateLimitRejected = &atomic.Bool{}
 		message := f(c, args, parameters, info)
+		// the handlers only report that GitHub failed, the user is told why if a request was rate limited.
+		if message != "" && info.rateLimitRejected.Load() {
+			if rateLimitedMessage := p.getRateLimitedMessage(info); rateLimitedMessage != "" {
+				message += "\n\n" + rateLimitedMessage
+			}
+		}
 		if message != "" {
 			p.postCommandResponse(args, message)
 		}
@@ -875,7 +1392,7 @@ func getAutocompleteData(config *Configuration) *model.AutocompleteData {
 		return github
 	}
 
-	github := model.NewAutocompleteData("github", "[command]", "Available commands: connect, disconnect, todo, subscriptions, issue, me, mute, settings, help, about")
+	github := model.NewAutocompleteData("github", "[command]", "Available commands: connect, disconnect, todo, subscriptions, watch, issue, pr, channel, me, mute, settings, help, about")
 
 	connect := model.NewAutocompleteData("connect", "", "Connect your Mattermost account to your GitHub account")
 	if config.EnablePrivateRepo {
@@ -883,9 +1400,16 @@ func getAutocompleteData(config *Configuration) *model.AutocompleteData {
 			connect = model.NewAutocompleteData("connect", "", "Connect your Mattermost account to your GitHub account. Read access to your private repositories will be requested")
 		} else {
 			private := model.NewAutocompleteData("private", "(optional)", "If used, read access to your private repositories will be requested")
+			if !config.UsePreregisteredApplication {
+				private.AddCommand(model.NewAutocompleteData("device", "(optional)", "If used, connect by entering a code on GitHub instead of being redirected"))
+			}
 			connect.AddCommand(private)
 		}
 	}
+	if !config.UsePreregisteredApplication {
+		device := model.NewAutocompleteData("device", "(optional)", "If used, connect by entering a code on GitHub instead of being redirected")
+		connect.AddCommand(device)
+	}
 	github.AddCommand(connect)
 
 	disconnect := model.NewAutocompleteData("disconnect", "", "Disconnect your Mattermost account from your GitHub account")
@@ -940,14 +1464,126 @@ func getAutocompleteData(config *Configuration) *model.AutocompleteData {
 
 	github.AddCommand(subscriptions)
 
-	issue := model.NewAutocompleteData("issue", "[command]", "Available commands: create")
+	watch := model.NewAutocompleteData("watch", "[command]", "Available commands: add, list, delete")
+
+	watchAdd := model.NewAutocompleteData("add", "[owner/repo] [features] [flags]", "Receive the events of an organization or repository in a direct message. [features] is optional and defaults to pulls,issues,creates,deletes. [flags] are the ones of subscriptions")
+	watchAdd.AddTextArgument("Owner/repo to watch", "[owner/repo]", "")
+	watchAdd.AddTextArgument("Comma-delimited list of the features to watch, as for subscriptions", "[features]", `/[^,-\s]+(,[^,-\s]+)*/`)
+	watch.AddCommand(watchAdd)
+
+	watchList := model.NewAutocompleteData("list", "", "List the organizations and repositories you watch")
+	watch.AddCommand(watchList)
+
+	watchDelete := model.NewAutocompleteData("delete", "[owner/repo]", "Stop watching an organization or repository")
+	watchDelete.AddTextArgument("Owner/repo to stop watching", "[owner/repo]", "")
+	watch.AddCommand(watchDelete)
+
+	github.AddCommand(watch)
+
+	issue := model.NewAutocompleteData("issue", "[command]", "Available commands: create, view, close, reopen, comment, assign, label, unlabel, milestone")
 
 	issueCreate := model.NewAutocompleteData("create", "[title]", "Open a dialog to create a new issue in GitHub, using the title if provided")
 	issueCreate.AddTextArgument("Title for the GitHub issue", "[title]", "")
 	issue.AddCommand(issueCreate)
 
+	issueView := model.NewAutocompleteData("view", "[owner/repo#number|#number]", "Display a summary of an issue")
+	issueView.AddTextArgument("Issue to view", "[owner/repo#number|#number]", "")
+	issue.AddCommand(issueView)
+
+	issueClose := model.NewAutocompleteData("close", "[owner/repo#number|#number]", "Close an issue")
+	issueClose.AddTextArgument("Issue to close", "[owner/repo#number|#number]", "")
+	issue.AddCommand(issueClose)
+
+	issueReopen := model.NewAutocompleteData("reopen", "[owner/repo#number|#number]", "Reopen a closed issue")
+	issueReopen.AddTextArgument("Issue to reopen", "[owner/repo#number|#number]", "")
+	issue.AddCommand(issueReopen)
+
+	issueComment := model.NewAutocompleteData("comment", "[owner/repo#number] [comment]", "Comment on an issue")
+	issueComment.AddTextArgument("Issue to comment on", "[owner/repo#number|#number]", "")
+	issueComment.AddTextArgument("Comment", "[comment]", "")
+	issue.AddCommand(issueComment)
+
+	issueAssign := model.NewAutocompleteData("assign", "[owner/repo#number] [username]", "Assign a GitHub user to an issue")
+	issueAssign.AddTextArgument("Issue to assign", "[owner/repo#number|#number]", "")
+	issueAssign.AddDynamicListArgument("GitHub username to assign", "api/v1/autocomplete/assignees", true)
+	issue.AddCommand(issueAssign)
+
+	issueLabel := model.NewAutocompleteData("label", "[owner/repo#number] [label]", "Add a label to an issue")
+	issueLabel.AddTextArgument("Issue to label", "[owner/repo#number|#number]", "")
+	issueLabel.AddDynamicListArgument("Label to add", "api/v1/autocomplete/labels", true)
+	issue.AddCommand(issueLabel)
+
+	issueUnlabel := model.NewAutocompleteData("unlabel", "[owner/repo#number] [label]", "Remove a label from an issue")
+	issueUnlabel.AddTextArgument("Issue to unlabel", "[owner/repo#number|#number]", "")
+	issueUnlabel.AddDynamicListArgument("Label to remove", "api/v1/autocomplete/labels", true)
+	issue.AddCommand(issueUnlabel)
+
+	issueMilestone := model.NewAutocompleteData("milestone", "[owner/repo#number] [milestone]", "Set the milestone of an issue, or remove it with `none`")
+	issueMilestone.AddTextArgument("Issue to update", "[owner/repo#number|#number]", "")
+	issueMilestone.AddDynamicListArgument("Milestone number to set", "api/v1/autocomplete/milestones", true)
+	issue.AddCommand(issueMilestone)
+
 	github.AddCommand(issue)
 
+	pr := model.NewAutocompleteData("pr", "[command]", "Available commands: view, approve, request-changes, comment, merge, close")
+
+	prView := model.NewAutocompleteData("view", "[owner/repo#number|#number]", "Display a summary of a pull request")
+	prView.AddTextArgument("Pull request to view", "[owner/repo#number|#number]", "")
+	pr.AddCommand(prView)
+
+	prApprove := model.NewAutocompleteData("approve", "[owner/repo#number] [message]", "Approve a pull request")
+	prApprove.AddTextArgument("Pull request to approve", "[owner/repo#number|#number]", "")
+	prApprove.AddTextArgument("(Optional) Review message", "[message]", "")
+	pr.AddCommand(prApprove)
+
+	prRequestChanges := model.NewAutocompleteData("request-changes", "[owner/repo#number] [message]", "Request changes on a pull request")
+	prRequestChanges.AddTextArgument("Pull request to review", "[owner/repo#number|#number]", "")
+	prRequestChanges.AddTextArgument("Review message", "[message]", "")
+	pr.AddCommand(prRequestChanges)
+
+	prComment := model.NewAutocompleteData("comment", "[owner/repo#number] [comment]", "Comment on a pull request")
+	prComment.AddTextArgument("Pull request to comment on", "[owner/repo#number|#number]", "")
+	prComment.AddTextArgument("Comment", "[comment]", "")
+	pr.AddCommand(prComment)
+
+	prMerge := model.NewAutocompleteData("merge", "[owner/repo#number|#number]", "Merge a pull request")
+	prMerge.AddTextArgument("Pull request to merge", "[owner/repo#number|#number]", "")
+	prMerge.AddNamedStaticListArgument("method", "The merge method to use. Defaults to merge", false, []model.AutocompleteListItem{
+		{
+			Item:     "merge",
+			HelpText: "Create a merge commit",
+		},
+		{
+			Item:     "squash",
+			HelpText: "Squash all commits into a single commit",
+		},
+		{
+			Item:     "rebase",
+			HelpText: "Rebase the commits onto the base branch",
+		},
+	})
+	pr.AddCommand(prMerge)
+
+	prClose := model.NewAutocompleteData("close", "[owner/repo#number|#number]", "Close a pull request without merging it")
+	prClose.AddTextArgument("Pull request to close", "[owner/repo#number|#number]", "")
+	pr.AddCommand(prClose)
+
+	github.AddCommand(pr)
+
+	channel := model.NewAutocompleteData("channel", "[command]", "Available commands: set-repo, unset-repo, show-repo")
+
+	channelSetRepo := model.NewAutocompleteData("set-repo", "[owner/repo]", "Set the repository used by commands in this channel when none is given")
+	channelSetRepo.AddTextArgument("Owner/repo to use by default", "[owner/repo]", "")
+	channel.AddCommand(channelSetRepo)
+
+	channelUnsetRepo := model.NewAutocompleteData("unset-repo", "", "Remove the default repository of this channel")
+	channel.AddCommand(channelUnsetRepo)
+
+	channelShowRepo := model.NewAutocompleteData("show-repo", "", "Display the default repository of this channel")
+	channel.AddCommand(channelShowRepo)
+
+	github.AddCommand(channel)
+
 	me := model.NewAutocompleteData("me", "", "Display the connected GitHub account")
 	github.AddCommand(me)
 
@@ -992,17 +1628,38 @@ func getAutocompleteData(config *Configuration) *model.AutocompleteData {
 	}, {
 		HelpText: "Turn reminders on, but only get reminders if any changes have occurred since the previous day's reminder",
 		Item:     settingOnChange,
+	}, {
+		HelpText: "Get reminders at a time of your timezone, e.g. `at 09:30 weekdays`",
+		Item:     settingAt,
 	}}
 	remainderNotifications.AddStaticListArgument("", true, settingValue)
 	settings.AddCommand(remainderNotifications)
 
+	settingToDoList := model.NewAutocompleteData(settingToDo, "", "Choose the sections of your todo list and filter it")
+	settingToDoList.AddCommand(model.NewAutocompleteData(todoSettingSections, "[sections]", "Comma separated sections: "+strings.Join(getToDoSectionNames(), ", ")+", or `default`"))
+	settingToDoList.AddCommand(model.NewAutocompleteData(todoSettingOrgs, "[orgs]", "Only list items of these comma separated organizations, or `none`"))
+	settingToDoList.AddCommand(model.NewAutocompleteData(todoSettingRepos, "[owner/repo,...]", "Only list items of these comma separated repositories, or `none`"))
+	settingToDoList.AddCommand(model.NewAutocompleteData(todoSettingLabels, "[labels]", "Only list items with any of these comma separated labels, or `none`"))
+	settingToDoList.AddCommand(model.NewAutocompleteData(todoSettingShow, "", "Display your todo list settings"))
+	settingToDoList.AddCommand(model.NewAutocompleteData(todoSettingReset, "", "Reset your todo list to the default sections without filters"))
+	settings.AddCommand(settingToDoList)
+
+	settingInboxList := model.NewAutocompleteData(settingInbox, "", "Receive the notifications of your GitHub inbox in a direct message")
+	settingInboxList.AddCommand(model.NewAutocompleteData(settingOn, "", "Turn the inbox notifications on"))
+	settingInboxList.AddCommand(model.NewAutocompleteData(settingOff, "", "Turn the inbox notifications off"))
+	settingInboxList.AddCommand(model.NewAutocompleteData(inboxSettingReasons, "[reasons]", "Only forward the notifications with these comma separated reasons, or `none`: "+strings.Join(inboxReasons, ", ")))
+	settingInboxList.AddCommand(model.NewAutocompleteData(inboxSettingRepos, "[owner/repo,...]", "Only forward the notifications of these comma separated organizations or repositories, or `none`"))
+	settingInboxList.AddCommand(model.NewAutocompleteData(inboxSettingShow, "", "Display your inbox settings"))
+	settings.AddCommand(settingInboxList)
+
 	github.AddCommand(settings)
 
-	setup := model.NewAutocompleteData("setup", "[command]", "Available commands: oauth, webhook, announcement")
+	setup := model.NewAutocompleteData("setup", "[command]", "Available commands: oauth, webhook, announcement, rotate-encryption-key")
 	setup.RoleID = model.SystemAdminRoleId
 	setup.AddCommand(model.NewAutocompleteData("oauth", "", "Set up the OAuth2 Application in GitHub"))
 	setup.AddCommand(model.NewAutocompleteData("webhook", "", "Create a webhook from GitHub to Mattermost"))
 	setup.AddCommand(model.NewAutocompleteData("announcement", "", "Announce to your team that they can use GitHub integration"))
+	setup.AddCommand(model.NewAutocompleteData("rotate-encryption-key", "", "Replace the encryption key and re-encrypt the stored tokens"))
 	github.AddCommand(setup)
 
 	help := model.NewAutocompleteData("help", "", "Display Slash Command help text")
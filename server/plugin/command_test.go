@@ -239,3 +239,102 @@ func TestCheckConflictingFeatures(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMergeMethod(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		want      string
+		expectErr bool
+	}{
+		{
+			name: "no method",
+			args: []string{},
+			want: "",
+		},
+		{
+			name: "squash method",
+			args: []string{"--method", "squash"},
+			want: "squash",
+		},
+		{
+			name:      "unknown method",
+			args:      []string{"--method", "octopus"},
+			expectErr: true,
+		},
+		{
+			name:      "missing method value",
+			args:      []string{"--method"},
+			expectErr: true,
+		},
+		{
+			name:      "unknown flag",
+			args:      []string{"--strategy", "merge"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMergeMethod(tt.args)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseMilestoneParameter(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		want      int
+		expectErr bool
+	}{
+		{
+			name: "milestone number",
+			args: []string{"3"},
+			want: 3,
+		},
+		{
+			name: "remove milestone",
+			args: []string{"none"},
+			want: 0,
+		},
+		{
+			name:      "no milestone",
+			args:      []string{},
+			expectErr: true,
+		},
+		{
+			name:      "milestone title",
+			args:      []string{"v1.0"},
+			expectErr: true,
+		},
+		{
+			name:      "too many arguments",
+			args:      []string{"1", "2"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMilestoneParameter(tt.args)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUnquoteParameters(t *testing.T) {
+	assert.Equal(t, []string{"bug", "good first issue"}, unquoteParameters([]string{"bug", `"good first issue"`, `""`}))
+	assert.Equal(t, []string{}, unquoteParameters(nil))
+}
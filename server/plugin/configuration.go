@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -28,17 +29,23 @@ type Configuration struct {
 	GitHubOrg                      string `json:"githuborg"`
 	GitHubOAuthClientID            string `json:"githuboauthclientid"`
 	GitHubOAuthClientSecret        string `json:"githuboauthclientsecret"`
+	GitHubAppID                    string `json:"githubappid"`
+	GitHubAppPrivateKey            string `json:"githubappprivatekey"`
 	WebhookSecret                  string `json:"webhooksecret"`
 	EnableLeftSidebar              bool   `json:"enableleftsidebar"`
 	EnablePrivateRepo              bool   `json:"enableprivaterepo"`
 	ConnectToPrivateByDefault      bool   `json:"connecttoprivatebydefault"`
 	EncryptionKey                  string `json:"encryptionkey"`
+	PreviousEncryptionKey          string `json:"previousencryptionkey"`
 	EnterpriseBaseURL              string `json:"enterprisebaseurl"`
 	EnterpriseUploadURL            string `json:"enterpriseuploadurl"`
 	EnableCodePreview              string `json:"enablecodepreview"`
+	PrivateCodePreviewPolicy       string `json:"privatecodepreviewpolicy"`
+	EnableReferencePreview         bool   `json:"enablereferencepreview"`
 	EnableWebhookEventLogging      bool   `json:"enablewebhookeventlogging"`
 	UsePreregisteredApplication    bool   `json:"usepreregisteredapplication"`
 	ShowAuthorInCommitNotification bool   `json:"showauthorincommitnotification"`
+	GitHubInstances                string `json:"githubinstances"`
 }
 
 func (c *Configuration) ToMap() (map[string]interface{}, error) {
@@ -94,6 +101,15 @@ func (c *Configuration) getBaseURL() string {
 	return "https://github.com/"
 }
 
+// getPrivatePreviewPolicy returns where content of private repositories can be previewed.
+func (c *Configuration) getPrivatePreviewPolicy() string {
+	if c.PrivateCodePreviewPolicy == "" {
+		return privatePreviewPolicyAnyChannel
+	}
+
+	return c.PrivateCodePreviewPolicy
+}
+
 func (c *Configuration) sanitize() {
 	c.EnterpriseBaseURL = strings.TrimRight(c.EnterpriseBaseURL, "/")
 	c.EnterpriseUploadURL = strings.TrimRight(c.EnterpriseUploadURL, "/")
@@ -102,6 +118,7 @@ func (c *Configuration) sanitize() {
 	c.GitHubOrg = strings.TrimSpace(c.GitHubOrg)
 	c.GitHubOAuthClientID = strings.TrimSpace(c.GitHubOAuthClientID)
 	c.GitHubOAuthClientSecret = strings.TrimSpace(c.GitHubOAuthClientSecret)
+	c.GitHubAppID = strings.TrimSpace(c.GitHubAppID)
 }
 
 func (c *Configuration) IsOAuthConfigured() bool {
@@ -109,6 +126,12 @@ func (c *Configuration) IsOAuthConfigured() bool {
 		c.UsePreregisteredApplication
 }
 
+// IsGitHubAppConfigured returns true if the plugin authenticates as a GitHub App. The OAuth client ID and secret are then
+// the ones of the GitHub App, and are used to connect the users with user-to-server tokens.
+func (c *Configuration) IsGitHubAppConfigured() bool {
+	return c.GitHubAppID != "" && c.GitHubAppPrivateKey != ""
+}
+
 // IsSASS return if SASS GitHub at https://github.com is used.
 func (c *Configuration) IsSASS() bool {
 	return c.EnterpriseBaseURL == "" && c.EnterpriseUploadURL == ""
@@ -142,6 +165,25 @@ func (c *Configuration) IsValid() error {
 		return errors.New("cannot use pre-registered application with GitHub enterprise")
 	}
 
+	if c.GitHubAppID != "" || c.GitHubAppPrivateKey != "" {
+		if c.UsePreregisteredApplication {
+			return errors.New("cannot use pre-registered application with a GitHub App")
+		}
+		if c.GitHubAppID == "" {
+			return errors.New("must have a github app id")
+		}
+		if _, err := strconv.ParseInt(c.GitHubAppID, 10, 64); err != nil {
+			return errors.New("the github app id must be a number")
+		}
+		if _, err := parseAppPrivateKey(c.GitHubAppPrivateKey); err != nil {
+			return errors.Wrap(err, "invalid github app private key")
+		}
+	}
+
+	if err := c.validateInstances(); err != nil {
+		return err
+	}
+
 	if c.EncryptionKey == "" {
 		return errors.New("must have an encryption key")
 	}
@@ -208,6 +250,9 @@ func (p *Plugin) OnConfigurationChange() error {
 
 	p.setConfiguration(configuration)
 
+	// Installation tokens may belong to a previously configured GitHub App.
+	p.installationTokens.clear()
+
 	command, err := p.getCommand(configuration)
 	if err != nil {
 		return errors.Wrap(err, "failed to get command")
@@ -267,3 +312,23 @@ func (c *Configuration) getOrganizations() []string {
 
 	return allOrgs
 }
+
+// checkOrg checks that the organization is one of the organizations the configuration is locked to, if any.
+func (c *Configuration) checkOrg(org string) error {
+	orgList := c.getOrganizations()
+	if len(orgList) == 0 {
+		return nil
+	}
+
+	for _, configOrg := range orgList {
+		if configOrg == strings.ToLower(org) {
+			return nil
+		}
+	}
+
+	return errors.Errorf("only repositories in the %v organization(s) are supported", c.GitHubOrg)
+}
+
+func (c *Configuration) isOrganizationLocked() bool {
+	return strings.TrimSpace(c.GitHubOrg) != ""
+}
@@ -46,6 +46,27 @@ func TestIsValid(t *testing.T) {
 			},
 			errMsg: "cannot use pre-registered application with GitHub enterprise",
 		},
+		{
+			description: "invalid configuration: GitHub App without private key",
+			config: &Configuration{
+				GitHubOAuthClientID:     "client-id",
+				GitHubOAuthClientSecret: "client-secret",
+				GitHubAppID:             "12345",
+				EncryptionKey:           "abcd",
+			},
+			errMsg: "invalid github app private key",
+		},
+		{
+			description: "invalid configuration: GitHub App with a non-numeric ID",
+			config: &Configuration{
+				GitHubOAuthClientID:     "client-id",
+				GitHubOAuthClientSecret: "client-secret",
+				GitHubAppID:             "my-app",
+				GitHubAppPrivateKey:     "key",
+				EncryptionKey:           "abcd",
+			},
+			errMsg: "the github app id must be a number",
+		},
 	} {
 		t.Run(testCase.description, func(t *testing.T) {
 			err := testCase.config.IsValid()
@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	dailyReminderJobKey = "daily_reminders"
+
+	// reminderUsersKey stores the users who turned the daily reminder on. The reminders are opt-in, the users
+	// connected before they were sent by the server aren't reminded until they turn them on.
+	reminderUsersKey = "_reminderusers"
+
+	// dailyReminderInterval is how often the job checks for the reminders to send.
+	dailyReminderInterval = 5 * time.Minute
+
+	// dailyReminderWindow is how late a reminder is still sent, e.g. after the server was down.
+	dailyReminderWindow = time.Hour
+
+	defaultReminderTime  = "09:00"
+	reminderTimeLayout   = "15:04"
+	reminderDaysDaily    = "daily"
+	reminderDaysWeekdays = "weekdays"
+)
+
+var reminderWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseReminderDays returns the days the reminder is sent on. The days are either "daily", "weekdays"
+// or a comma separated list of days, e.g. "mon,wed,fri". The reminder is sent on weekdays by default.
+func parseReminderDays(value string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+
+	switch value {
+	case reminderDaysDaily:
+		for _, day := range reminderWeekdays {
+			days[day] = true
+		}
+	case "", reminderDaysWeekdays:
+		for day := time.Monday; day <= time.Friday; day++ {
+			days[day] = true
+		}
+	default:
+		for _, name := range strings.Split(strings.ToLower(value), ",") {
+			day, ok := reminderWeekdays[strings.TrimSpace(name)]
+			if !ok {
+				return nil, errors.Errorf("unknown day %q", name)
+			}
+			days[day] = true
+		}
+	}
+
+	return days, nil
+}
+
+// getReminderTime returns the time of the day the reminder is sent at.
+func (s *UserSettings) getReminderTime() string {
+	if s.ReminderTime == "" {
+		return defaultReminderTime
+	}
+
+	return s.ReminderTime
+}
+
+// isReminderDue returns true if the reminder of today is due at the given time of the user, and wasn't sent yet.
+func isReminderDue(settings *UserSettings, lastReminderAt int64, now time.Time) bool {
+	if settings == nil || !settings.DailyReminder {
+		return false
+	}
+
+	days, err := parseReminderDays(settings.ReminderDays)
+	if err != nil || !days[now.Weekday()] {
+		return false
+	}
+
+	reminderTime, err := time.Parse(reminderTimeLayout, settings.getReminderTime())
+	if err != nil {
+		return false
+	}
+
+	reminderAt := time.Date(now.Year(), now.Month(), now.Day(), reminderTime.Hour(), reminderTime.Minute(), 0, 0, now.Location())
+	if now.Before(reminderAt) || now.Sub(reminderAt) > dailyReminderWindow {
+		return false
+	}
+
+	return time.UnixMilli(lastReminderAt).Before(reminderAt)
+}
+
+// sendDailyReminders sends the todo list to the users whose reminder is due. Only a single plugin instance
+// in the cluster runs the job.
+func (p *Plugin) sendDailyReminders() {
+	userIDs, err := p.listIndexedUserIDs(reminderUsersKey)
+	if err != nil {
+		p.client.Log.Warn("Failed to list the users for the daily reminders", "error", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		if err := p.sendDailyReminder(userID, time.Now()); err != nil {
+			p.client.Log.Warn("Failed to send the daily reminder", "userID", userID, "error", err.Error())
+		}
+	}
+}
+
+// sendDailyReminder sends the todo list to the user if their reminder is due, in the timezone of the user.
+// The todo list only uses the default instance.
+func (p *Plugin) sendDailyReminder(userID string, now time.Time) error {
+	info, apiErr := p.getGitHubUserInfo(userID)
+	if apiErr != nil && apiErr.ID != apiErrorIDNotConnected {
+		return apiErr
+	}
+	if info == nil || info.Settings == nil || !info.Settings.DailyReminder {
+		// the user disconnected or turned the reminder off, they aren't listed anymore.
+		return p.setUserIndexed(reminderUsersKey, userID, false)
+	}
+
+	user, err := p.client.User.Get(userID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the user")
+	}
+
+	if !isReminderDue(info.Settings, info.LastReminderAt, now.In(user.GetTimezoneLocation())) {
+		return nil
+	}
+
+	ctx := withRateLimitRetries(context.Background())
+
+	// the reminder is sent at the next run of the job, if it's still due.
+	if !p.hasGitHubQuota(ctx, info) {
+		p.client.Log.Debug("Delaying the daily reminder of a rate limited user", "userID", userID)
+		return nil
+	}
+
+	if err := p.PostToDo(ctx, info, userID); err != nil {
+		return errors.Wrap(err, "failed to create GitHub todo message")
+	}
+
+	return p.storeReminderDelivery(userID, now.UnixMilli(), model.GetMillis())
+}
+
+// storeReminderDelivery records when the reminder was sent, without overwriting a token refreshed while the
+// todo list was fetched.
+func (p *Plugin) storeReminderDelivery(userID string, lastReminderAt, lastToDoPostAt int64) error {
+	err := p.updateGitHubUserInfo(userID, "", func(info *GitHubUserInfo) {
+		info.LastReminderAt = lastReminderAt
+		info.LastToDoPostAt = lastToDoPostAt
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to store the reminder delivery")
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestParseReminderDays(t *testing.T) {
+	for _, tc := range []struct {
+		value     string
+		expected  []time.Weekday
+		expectErr bool
+	}{
+		{value: "", expected: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{value: "daily", expected: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}},
+		{value: "weekdays", expected: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{value: "Mon,wed, fri", expected: []time.Weekday{time.Monday, time.Wednesday, time.Friday}},
+		{value: "mon,someday", expectErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			days, err := parseReminderDays(tc.value)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, days, len(tc.expected))
+			for _, day := range tc.expected {
+				assert.True(t, days[day], day.String())
+			}
+		})
+	}
+}
+
+func TestIsReminderDue(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// a Wednesday.
+	reminderAt := time.Date(2024, time.May, 15, 9, 30, 0, 0, location)
+	yesterday := reminderAt.Add(-24 * time.Hour).UnixMilli()
+
+	for _, tc := range []struct {
+		name           string
+		settings       *UserSettings
+		lastReminderAt int64
+		now            time.Time
+		expected       bool
+	}{
+		{
+			name:           "due at the reminder time",
+			settings:       &UserSettings{DailyReminder: true, ReminderTime: "09:30"},
+			lastReminderAt: yesterday,
+			now:            reminderAt.Add(2 * time.Minute),
+			expected:       true,
+		},
+		{
+			name:           "not due before the reminder time",
+			settings:       &UserSettings{DailyReminder: true, ReminderTime: "09:30"},
+			lastReminderAt: yesterday,
+			now:            reminderAt.Add(-2 * time.Minute),
+		},
+		{
+			name:           "not due after the delivery window",
+			settings:       &UserSettings{DailyReminder: true, ReminderTime: "09:30"},
+			lastReminderAt: yesterday,
+			now:            reminderAt.Add(2 * time.Hour),
+		},
+		{
+			name:           "not due if already sent today",
+			settings:       &UserSettings{DailyReminder: true, ReminderTime: "09:30"},
+			lastReminderAt: reminderAt.Add(time.Minute).UnixMilli(),
+			now:            reminderAt.Add(10 * time.Minute),
+		},
+		{
+			name:           "default time",
+			settings:       &UserSettings{DailyReminder: true},
+			lastReminderAt: yesterday,
+			now:            time.Date(2024, time.May, 15, 9, 5, 0, 0, location),
+			expected:       true,
+		},
+		{
+			name:           "not due on weekends by default",
+			settings:       &UserSettings{DailyReminder: true, ReminderTime: "09:30"},
+			lastReminderAt: yesterday,
+			now:            reminderAt.Add(3*24*time.Hour + 2*time.Minute),
+		},
+		{
+			name:           "not due on an excluded day",
+			settings:       &UserSettings{DailyReminder: true, ReminderTime: "09:30", ReminderDays: "mon,fri"},
+			lastReminderAt: yesterday,
+			now:            reminderAt.Add(2 * time.Minute),
+		},
+		{
+			name:           "not due if reminders are off",
+			settings:       &UserSettings{ReminderTime: "09:30"},
+			lastReminderAt: yesterday,
+			now:            reminderAt.Add(2 * time.Minute),
+		},
+		{
+			name:     "due in the timezone of the user",
+			settings: &UserSettings{DailyReminder: true, ReminderTime: "09:30"},
+			now:      reminderAt.Add(2 * time.Minute).UTC().In(location),
+			expected: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isReminderDue(tc.settings, tc.lastReminderAt, tc.now))
+		})
+	}
+}
+
+func TestStoreReminderDeliveryKeepsRefreshedToken(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EncryptionKey: testEncryptionKey})
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+		UserID:   "user1",
+		Token:    &oauth2.Token{AccessToken: "token1", RefreshToken: "refresh1"},
+		Settings: &UserSettings{DailyReminder: true},
+	}))
+
+	// the token is refreshed while the todo list of the reminder is fetched.
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+		UserID:   "user1",
+		Token:    &oauth2.Token{AccessToken: "token2", RefreshToken: "refresh2"},
+		Settings: &UserSettings{DailyReminder: true},
+	}))
+
+	require.NoError(t, p.storeReminderDelivery("user1", 100, 200))
+
+	info, apiErr := p.getGitHubUserInfo("user1")
+	require.Nil(t, apiErr)
+	assert.Equal(t, "token2", info.Token.AccessToken)
+	assert.Equal(t, "refresh2", info.Token.RefreshToken)
+	assert.Equal(t, int64(100), info.LastReminderAt)
+	assert.Equal(t, int64(200), info.LastToDoPostAt)
+}
+
+func TestSendDailyReminderUnlistsUser(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EncryptionKey: testEncryptionKey})
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+		UserID:   "user1",
+		Token:    &oauth2.Token{AccessToken: "token1"},
+		Settings: &UserSettings{},
+	}))
+	for _, userID := range []string{"user1", "user2"} {
+		require.NoError(t, p.setUserIndexed(reminderUsersKey, userID, true))
+	}
+
+	// user1 turned the reminder off and user2 disconnected.
+	require.NoError(t, p.sendDailyReminder("user1", time.Now()))
+	require.NoError(t, p.sendDailyReminder("user2", time.Now()))
+
+	userIDs, err := p.listIndexedUserIDs(reminderUsersKey)
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+}
@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/bot/logger"
+	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/flow"
+)
+
+// startDeviceConnect starts connecting the GitHub account of a user with the device authorization flow.
+// The user code is sent to the user in a DM, and the plugin polls GitHub for the token in the background.
+func (p *Plugin) startDeviceConnect(userID, instance string, privateAllowed bool) error {
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		return err
+	}
+	if config.UsePreregisteredApplication {
+		return errors.New("the device flow isn't supported with the pre-registered application")
+	}
+
+	conf := p.getOAuthConfigWithConfig(config, privateAllowed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	deviceAuth, err := conf.DeviceAuth(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to request a device code")
+	}
+
+	message := fmt.Sprintf("To connect your GitHub account, open %s and enter the code `%s`.", deviceAuth.VerificationURI, deviceAuth.UserCode)
+	if !deviceAuth.Expiry.IsZero() {
+		message += fmt.Sprintf(" The code expires in %d minutes.", int(time.Until(deviceAuth.Expiry).Round(time.Minute).Minutes()))
+	}
+	p.CreateBotDMPost(userID, message, "custom_git_device_code")
+
+	go p.completeDeviceConnect(conf, deviceAuth, userID, instance, privateAllowed)
+
+	return nil
+}
+
+// completeDeviceConnect polls GitHub until the user entered the code, and connects their account.
+// The completion is published to the OAuthBroker, so the setup flow continues on any plugin instance.
+func (p *Plugin) completeDeviceConnect(conf *oauth2.Config, deviceAuth *oauth2.DeviceAuthResponse, userID, instance string, privateAllowed bool) {
+	var rErr error
+	defer func() {
+		p.oauthBroker.publishOAuthComplete(userID, rErr, false)
+	}()
+
+	// DeviceAccessToken stops polling when the device code expires, or when the plugin is deactivated.
+	tok, err := conf.DeviceAccessToken(p.lifetimeCtx, deviceAuth)
+	if err != nil {
+		rErr = errors.Wrap(err, "failed to get the token of the device code")
+		if p.lifetimeCtx.Err() != nil {
+			// the code didn't expire, the plugin was deactivated while waiting for it.
+			return
+		}
+		p.client.Log.Warn("Failed to connect with the device flow", "userID", userID, "error", err.Error())
+
+		if _, err = p.poster.DMWithAttachments(userID, &model.SlackAttachment{
+			Text:  "Your GitHub account couldn't be connected, the code wasn't entered in time or the access was denied. Please try `/github connect device" + getInstanceCommandSuffix(instance) + "` again.",
+			Color: string(flow.ColorDanger),
+		}); err != nil {
+			p.client.Log.Warn("Failed to DM the device flow failure", "userID", userID, "error", err.Error())
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauthCompleteTimeout)
+	defer cancel()
+
+	c := &Context{
+		Ctx:    ctx,
+		UserID: userID,
+		Log: logger.New(p.API).With(logger.LogContext{
+			"userid": userID,
+		}),
+	}
+
+	rErr = p.connectGitHubAccount(c, tok, instance, privateAllowed)
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/bot/poster"
+)
+
+func TestStartDeviceConnectWithPreregisteredApplication(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{UsePreregisteredApplication: true})
+
+	err := p.startDeviceConnect("user1", "", false)
+	assert.EqualError(t, err, "the device flow isn't supported with the pre-registered application")
+}
+
+func TestCompleteDeviceConnectDenied(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login/oauth/access_token", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"access_denied"}`))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EnterpriseBaseURL: ts.URL})
+	p.BotUserID = "bot"
+
+	api := &plugintest.API{}
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("GetDirectChannel", "bot", "user1").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("CreatePost", mock.Anything).Return(&model.Post{}, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+	p.poster = poster.NewPoster(&p.client.Post, p.BotUserID)
+
+	var events []OAuthCompleteEvent
+	p.oauthBroker = NewOAuthBroker(func(event OAuthCompleteEvent) {
+		events = append(events, event)
+	})
+	ch := p.oauthBroker.SubscribeOAuthComplete("user1")
+
+	p.completeDeviceConnect(p.getOAuthConfig(false), &oauth2.DeviceAuthResponse{DeviceCode: "code", Interval: 1}, "user1", "", false)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Error(t, events[0].Err)
+	assert.Error(t, <-ch)
+	api.AssertNumberOfCalls(t, "CreatePost", 1)
+}
+
+func TestCompleteDeviceConnectDeactivated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EnterpriseBaseURL: ts.URL})
+
+	api := &plugintest.API{}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	var events []OAuthCompleteEvent
+	p.oauthBroker = NewOAuthBroker(func(event OAuthCompleteEvent) {
+		events = append(events, event)
+	})
+
+	// the polling stops when the plugin is deactivated, without waiting for the code to expire.
+	p.cancelLifetime()
+	p.completeDeviceConnect(p.getOAuthConfig(false), &oauth2.DeviceAuthResponse{DeviceCode: "code", Interval: 1}, "user1", "", false)
+
+	require.Len(t, events, 1)
+	assert.Error(t, events[0].Err)
+	api.AssertNotCalled(t, "CreatePost", mock.Anything)
+}
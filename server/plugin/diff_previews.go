@@ -0,0 +1,309 @@
+package plugin
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v54/github"
+)
+
+// maxDiffPreviewLines sets the maximum number of diff lines shown
+// while replacing a commit or pull request diff link.
+const maxDiffPreviewLines = 20
+
+// maxDiffFilesListed sets the maximum number of files listed in a commit summary.
+const maxDiffFilesListed = 5
+
+// diffAnchorRegex is the part of the regexes matching the #diff-<file hash>R10-R20 anchors of diff links.
+const diffAnchorRegex = `#diff-(?P<file>[0-9a-f]{32,64})(?:(?P<side>[LR])(?P<start>\d+)(?:-[LR](?P<end>\d+))?)?`
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// diffRegexes caches the regexes returned by getDiffRegexes by host.
+var diffRegexes sync.Map
+
+// getDiffRegexes returns the regexes matching the commit and pull request diff links of the given GitHub host.
+func getDiffRegexes(host string) []*regexp.Regexp {
+	if res, ok := diffRegexes.Load(host); ok {
+		return res.([]*regexp.Regexp)
+	}
+
+	prefix := `https?://(?:www\.)?` + regexp.QuoteMeta(host) + `/(?P<user>[\w-]+)/(?P<repo>[\w-.]+)/`
+	res := []*regexp.Regexp{
+		regexp.MustCompile(prefix + `commit/(?P<commit>[0-9a-f]{7,40})\b(?:` + diffAnchorRegex + `)?`),
+		regexp.MustCompile(prefix + `pull/(?P<number>\d+)/files(?:/[0-9a-f]{7,40})?` + diffAnchorRegex),
+	}
+	diffRegexes.Store(host, res)
+	return res
+}
+
+// diffReplacement holds necessary info to replace commit and pull request
+// diff links in messages with a diff preview block.
+type diffReplacement struct {
+	index    int    // index of the link in the string
+	word     string // the link
+	user     string
+	repo     string
+	commit   string // set for commit links
+	number   int    // set for pull request diff links
+	fileHash string // hash of the file name in the diff anchor, if any
+	side     string // L for the old version of the file, R for the new one
+	start    int
+	end      int
+}
+
+// getDiffReplacements returns the commit and pull request diff link replacements of the given GitHub host that
+// needs to be performed on a message. The returned slice is sorted by the index in ascending order.
+func getDiffReplacements(msg, host string) []diffReplacement {
+	var replacements []diffReplacement
+	for _, re := range getDiffRegexes(host) {
+		for _, m := range re.FindAllStringSubmatchIndex(msg, -1) {
+			// have a limit on the number of replacements to do
+			if len(replacements) >= maxPermalinkReplacements {
+				break
+			}
+			// ignore if the word is inside a link
+			if isInsideLink(msg, m[0]) {
+				continue
+			}
+
+			r := diffReplacement{
+				index: m[0],
+				word:  msg[m[0]:m[1]],
+			}
+			for j, name := range re.SubexpNames() {
+				if j == 0 || m[2*j] == -1 {
+					continue
+				}
+				value := msg[m[2*j]:m[2*j+1]]
+				switch name {
+				case "user":
+					r.user = value
+				case "repo":
+					r.repo = value
+				case "commit":
+					r.commit = value
+				case "number":
+					r.number, _ = strconv.Atoi(value)
+				case "file":
+					r.fileHash = value
+				case "side":
+					r.side = value
+				case "start":
+					r.start, _ = strconv.Atoi(value)
+				case "end":
+					r.end, _ = strconv.Atoi(value)
+				}
+			}
+			if r.end == 0 {
+				r.end = r.start
+			}
+
+			replacements = append(replacements, r)
+		}
+	}
+
+	// Both regexes are applied one after the other, so the replacements need to be sorted again.
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].index < replacements[j].index
+	})
+
+	return replacements
+}
+
+// makeDiffReplacements perform the given replacements on the msg and returns
+// the new msg. The replacements slice needs to be sorted by the index in ascending order.
+func (p *Plugin) makeDiffReplacements(ctx context.Context, msg string, replacements []diffReplacement, ghClient *github.Client, repos *repositoryLookup, channelID string) string {
+	// iterating the slice in reverse to preserve the replacement indices.
+	for i := len(replacements) - 1; i >= 0; i-- {
+		r := replacements[i]
+
+		allowed, userCanAccess := p.isCodePreviewAllowed(ctx, ghClient, repos, channelID, r.user, r.repo)
+		if !allowed {
+			continue
+		}
+		previewClient := p.getPreviewClient(ctx, ghClient, repos, r.user, r.repo, userCanAccess)
+
+		var final string
+		var err error
+		if r.commit != "" {
+			final, err = p.getCommitPreview(ctx, previewClient, r)
+		} else {
+			final, err = p.getPullRequestDiffPreview(ctx, previewClient, r)
+		}
+		if err != nil {
+			p.client.Log.Warn("Error while fetching diff", "error", err.Error(), "link", r.word)
+			continue
+		}
+		if final == "" {
+			continue
+		}
+
+		// replace word in msg starting from r.index only once.
+		msg = msg[:r.index] + strings.Replace(msg[r.index:], r.word, final, 1)
+	}
+	return msg
+}
+
+func (p *Plugin) getCommitPreview(ctx context.Context, ghClient *github.Client, r diffReplacement) (string, error) {
+	commit, _, err := ghClient.Repositories.GetCommit(ctx, r.user, r.repo, r.commit, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if r.fileHash != "" {
+		file := findDiffFile(commit.Files, r.fileHash)
+		if file == nil {
+			return "", nil
+		}
+		return getDiffMarkdown(r, file), nil
+	}
+
+	message, _, _ := strings.Cut(commit.GetCommit().GetMessage(), "\n")
+	sha := commit.GetSHA()
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+
+	final := fmt.Sprintf("\n[%s/%s@%s](%s) %s - %s\n", r.user, r.repo, sha, r.word, escapeMarkdownLinkText(message), commit.GetCommit().GetAuthor().GetName())
+	final += fmt.Sprintf("%d files changed, +%d -%d\n", len(commit.Files), commit.GetStats().GetAdditions(), commit.GetStats().GetDeletions())
+	for i, file := range commit.Files {
+		if i == maxDiffFilesListed {
+			final += fmt.Sprintf("* and %d more files\n", len(commit.Files)-maxDiffFilesListed)
+			break
+		}
+		final += fmt.Sprintf("* `%s` +%d -%d\n", file.GetFilename(), file.GetAdditions(), file.GetDeletions())
+	}
+
+	var patch strings.Builder
+	for _, file := range commit.Files {
+		if file.GetPatch() == "" {
+			continue
+		}
+		patch.WriteString("--- " + file.GetFilename() + "\n")
+		patch.WriteString(file.GetPatch() + "\n")
+	}
+	if lines, isTruncated := truncateLines(patch.String(), maxDiffPreviewLines); lines != "" {
+		final += getDiffCodeBlock(lines, isTruncated)
+	}
+
+	return final, nil
+}
+
+func (p *Plugin) getPullRequestDiffPreview(ctx context.Context, ghClient *github.Client, r diffReplacement) (string, error) {
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := ghClient.PullRequests.ListFiles(ctx, r.user, r.repo, r.number, opt)
+		if err != nil {
+			return "", err
+		}
+		if file := findDiffFile(files, r.fileHash); file != nil {
+			return getDiffMarkdown(r, file), nil
+		}
+		if resp.NextPage == 0 {
+			return "", nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// findDiffFile returns the file whose name matches the hash of a diff anchor.
+// GitHub uses the SHA-256 of the file name, and used its MD5 in older links.
+func findDiffFile(files []*github.CommitFile, fileHash string) *github.CommitFile {
+	for _, file := range files {
+		sha256Sum := sha256.Sum256([]byte(file.GetFilename()))
+		md5Sum := md5.Sum([]byte(file.GetFilename())) //nolint:gosec
+		if fileHash == hex.EncodeToString(sha256Sum[:]) || fileHash == hex.EncodeToString(md5Sum[:]) {
+			return file
+		}
+	}
+	return nil
+}
+
+// getDiffMarkdown returns the constructed markdown for a diff link pointing to a file.
+func getDiffMarkdown(r diffReplacement, file *github.CommitFile) string {
+	patch := file.GetPatch()
+	if r.side != "" {
+		patch = filterDiffLines(patch, r.side, r.start, r.end)
+	}
+	lines, isTruncated := truncateLines(patch, maxDiffPreviewLines)
+	if lines == "" {
+		return ""
+	}
+
+	final := fmt.Sprintf("\n[%s/%s/%s](%s)\n", r.user, r.repo, strings.ReplaceAll(file.GetFilename(), "_", "\\_"), r.word)
+	return final + getDiffCodeBlock(lines, isTruncated)
+}
+
+func getDiffCodeBlock(lines string, isTruncated bool) string {
+	final := "```diff\n"
+	final += lines
+	if isTruncated { // add an ellipsis if lines were cut off
+		final += "...\n"
+	}
+	final += "```\n"
+	return final
+}
+
+// filterDiffLines returns the lines of a patch between start and end. The line numbers refer to the
+// old version of the file if side is L, and to the new version if side is R.
+func filterDiffLines(patch, side string, start, end int) string {
+	var buf strings.Builder
+	oldLine, newLine := 0, 0
+	for _, line := range strings.Split(patch, "\n") {
+		if m := hunkHeaderRegex.FindStringSubmatch(line); m != nil {
+			oldLine, _ = strconv.Atoi(m[1])
+			newLine, _ = strconv.Atoi(m[2])
+			continue
+		}
+
+		position := newLine
+		if side == "L" {
+			position = oldLine
+		}
+		if position >= start && position <= end {
+			buf.WriteString(line + "\n")
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			oldLine++
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" doesn't count as a line.
+		default:
+			oldLine++
+			newLine++
+		}
+	}
+
+	return buf.String()
+}
+
+// truncateLines returns at most max lines of s, each followed by a new line.
+func truncateLines(s string, max int) (lines string, isTruncated bool) {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return "", false
+	}
+
+	split := strings.Split(s, "\n")
+	if len(split) > max {
+		split = split[:max]
+		isTruncated = true
+	}
+	return strings.Join(split, "\n") + "\n", isTruncated
+}
+
+func escapeMarkdownLinkText(s string) string {
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(s)
+}
@@ -0,0 +1,163 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPatch = `@@ -10,5 +10,6 @@ func main() {
+ a := 1
+-b := 2
++b := 3
++c := 4
+ d := 5
+ e := 6`
+
+func TestGetDiffReplacements(t *testing.T) {
+	fileHash := "a3f0b6fa2e0a2fbbbdf5fd8a40fd2dfb3c86f5a4a0b6d3b5c0c1e8f3d2a1b0c9"
+	tcs := []struct {
+		name string
+		msg  string
+		want []diffReplacement
+	}{
+		{
+			name: "commit link",
+			msg:  "see https://github.com/mattermost/mattermost-server/commit/0123abc for details",
+			want: []diffReplacement{{
+				index:  4,
+				word:   "https://github.com/mattermost/mattermost-server/commit/0123abc",
+				user:   "mattermost",
+				repo:   "mattermost-server",
+				commit: "0123abc",
+			}},
+		},
+		{
+			name: "pull request diff link with a line range",
+			msg:  "https://github.com/mattermost/mattermost-server/pull/12/files#diff-" + fileHash + "R10-R20",
+			want: []diffReplacement{{
+				word:     "https://github.com/mattermost/mattermost-server/pull/12/files#diff-" + fileHash + "R10-R20",
+				user:     "mattermost",
+				repo:     "mattermost-server",
+				number:   12,
+				fileHash: fileHash,
+				side:     "R",
+				start:    10,
+				end:      20,
+			}},
+		},
+		{
+			name: "commit diff link with a single line",
+			msg:  "https://github.com/mattermost/mattermost-server/commit/0123abc#diff-" + fileHash + "L5",
+			want: []diffReplacement{{
+				word:     "https://github.com/mattermost/mattermost-server/commit/0123abc#diff-" + fileHash + "L5",
+				user:     "mattermost",
+				repo:     "mattermost-server",
+				commit:   "0123abc",
+				fileHash: fileHash,
+				side:     "L",
+				start:    5,
+				end:      5,
+			}},
+		},
+		{
+			name: "pull request link without a diff anchor",
+			msg:  "https://github.com/mattermost/mattermost-server/pull/12/files",
+			want: nil,
+		},
+		{
+			name: "commit link of another host",
+			msg:  "https://github.example.com/mattermost/mattermost-server/commit/0123abc",
+			want: nil,
+		},
+		{
+			name: "link inside a markdown link",
+			msg:  "[commit](https://github.com/mattermost/mattermost-server/commit/0123abc)",
+			want: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, getDiffReplacements(tc.msg, "github.com"))
+		})
+	}
+
+	t.Run("commit link of a GitHub Enterprise host", func(t *testing.T) {
+		msg := "https://github.example.com/mattermost/mattermost-server/commit/0123abc"
+		assert.Equal(t, []diffReplacement{{
+			word:   msg,
+			user:   "mattermost",
+			repo:   "mattermost-server",
+			commit: "0123abc",
+		}}, getDiffReplacements(msg, getGitHubHost("https://github.example.com/")))
+	})
+}
+
+func TestFilterDiffLines(t *testing.T) {
+	tcs := []struct {
+		name  string
+		side  string
+		start int
+		end   int
+		want  string
+	}{
+		{
+			name:  "new lines",
+			side:  "R",
+			start: 11,
+			end:   12,
+			want:  "-b := 2\n+b := 3\n+c := 4\n",
+		},
+		{
+			name:  "old lines",
+			side:  "L",
+			start: 11,
+			end:   11,
+			want:  "-b := 2\n",
+		},
+		{
+			name:  "out of range",
+			side:  "R",
+			start: 100,
+			end:   110,
+			want:  "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, filterDiffLines(testPatch, tc.side, tc.start, tc.end))
+		})
+	}
+}
+
+func TestFindDiffFile(t *testing.T) {
+	sum := sha256.Sum256([]byte("server/main.go"))
+	files := []*github.CommitFile{
+		{Filename: github.String("README.md")},
+		{Filename: github.String("server/main.go")},
+	}
+
+	file := findDiffFile(files, hex.EncodeToString(sum[:]))
+	require.NotNil(t, file)
+	assert.Equal(t, "server/main.go", file.GetFilename())
+	assert.Nil(t, findDiffFile(files, "0123"))
+}
+
+func TestTruncateLines(t *testing.T) {
+	lines, isTruncated := truncateLines("a\nb\nc\n", 2)
+	assert.Equal(t, "a\nb\n", lines)
+	assert.True(t, isTruncated)
+
+	lines, isTruncated = truncateLines("a\nb", 2)
+	assert.Equal(t, "a\nb\n", lines)
+	assert.False(t, isTruncated)
+
+	lines, _ = truncateLines("", 2)
+	assert.Empty(t, lines)
+}
@@ -0,0 +1,318 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	encryptionKeyRotationMutexKey   = "_encryptionkeyrotation"
+	encryptionKeyRotationPendingKey = "_encryptionkeyrotationpending"
+
+	// encryptionKeyRotationLockTimeout is how long a rotation waits for another one to finish before giving up.
+	encryptionKeyRotationLockTimeout = 5 * time.Second
+
+	// encryptionKeyRotationMaxScans is how many times the stored tokens are scanned before the rotation gives up
+	// on the tokens still encrypted with the previous key.
+	encryptionKeyRotationMaxScans = 5
+)
+
+// encryptionKeyRotationScanDelay is the time given to the other nodes of the cluster to apply the new key
+// before the stored tokens are scanned again.
+var encryptionKeyRotationScanDelay = time.Minute
+
+// encryptUserInfoTokens encrypts the tokens of the account in place.
+func encryptUserInfoTokens(info *GitHubUserInfo, key string) error {
+	encryptedToken, err := encrypt([]byte(key), info.Token.AccessToken)
+	if err != nil {
+		return errors.Wrap(err, "error occurred while encrypting access token")
+	}
+
+	info.Token.AccessToken = encryptedToken
+
+	// GitHub Apps issue refresh tokens along with expiring user-to-server tokens.
+	if info.Token.RefreshToken != "" {
+		encryptedRefreshToken, err := encrypt([]byte(key), info.Token.RefreshToken)
+		if err != nil {
+			return errors.Wrap(err, "error occurred while encrypting refresh token")
+		}
+
+		info.Token.RefreshToken = encryptedRefreshToken
+	}
+
+	return nil
+}
+
+// decryptUserInfoTokens decrypts the tokens of the account in place. reencrypt is true if a token was
+// encrypted with the previous key or with AES-CFB, and should be stored again.
+func decryptUserInfoTokens(info *GitHubUserInfo, key, previousKey string) (reencrypt bool, err error) {
+	accessToken, reencrypt, err := decryptWithKeys(key, previousKey, info.Token.AccessToken)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to decrypt access token")
+	}
+
+	info.Token.AccessToken = accessToken
+
+	if info.Token.RefreshToken != "" {
+		refreshToken, reencryptRefreshToken, err := decryptWithKeys(key, previousKey, info.Token.RefreshToken)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to decrypt refresh token")
+		}
+
+		info.Token.RefreshToken = refreshToken
+		reencrypt = reencrypt || reencryptRefreshToken
+	}
+
+	return reencrypt, nil
+}
+
+// decryptWithKeys decrypts a value encrypted with the current key or, after a key rotation, with the previous one.
+func decryptWithKeys(key, previousKey, text string) (plaintext string, reencrypt bool, err error) {
+	keys := []string{key, previousKey}
+	if !isEncryptedWithGCM(text) {
+		// AES-CFB values were stored before the first key rotation, and a wrong key isn't always detected.
+		keys = []string{previousKey, key}
+	}
+
+	err = errors.New("no encryption key")
+	for _, k := range keys {
+		if k == "" {
+			continue
+		}
+
+		plaintext, err = decrypt([]byte(k), text)
+		if err == nil {
+			return plaintext, k != key || !isEncryptedWithGCM(text), nil
+		}
+	}
+
+	return "", false, err
+}
+
+// reencryptGitHubUserInfo stores the account with its tokens encrypted with the given key. The account is only
+// stored if it still has the stored value, so a token refreshed in the meantime isn't overwritten.
+func (p *Plugin) reencryptGitHubUserInfo(userInfoKey string, stored []byte, info *GitHubUserInfo, key string) error {
+	encrypted := *info
+	token := *info.Token
+	encrypted.Token = &token
+
+	if err := encryptUserInfoTokens(&encrypted, key); err != nil {
+		return err
+	}
+
+	// if the account was changed in the meantime, it was stored with the current key already.
+	if _, err := p.store.Set(userInfoKey, &encrypted, pluginapi.SetAtomic(stored)); err != nil {
+		return errors.Wrap(err, "error occurred while trying to store user info into KV store")
+	}
+
+	return nil
+}
+
+// startEncryptionKeyRotation replaces the encryption key and re-encrypts the stored tokens in the background.
+// The previous key is kept to decrypt the tokens until they are re-encrypted. An unfinished rotation is resumed
+// instead of replacing the key again.
+func (p *Plugin) startEncryptionKeyRotation(userID string) (string, error) {
+	m, err := cluster.NewMutex(p.API, encryptionKeyRotationMutexKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create mutex")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), encryptionKeyRotationLockTimeout)
+	defer cancel()
+	if err = m.LockWithContext(ctx); err != nil {
+		return "The encryption key is already being rotated. You'll receive a direct message once the tokens are re-encrypted.", nil
+	}
+
+	message, err := p.rotateEncryptionKey(userID, m)
+	if err != nil {
+		m.Unlock()
+		return "", err
+	}
+
+	return message, nil
+}
+
+func (p *Plugin) rotateEncryptionKey(userID string, m *cluster.Mutex) (string, error) {
+	var pending bool
+	if err := p.store.Get(encryptionKeyRotationPendingKey, &pending); err != nil {
+		return "", errors.Wrap(err, "failed to get the pending key rotation")
+	}
+
+	config := p.getConfiguration().Clone()
+	message := "Resuming the unfinished rotation of the encryption key."
+
+	if !pending {
+		newKey, err := generateSecret()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate the encryption key")
+		}
+
+		// the rotation is marked pending first, so it's resumed if the configuration is saved but the job fails.
+		if _, err = p.store.Set(encryptionKeyRotationPendingKey, true); err != nil {
+			return "", errors.Wrap(err, "failed to store the pending key rotation")
+		}
+
+		config.PreviousEncryptionKey = config.EncryptionKey
+		config.EncryptionKey = newKey
+
+		configMap, err := config.ToMap()
+		if err != nil {
+			return "", err
+		}
+
+		if err = p.client.Configuration.SavePluginConfig(configMap); err != nil {
+			return "", errors.Wrap(err, "failed to save the plugin configuration")
+		}
+
+		message = "The encryption key was replaced."
+	}
+
+	go func() {
+		defer m.Unlock()
+		p.reencryptAllTokens(userID, config.EncryptionKey, config.PreviousEncryptionKey)
+	}()
+
+	return message + " The stored tokens are re-encrypted in the background, you'll receive a direct message once it's done.", nil
+}
+
+// reencryptAllTokens re-encrypts the tokens of all the connected users with the given key,
+// and notifies the user who started the rotation.
+//
+// The nodes of the cluster that haven't applied the new key yet keep storing tokens encrypted with the
+// previous one, so the tokens are scanned again until a scan finds none of them.
+func (p *Plugin) reencryptAllTokens(userID, key, previousKey string) {
+	reencrypted := 0
+	for scan := 1; ; scan++ {
+		count, err := p.reencryptStoredTokens(key, previousKey)
+		if err != nil {
+			p.client.Log.Warn("Failed to re-encrypt the stored tokens", "error", err.Error())
+			p.CreateBotDMPost(userID, "The stored tokens couldn't be re-encrypted, see the server logs for details. Please run `/github setup rotate-encryption-key` again to resume the rotation.", "custom_git_encryption_key_rotation")
+			return
+		}
+
+		reencrypted += count
+		if scan > 1 && count == 0 {
+			break
+		}
+
+		if scan == encryptionKeyRotationMaxScans {
+			p.CreateBotDMPost(userID, "Tokens encrypted with the previous encryption key are still being stored, so the key was kept in the configuration. Please check that all the servers of the cluster run the latest configuration and run `/github setup rotate-encryption-key` again to finish the rotation.", "custom_git_encryption_key_rotation")
+			return
+		}
+
+		select {
+		case <-p.lifetimeCtx.Done():
+			return
+		case <-time.After(encryptionKeyRotationScanDelay):
+		}
+	}
+
+	// the previous key is kept in the configuration until it's cleared, so the rotation can be resumed.
+	if err := p.clearPreviousEncryptionKey(previousKey); err != nil {
+		p.client.Log.Warn("Failed to clear the previous encryption key", "error", err.Error())
+		p.CreateBotDMPost(userID, "The stored tokens were re-encrypted, but the previous encryption key couldn't be removed from the configuration. Please run `/github setup rotate-encryption-key` again to resume the rotation.", "custom_git_encryption_key_rotation")
+		return
+	}
+
+	if err := p.store.Delete(encryptionKeyRotationPendingKey); err != nil {
+		p.client.Log.Warn("Failed to delete the pending key rotation", "error", err.Error())
+	}
+
+	p.CreateBotDMPost(userID, fmt.Sprintf("The encryption key was rotated and %d stored tokens were re-encrypted.", reencrypted), "custom_git_encryption_key_rotation")
+}
+
+// reencryptStoredTokens re-encrypts the stored tokens that need it with the given key. It returns the number of
+// re-encrypted tokens.
+func (p *Plugin) reencryptStoredTokens(key, previousKey string) (int, error) {
+	keys, err := p.listUserInfoKeys()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list the stored tokens")
+	}
+
+	reencrypted, failed := 0, 0
+	for _, userInfoKey := range keys {
+		done, err := p.reencryptUserToken(userInfoKey, key, previousKey)
+		if err != nil {
+			p.client.Log.Warn("Failed to re-encrypt the GitHub token", "key", userInfoKey, "error", err.Error())
+			failed++
+			continue
+		}
+		if done {
+			reencrypted++
+		}
+	}
+
+	if failed > 0 {
+		return 0, errors.Errorf("%d of %d stored tokens couldn't be re-encrypted", failed, len(keys))
+	}
+
+	return reencrypted, nil
+}
+
+// clearPreviousEncryptionKey removes the replaced key from the saved configuration once no token is encrypted
+// with it. The saved configuration is used since this node may not have applied the new key yet.
+func (p *Plugin) clearPreviousEncryptionKey(previousKey string) error {
+	configMap := p.client.Configuration.GetPluginConfig()
+	if configMap == nil {
+		return errors.New("failed to get the plugin configuration")
+	}
+	if storedKey, _ := configMap["previousencryptionkey"].(string); storedKey != previousKey {
+		return nil
+	}
+
+	configMap["previousencryptionkey"] = ""
+
+	if err := p.client.Configuration.SavePluginConfig(configMap); err != nil {
+		return errors.Wrap(err, "failed to save the plugin configuration")
+	}
+
+	return nil
+}
+
+// reencryptUserToken re-encrypts the tokens of an account with the given key, and returns true if they
+// needed it. The refresh mutex of the user is held, so a token refreshed at the same time isn't lost.
+func (p *Plugin) reencryptUserToken(userInfoKey, key, previousKey string) (bool, error) {
+	m, err := cluster.NewMutex(p.API, userInfoKey+githubTokenRefreshMutexKey)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create mutex")
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	var data []byte
+	if err = p.store.Get(userInfoKey, &data); err != nil {
+		return false, errors.Wrap(err, "failed to get the user info")
+	}
+
+	var info *GitHubUserInfo
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &info); err != nil {
+			return false, errors.Wrap(err, "failed to parse the user info")
+		}
+	}
+	if info == nil {
+		// the user was disconnected.
+		return false, nil
+	}
+
+	reencrypt, err := decryptUserInfoTokens(info, key, previousKey)
+	if err != nil {
+		return false, err
+	}
+	if !reencrypt {
+		return false, nil
+	}
+
+	if err = p.reencryptGitHubUserInfo(userInfoKey, data, info, key); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
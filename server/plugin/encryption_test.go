@@ -0,0 +1,208 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	testEncryptionKey         = "abcdefghijklmnopqrstuvwxyz012345"
+	testPreviousEncryptionKey = "543210zyxwvutsrqponmlkjihgfedcba"
+)
+
+// encryptCFB encrypts the value like previous versions of the plugin did.
+func encryptCFB(t *testing.T, key, text string) string {
+	block, err := aes.NewCipher([]byte(key))
+	require.NoError(t, err)
+
+	padding := aes.BlockSize - len(text)%aes.BlockSize
+	msg := append([]byte(text), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, aes.BlockSize+len(msg))
+	iv := ciphertext[:aes.BlockSize]
+	_, err = io.ReadFull(rand.Reader, iv)
+	require.NoError(t, err)
+
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(ciphertext[aes.BlockSize:], msg)
+	return base64.URLEncoding.EncodeToString(ciphertext)
+}
+
+func TestEncrypt(t *testing.T) {
+	encrypted, err := encrypt([]byte(testEncryptionKey), "token")
+	require.NoError(t, err)
+	assert.True(t, isEncryptedWithGCM(encrypted))
+
+	decrypted, err := decrypt([]byte(testEncryptionKey), encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "token", decrypted)
+
+	_, err = decrypt([]byte(testPreviousEncryptionKey), encrypted)
+	assert.Error(t, err)
+
+	_, err = decrypt([]byte(testEncryptionKey), encrypted[:len(encrypted)-4]+"AAAA")
+	assert.Error(t, err)
+
+	decrypted, err = decrypt([]byte(testEncryptionKey), encryptCFB(t, testEncryptionKey, "legacy-token"))
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-token", decrypted)
+
+	_, err = decrypt([]byte(testEncryptionKey), base64.URLEncoding.EncodeToString(make([]byte, aes.BlockSize)))
+	assert.Error(t, err)
+}
+
+func TestDecryptWithKeys(t *testing.T) {
+	encryptedWithKey, err := encrypt([]byte(testEncryptionKey), "token")
+	require.NoError(t, err)
+	encryptedWithPreviousKey, err := encrypt([]byte(testPreviousEncryptionKey), "token")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name            string
+		previousKey     string
+		text            string
+		expectReencrypt bool
+		expectErr       bool
+		expectPlaintext string
+	}{
+		{
+			name:            "current key",
+			text:            encryptedWithKey,
+			expectPlaintext: "token",
+		},
+		{
+			name:            "previous key",
+			previousKey:     testPreviousEncryptionKey,
+			text:            encryptedWithPreviousKey,
+			expectPlaintext: "token",
+			expectReencrypt: true,
+		},
+		{
+			name:      "previous key without rotation",
+			text:      encryptedWithPreviousKey,
+			expectErr: true,
+		},
+		{
+			name:            "legacy value",
+			text:            encryptCFB(t, testEncryptionKey, "token"),
+			expectPlaintext: "token",
+			expectReencrypt: true,
+		},
+		{
+			name:            "legacy value encrypted before the rotation",
+			previousKey:     testPreviousEncryptionKey,
+			text:            encryptCFB(t, testPreviousEncryptionKey, "token"),
+			expectPlaintext: "token",
+			expectReencrypt: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			plaintext, reencrypt, err := decryptWithKeys(testEncryptionKey, tc.previousKey, tc.text)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectPlaintext, plaintext)
+			assert.Equal(t, tc.expectReencrypt, reencrypt)
+		})
+	}
+}
+
+func TestGetGitHubUserInfoReencryptsTokens(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EncryptionKey: testEncryptionKey})
+	p.store = &pluginapi.MemoryStore{}
+
+	_, err := p.store.Set(getUserInfoKey("user1", ""), &GitHubUserInfo{
+		UserID: "user1",
+		Token:  &oauth2.Token{AccessToken: encryptCFB(t, testEncryptionKey, "token")},
+	})
+	require.NoError(t, err)
+
+	info, apiErr := p.getGitHubUserInfo("user1")
+	require.Nil(t, apiErr)
+	assert.Equal(t, "token", info.Token.AccessToken)
+
+	var stored *GitHubUserInfo
+	require.NoError(t, p.store.Get(getUserInfoKey("user1", ""), &stored))
+	assert.True(t, isEncryptedWithGCM(stored.Token.AccessToken))
+
+	info, apiErr = p.getGitHubUserInfo("user1")
+	require.Nil(t, apiErr)
+	assert.Equal(t, "token", info.Token.AccessToken)
+}
+
+func TestReencryptAllTokens(t *testing.T) {
+	encryptionKeyRotationScanDelay = 0
+	defer func() { encryptionKeyRotationScanDelay = time.Minute }()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EncryptionKey: testPreviousEncryptionKey})
+	p.BotUserID = "bot"
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	// the saved configuration is used, since this node didn't apply the new key yet.
+	api.On("GetPluginConfig").Return(map[string]any{"encryptionkey": testEncryptionKey, "previousencryptionkey": testPreviousEncryptionKey})
+	api.On("SavePluginConfig", mock.MatchedBy(func(config map[string]any) bool {
+		return config["encryptionkey"] == testEncryptionKey && config["previousencryptionkey"] == ""
+	})).Return(nil).Once()
+	api.On("GetDirectChannel", "admin", "bot").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.Message == "The encryption key was rotated and 2 stored tokens were re-encrypted."
+	})).Return(&model.Post{}, nil).Once()
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	encryptedWithPreviousKey, err := encrypt([]byte(testPreviousEncryptionKey), "token1")
+	require.NoError(t, err)
+	_, err = p.store.Set(getUserInfoKey("user1", ""), &GitHubUserInfo{UserID: "user1", Token: &oauth2.Token{AccessToken: encryptedWithPreviousKey}})
+	require.NoError(t, err)
+	_, err = p.store.Set(getUserInfoKey("user2", "ghes"), map[string]any{
+		"UserID": "user2",
+		"Token":  map[string]string{"access_token": encryptCFB(t, testPreviousEncryptionKey, "token2"), "refresh_token": encryptCFB(t, testPreviousEncryptionKey, "refresh2")},
+	})
+	require.NoError(t, err)
+	_, err = p.store.Set(encryptionKeyRotationPendingKey, true)
+	require.NoError(t, err)
+
+	p.reencryptAllTokens("admin", testEncryptionKey, testPreviousEncryptionKey)
+
+	for key, expected := range map[string]oauth2.Token{
+		getUserInfoKey("user1", ""):     {AccessToken: "token1"},
+		getUserInfoKey("user2", "ghes"): {AccessToken: "token2", RefreshToken: "refresh2"},
+	} {
+		var data []byte
+		require.NoError(t, p.store.Get(key, &data))
+		var stored *GitHubUserInfo
+		require.NoError(t, json.Unmarshal(data, &stored))
+
+		reencrypt, err := decryptUserInfoTokens(stored, testEncryptionKey, "")
+		require.NoError(t, err)
+		assert.False(t, reencrypt)
+		assert.Equal(t, expected.AccessToken, stored.Token.AccessToken)
+		assert.Equal(t, expected.RefreshToken, stored.Token.RefreshToken)
+	}
+
+	var pending bool
+	require.NoError(t, p.store.Get(encryptionKeyRotationPendingKey, &pending))
+	assert.False(t, pending)
+	api.AssertExpectations(t)
+}
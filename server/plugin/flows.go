@@ -683,7 +683,8 @@ func (fm *FlowManager) submitWebhook(f *flow.Flow, submitted map[string]interfac
 		return "", nil, nil, errors.New("invalid format")
 	}
 
-	webhookEvents := []string{"create", "delete", "issue_comment", "issues", "pull_request", "pull_request_review", "pull_request_review_comment", "push", "star"}
+	// label, member, milestone and repository events invalidate the cached GitHub responses.
+	webhookEvents := []string{"create", "delete", "issue_comment", "issues", "label", "member", "milestone", "pull_request", "pull_request_review", "pull_request_review_comment", "push", "repository", "star"}
 
 	webhookConfig := map[string]interface{}{
 		"content_type": "json",
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v54/github"
+)
+
+// githubGistRegex matches gist links, optionally pointing to one of the gist files.
+var githubGistRegex = regexp.MustCompile(`https?://gist\.github\.com/(?:[\w-]+/)?(?P<id>[0-9a-f]{20,32})\b(?:#file-(?P<file>[\w-]+))?`)
+
+// gistFileAnchorRegex matches the characters replaced by dashes in the anchors of gist files.
+var gistFileAnchorRegex = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// gistReplacement holds necessary info to replace gist links in messages with a code preview block.
+type gistReplacement struct {
+	index      int    // index of the link in the string
+	word       string // the link
+	id         string
+	fileAnchor string
+}
+
+// getGistReplacements returns the gist link replacements that needs to be performed
+// on a message. The returned slice is sorted by the index in ascending order.
+func getGistReplacements(msg string) []gistReplacement {
+	var replacements []gistReplacement
+	for _, m := range githubGistRegex.FindAllStringSubmatchIndex(msg, -1) {
+		// have a limit on the number of replacements to do
+		if len(replacements) >= maxPermalinkReplacements {
+			break
+		}
+		// ignore if the word is inside a link
+		if isInsideLink(msg, m[0]) {
+			continue
+		}
+
+		r := gistReplacement{
+			index: m[0],
+			word:  msg[m[0]:m[1]],
+			id:    msg[m[2]:m[3]],
+		}
+		if m[4] != -1 {
+			r.fileAnchor = msg[m[4]:m[5]]
+		}
+		replacements = append(replacements, r)
+	}
+	return replacements
+}
+
+// makeGistReplacements perform the given replacements on the msg and returns
+// the new msg. The replacements slice needs to be sorted by the index in ascending order.
+func (p *Plugin) makeGistReplacements(ctx context.Context, msg string, replacements []gistReplacement, ghClient *github.Client, channelID string) string {
+	// iterating the slice in reverse to preserve the replacement indices.
+	for i := len(replacements) - 1; i >= 0; i-- {
+		r := replacements[i]
+
+		gist, _, err := ghClient.Gists.Get(ctx, r.id)
+		if err != nil {
+			p.client.Log.Warn("Error while fetching gist", "error", err.Error(), "id", r.id)
+			continue
+		}
+
+		// secret gists are handled like private repositories.
+		if !gist.GetPublic() && !p.isPrivatePreviewAllowed(ctx, channelID, "", "") {
+			continue
+		}
+
+		file := getGistFile(gist, r.fileAnchor)
+		if file == nil {
+			continue
+		}
+
+		lines, isTruncated := truncateLines(file.GetContent(), maxPreviewLines)
+		if lines == "" {
+			continue
+		}
+
+		final := getGistMarkdown(gist.GetOwner().GetLogin(), file.GetFilename(), r.word, lines, isTruncated)
+
+		// replace word in msg starting from r.index only once.
+		msg = msg[:r.index] + strings.Replace(msg[r.index:], r.word, final, 1)
+	}
+	return msg
+}
+
+// getGistFile returns the gist file the link points to, or the first file
+// as displayed by GitHub if the link doesn't point to a file.
+func getGistFile(gist *github.Gist, fileAnchor string) *github.GistFile {
+	var names []string
+	for name := range gist.Files {
+		names = append(names, string(name))
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	if fileAnchor != "" {
+		for _, name := range names {
+			if getGistFileAnchor(name) == fileAnchor {
+				file := gist.Files[github.GistFilename(name)]
+				return &file
+			}
+		}
+	}
+
+	file := gist.Files[github.GistFilename(names[0])]
+	return &file
+}
+
+// getGistFileAnchor returns the anchor GitHub uses to link to a gist file without its file- prefix,
+// e.g. main-go for main.go.
+func getGistFileAnchor(filename string) string {
+	return strings.Trim(gistFileAnchorRegex.ReplaceAllString(strings.ToLower(filename), "-"), "-")
+}
+
+// getGistMarkdown returns the constructed markdown for a gist link.
+func getGistMarkdown(owner, filename, word, lines string, isTruncated bool) string {
+	title := strings.ReplaceAll(filename, "_", "\\_")
+	if owner != "" {
+		title = strings.ReplaceAll(owner, "_", "\\_") + "/" + title
+	}
+	final := fmt.Sprintf("\n[gist: %s](%s)\n", title, word)
+	ext := path.Ext(filename)
+	// remove the preceding dot
+	if len(ext) > 1 {
+		ext = strings.TrimPrefix(ext, ".")
+	}
+	final += "```" + ext + "\n"
+	final += lines
+	if isTruncated { // add an ellipsis if lines were cut off
+		final += "...\n"
+	}
+	final += "```\n"
+	return final
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGistReplacements(t *testing.T) {
+	tcs := []struct {
+		name string
+		msg  string
+		want []gistReplacement
+	}{
+		{
+			name: "gist link with owner",
+			msg:  "see https://gist.github.com/octocat/6cad326836d38bd3a7ae please",
+			want: []gistReplacement{{index: 4, word: "https://gist.github.com/octocat/6cad326836d38bd3a7ae", id: "6cad326836d38bd3a7ae"}},
+		},
+		{
+			name: "gist link to a file",
+			msg:  "https://gist.github.com/6cad326836d38bd3a7ae#file-main-go",
+			want: []gistReplacement{{word: "https://gist.github.com/6cad326836d38bd3a7ae#file-main-go", id: "6cad326836d38bd3a7ae", fileAnchor: "main-go"}},
+		},
+		{
+			name: "gist link inside a markdown link",
+			msg:  "[gist](https://gist.github.com/octocat/6cad326836d38bd3a7ae)",
+			want: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, getGistReplacements(tc.msg))
+		})
+	}
+}
+
+func TestGetGistFile(t *testing.T) {
+	gist := &github.Gist{
+		Files: map[github.GistFilename]github.GistFile{
+			"main.go":     {Filename: github.String("main.go")},
+			"README.md":   {Filename: github.String("README.md")},
+			"my_test.py":  {Filename: github.String("my_test.py")},
+			"Makefile.mk": {Filename: github.String("Makefile.mk")},
+		},
+	}
+
+	file := getGistFile(gist, "")
+	require.NotNil(t, file)
+	assert.Equal(t, "Makefile.mk", file.GetFilename())
+
+	file = getGistFile(gist, "my_test-py")
+	require.NotNil(t, file)
+	assert.Equal(t, "my_test.py", file.GetFilename())
+
+	assert.Nil(t, getGistFile(&github.Gist{}, ""))
+}
+
+func TestGetGistMarkdown(t *testing.T) {
+	assert.Equal(t, "\n[gist: octocat/main.go](https://gist.github.com/6cad326836d38bd3a7ae)\n```go\npackage main\n...\n```\n",
+		getGistMarkdown("octocat", "main.go", "https://gist.github.com/6cad326836d38bd3a7ae", "package main\n", true))
+}
@@ -0,0 +1,239 @@
+package plugin
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime is the lifetime of the JWTs authenticating as the GitHub App. GitHub allows at most 10 minutes.
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockDrift is subtracted from the issue time of the JWTs to allow for clock drift with GitHub.
+	appJWTClockDrift = time.Minute
+	// installationTokenExpiryDelta is how long before their expiry installation tokens are renewed.
+	installationTokenExpiryDelta = 5 * time.Minute
+)
+
+// installationTokenCache caches the installation tokens of the GitHub App per account.
+type installationTokenCache struct {
+	lock   sync.Mutex
+	tokens map[string]*oauth2.Token
+}
+
+func newInstallationTokenCache() *installationTokenCache {
+	return &installationTokenCache{
+		tokens: map[string]*oauth2.Token{},
+	}
+}
+
+func (c *installationTokenCache) get(owner string) *oauth2.Token {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.tokens[strings.ToLower(owner)]
+}
+
+func (c *installationTokenCache) set(owner string, token *oauth2.Token) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.tokens[strings.ToLower(owner)] = token
+}
+
+// clear drops all the cached tokens, e.g. after the GitHub App configuration changed.
+func (c *installationTokenCache) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.tokens = map[string]*oauth2.Token{}
+}
+
+// parseAppPrivateKey parses the PEM encoded private key of a GitHub App.
+func parseAppPrivateKey(key string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(key)))
+	if block == nil {
+		return nil, errors.New("the private key is not PEM encoded")
+	}
+
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the private key")
+	}
+
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+
+	return privateKey, nil
+}
+
+// createAppJWT creates the JWT authenticating as the GitHub App, signed with RS256.
+func createAppJWT(appID string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockDrift).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign the JWT")
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// getAppClient returns a client authenticated as the GitHub App itself.
+// It can only be used to manage the installations of the app.
+func (p *Plugin) getAppClient() (*github.Client, error) {
+	config := p.getConfiguration()
+	if !config.IsGitHubAppConfigured() {
+		return nil, errors.New("the GitHub App is not configured")
+	}
+
+	key, err := parseAppPrivateKey(config.GitHubAppPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt, err := createAppJWT(config.GitHubAppID, key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return GetGitHubClient(oauth2.Token{AccessToken: jwt}, config)
+}
+
+// findAppInstallation returns the installation of the GitHub App covering the repository,
+// or the organization or user account if repo is empty.
+func findAppInstallation(ctx context.Context, appClient *github.Client, owner, repo string) (*github.Installation, error) {
+	if repo != "" {
+		installation, _, err := appClient.Apps.FindRepositoryInstallation(ctx, owner, repo)
+		return installation, err
+	}
+
+	installation, resp, err := appClient.Apps.FindOrganizationInstallation(ctx, owner)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		installation, _, err = appClient.Apps.FindUserInstallation(ctx, owner)
+	}
+
+	return installation, err
+}
+
+// getInstallationToken returns a token of the GitHub App installation on the account owning the repository.
+// Tokens are cached until shortly before they expire.
+func (p *Plugin) getInstallationToken(ctx context.Context, owner, repo string) (*oauth2.Token, error) {
+	if cached := p.installationTokens.get(owner); cached != nil && time.Now().Add(installationTokenExpiryDelta).Before(cached.Expiry) {
+		return cached, nil
+	}
+
+	appClient, err := p.getAppClient()
+	if err != nil {
+		return nil, err
+	}
+
+	installation, err := findAppInstallation(ctx, appClient, owner, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the GitHub App is not installed on %s", owner)
+	}
+
+	token, _, err := appClient.Apps.CreateInstallationToken(ctx, installation.GetID(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create an installation token")
+	}
+
+	oauthToken := &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "token",
+		Expiry:      token.GetExpiresAt().Time,
+	}
+	p.installationTokens.set(owner, oauthToken)
+
+	return oauthToken, nil
+}
+
+// getInstallationClient returns a client authenticated as the GitHub App installation on the account owning
+// the repository. An error is returned if the GitHub App isn't configured or isn't installed on the account.
+func (p *Plugin) getInstallationClient(ctx context.Context, owner, repo string) (*github.Client, error) {
+	token, err := p.getInstallationToken(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetGitHubClient(*token, p.getConfiguration())
+}
+
+// getPreviewClient returns the client used to fetch the content previewed in messages. The installation
+// client is preferred when the GitHub App is installed, so previews don't use the rate limit of the users.
+// It is only used if the user posting the message can access the repository, which is only checked here if
+// userCanAccess is false, i.e. if isCodePreviewAllowed didn't check it already.
+func (p *Plugin) getPreviewClient(ctx context.Context, userClient *github.Client, repos *repositoryLookup, owner, repo string, userCanAccess bool) *github.Client {
+	config := p.getConfiguration()
+	if !config.IsGitHubAppConfigured() {
+		return userClient
+	}
+
+	// the GitHub App is only used for the default instance.
+	defaultClient, err := getGitHubClient(http.DefaultClient, config)
+	if err != nil || defaultClient.BaseURL.Host != userClient.BaseURL.Host {
+		return userClient
+	}
+
+	if !userCanAccess {
+		if _, err := repos.get(ctx, userClient, owner, repo); err != nil {
+			return userClient
+		}
+	}
+
+	installationClient, err := p.getInstallationClient(ctx, owner, repo)
+	if err != nil {
+		p.client.Log.Debug("Falling back to the user token for previews", "owner", owner, "repo", repo, "error", err.Error())
+		return userClient
+	}
+
+	return installationClient
+}
+
+// getAppInstallationNote returns a note shown after subscribing if the GitHub App isn't installed on the account,
+// as the events are only delivered for the installations of the app.
+func (p *Plugin) getAppInstallationNote(ctx context.Context, owner, repo string) string {
+	if _, err := p.getInstallationToken(ctx, owner, repo); err != nil {
+		p.client.Log.Debug("GitHub App installation not found", "owner", owner, "repo", repo, "error", err.Error())
+		return "\n**Note:** The GitHub App isn't installed on " + owner + ". Install it to receive the events of this subscription."
+	}
+
+	return ""
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAppPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name      string
+		key       string
+		expectErr bool
+	}{
+		{
+			name: "PKCS1 key",
+			key:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		},
+		{
+			name: "PKCS8 key with surrounding spaces",
+			key:  "\n " + string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+		},
+		{
+			name:      "not PEM encoded",
+			key:       "not a key",
+			expectErr: true,
+		},
+		{
+			name:      "invalid key",
+			key:       string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("invalid")})),
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := parseAppPrivateKey(tc.key)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, key.Equal(parsed))
+		})
+	}
+}
+
+func TestCreateAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	jwt, err := createAppJWT("12345", key, now)
+	require.NoError(t, err)
+
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	require.NoError(t, json.Unmarshal(rawClaims, &claims))
+	assert.Equal(t, "12345", claims.Issuer)
+	assert.Equal(t, now.Add(-appJWTClockDrift).Unix(), claims.IssuedAt)
+	assert.Equal(t, now.Add(appJWTLifetime).Unix(), claims.ExpiresAt)
+}
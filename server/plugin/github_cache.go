@@ -0,0 +1,224 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	githubCacheKeyPrefix        = "_ghcache_"
+	githubCacheVersionKeyPrefix = "_ghcachever_"
+
+	// githubCacheMaxAge is how long a cached response is used without asking GitHub. Webhook events
+	// invalidate the cached responses before.
+	githubCacheMaxAge = 5 * time.Minute
+
+	// githubCacheTTL is how long a cached response is kept to revalidate it with its ETag.
+	githubCacheTTL = 24 * time.Hour
+
+	githubCacheScopeLabels     = "labels"
+	githubCacheScopeMilestones = "milestones"
+	githubCacheScopeAssignees  = "assignees"
+	githubCacheScopeRepos      = "repos"
+)
+
+// cachedGitHubResponse is a GitHub response stored in the KV store.
+type cachedGitHubResponse struct {
+	ETag      string
+	Link      string
+	Body      []byte
+	FetchedAt int64
+}
+
+func (c *cachedGitHubResponse) toResponse(req *http.Request) *http.Response {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("ETag", c.ETag)
+	if c.Link != "" {
+		// go-github reads the next page from the Link header.
+		header.Set("Link", c.Link)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// githubCacheTransport caches the GET responses of a user for the endpoints returned by getGitHubCacheScope.
+// Stale responses are revalidated with If-None-Match, and the 304 responses GitHub sends back don't count
+// against the rate limit. The cache is in the KV store, so it's shared by the servers of the cluster.
+type githubCacheTransport struct {
+	base  http.RoundTripper
+	store kvStore
+	log   pluginapi.LogService
+	// userKey keeps the responses of each user apart, as they depend on the permissions of the user.
+	userKey string
+}
+
+func (t *githubCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	repo, scope := getGitHubCacheScope(req.URL.Path)
+	if req.Method != http.MethodGet || scope == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	var version int64
+	if err := t.store.Get(getGitHubCacheVersionKey(repo, scope), &version); err != nil {
+		t.log.Warn("Failed to get the version of the GitHub cache", "error", err.Error())
+		return t.base.RoundTrip(req)
+	}
+
+	key := getGitHubCacheKey(t.userKey, version, req)
+
+	var cached *cachedGitHubResponse
+	if err := t.store.Get(key, &cached); err != nil {
+		t.log.Warn("Failed to get the cached GitHub response", "error", err.Error())
+		cached = nil
+	}
+
+	if cached != nil && time.Since(time.UnixMilli(cached.FetchedAt)) < githubCacheMaxAge {
+		return cached.toResponse(req), nil
+	}
+
+	if cached != nil && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		cached.FetchedAt = time.Now().UnixMilli()
+		t.storeResponse(key, cached)
+		return cached.toResponse(req), nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		t.storeResponse(key, &cachedGitHubResponse{
+			ETag:      resp.Header.Get("ETag"),
+			Link:      resp.Header.Get("Link"),
+			Body:      body,
+			FetchedAt: time.Now().UnixMilli(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (t *githubCacheTransport) storeResponse(key string, cached *cachedGitHubResponse) {
+	if _, err := t.store.Set(key, cached, pluginapi.SetExpiry(githubCacheTTL)); err != nil {
+		t.log.Warn("Failed to store the GitHub response", "error", err.Error())
+	}
+}
+
+// getGitHubCacheScope returns the repository or organization of a cached endpoint, and the kind of data
+// invalidated together. The scope is empty for the endpoints that aren't cached.
+func getGitHubCacheScope(urlPath string) (repo, scope string) {
+	// GitHub Enterprise serves the API under /api/v3.
+	urlPath = strings.TrimPrefix(urlPath, "/api/v3")
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+
+	switch {
+	case len(parts) == 4 && parts[0] == "repos" && (parts[3] == githubCacheScopeLabels || parts[3] == githubCacheScopeMilestones || parts[3] == githubCacheScopeAssignees):
+		return parts[1] + "/" + parts[2], parts[3]
+	case len(parts) == 3 && (parts[0] == "orgs" || parts[0] == "users") && parts[2] == githubCacheScopeRepos:
+		return parts[1], githubCacheScopeRepos
+	case len(parts) == 2 && parts[0] == "user" && parts[1] == githubCacheScopeRepos:
+		return "", githubCacheScopeRepos
+	}
+
+	return "", ""
+}
+
+func getGitHubCacheVersionKey(repo, scope string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(repo) + "/" + scope))
+	return githubCacheVersionKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+func getGitHubCacheKey(userKey string, version int64, req *http.Request) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{userKey, strconv.FormatInt(version, 10), req.URL.String(), req.Header.Get("Accept")}, "\n")))
+	return githubCacheKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+// invalidateGitHubCache drops the cached responses of the scope for all the users, by changing its version.
+func (p *Plugin) invalidateGitHubCache(repo, scope string) {
+	if _, err := p.store.Set(getGitHubCacheVersionKey(repo, scope), time.Now().UnixNano()); err != nil {
+		p.client.Log.Warn("Failed to invalidate the GitHub cache", "repo", repo, "scope", scope, "error", err.Error())
+	}
+}
+
+// invalidateGitHubCacheForEvent drops the cached responses changed by a webhook event.
+func (p *Plugin) invalidateGitHubCacheForEvent(event interface{}) {
+	switch event := event.(type) {
+	case *github.LabelEvent:
+		p.invalidateGitHubCache(event.GetRepo().GetFullName(), githubCacheScopeLabels)
+	case *github.MilestoneEvent:
+		p.invalidateGitHubCache(event.GetRepo().GetFullName(), githubCacheScopeMilestones)
+	case *github.MemberEvent:
+		p.invalidateGitHubCache(event.GetRepo().GetFullName(), githubCacheScopeAssignees)
+	case *github.RepositoryEvent:
+		p.invalidateGitHubCache(event.GetRepo().GetOwner().GetLogin(), githubCacheScopeRepos)
+		p.invalidateGitHubCache("", githubCacheScopeRepos)
+	}
+}
+
+// githubConnectUserWithCache returns a client of the user whose GET requests to the endpoints returned by
+// getGitHubCacheScope are cached.
+func (p *Plugin) githubConnectUserWithCache(ctx context.Context, info *GitHubUserInfo) *github.Client {
+	config, err := p.getConfiguration().forInstance(info.Instance)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the configuration of the GitHub instance", "instance", info.Instance, "error", err.Error())
+		return nil
+	}
+
+	tc := oauth2.NewClient(context.Background(), p.getUserTokenSource(ctx, info))
+	tc.Transport = &rateLimitTransport{
+		base: &githubCacheTransport{
+			base:    tc.Transport,
+			store:   p.store,
+			log:     p.client.Log,
+			userKey: getUserInfoKey(info.UserID, info.Instance),
+		},
+		state:    p.getRateLimitState(info),
+		rejected: info.rateLimitRejected,
+	}
+
+	client, err := getGitHubClient(tc, config)
+	if err != nil {
+		p.client.Log.Warn("Failed to create GitHub client", "error", err.Error())
+		return nil
+	}
+
+	return client
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestGetGitHubCacheScope(t *testing.T) {
+	for _, tc := range []struct {
+		path          string
+		expectedRepo  string
+		expectedScope string
+	}{
+		{path: "/repos/owner/repo/labels", expectedRepo: "owner/repo", expectedScope: githubCacheScopeLabels},
+		{path: "/api/v3/repos/owner/repo/milestones", expectedRepo: "owner/repo", expectedScope: githubCacheScopeMilestones},
+		{path: "/repos/owner/repo/assignees", expectedRepo: "owner/repo", expectedScope: githubCacheScopeAssignees},
+		{path: "/repos/owner/repo/pulls/1/reviews"},
+		{path: "/repos/owner/repo/commits/abc/status"},
+		{path: "/orgs/owner/repos", expectedRepo: "owner", expectedScope: githubCacheScopeRepos},
+		{path: "/users/owner/repos", expectedRepo: "owner", expectedScope: githubCacheScopeRepos},
+		{path: "/user/repos", expectedScope: githubCacheScopeRepos},
+		{path: "/repos/owner/repo/issues/1"},
+		{path: "/notifications"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			repo, scope := getGitHubCacheScope(tc.path)
+			assert.Equal(t, tc.expectedRepo, repo)
+			assert.Equal(t, tc.expectedScope, scope)
+		})
+	}
+}
+
+func TestGitHubCacheTransport(t *testing.T) {
+	requests := 0
+	var ifNoneMatch string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		ifNoneMatch = r.Header.Get("If-None-Match")
+		if ifNoneMatch == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"name": "bug"}]`))
+	}))
+	defer server.Close()
+
+	store := &pluginapi.MemoryStore{}
+	transport := &githubCacheTransport{
+		base:    http.DefaultTransport,
+		store:   store,
+		userKey: "user1",
+	}
+	client := &http.Client{Transport: transport}
+
+	get := func(path string) string {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, `[{"name": "bug"}]`, get("/repos/owner/repo/labels"))
+	assert.Equal(t, 1, requests)
+
+	// a fresh response is served from the cache.
+	assert.Equal(t, `[{"name": "bug"}]`, get("/repos/owner/repo/labels"))
+	assert.Equal(t, 1, requests)
+
+	// a stale response is revalidated.
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/repos/owner/repo/labels", nil)
+	require.NoError(t, err)
+	key := getGitHubCacheKey("user1", 0, req)
+	var cached *cachedGitHubResponse
+	require.NoError(t, store.Get(key, &cached))
+	cached.FetchedAt = 0
+	_, err = store.Set(key, cached)
+	require.NoError(t, err)
+
+	assert.Equal(t, `[{"name": "bug"}]`, get("/repos/owner/repo/labels"))
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, `"v1"`, ifNoneMatch)
+
+	// an invalidated response is fetched again.
+	p := NewPlugin()
+	p.store = store
+	p.invalidateGitHubCacheForEvent(&github.LabelEvent{Repo: &github.Repository{FullName: github.String("Owner/Repo")}})
+
+	assert.Equal(t, `[{"name": "bug"}]`, get("/repos/owner/repo/labels"))
+	assert.Equal(t, 3, requests)
+	assert.Empty(t, ifNoneMatch)
+
+	// the endpoints that aren't cached are always fetched.
+	get("/repos/owner/repo/issues/1")
+	get("/repos/owner/repo/issues/1")
+	assert.Equal(t, 5, requests)
+}
@@ -38,6 +38,8 @@ type (
 			Milestone struct {
 				Title githubv4.String
 			}
+			IsDraft          githubv4.Boolean
+			MergeStateStatus githubv4.String
 		} `graphql:"... on PullRequest"`
 	}
 )
@@ -80,11 +82,14 @@ type (
 			Milestone struct {
 				Title githubv4.String
 			}
+			IsDraft          githubv4.Boolean
+			MergeStateStatus githubv4.String
 		} `graphql:"... on PullRequest"`
 	}
 )
 
-var mainQuery struct {
+// lhsQuery runs the searches of the sidebar at once. Each search is only included while it has pages to fetch.
+type lhsQuery struct {
 	ReviewRequests struct {
 		IssueCount int
 		Nodes      []prSearchNodes
@@ -92,7 +97,7 @@ var mainQuery struct {
 			EndCursor   githubv4.String
 			HasNextPage bool
 		}
-	} `graphql:"pullRequest: search(first:100, after:$reviewsCursor, query: $prReviewQueryArg, type: ISSUE)"`
+	} `graphql:"pullRequest: search(first:100, after:$reviewsCursor, query: $prReviewQueryArg, type: ISSUE) @include(if: $includeReviews)"`
 
 	Assignments struct {
 		IssueCount int
@@ -101,7 +106,7 @@ var mainQuery struct {
 			EndCursor   githubv4.String
 			HasNextPage bool
 		}
-	} `graphql:"assignee: search(first:100, after:$assignmentsCursor, query: $assigneeQueryArg, type: ISSUE)"`
+	} `graphql:"assignee: search(first:100, after:$assignmentsCursor, query: $assigneeQueryArg, type: ISSUE) @include(if: $includeAssignments)"`
 
 	OpenPullRequests struct {
 		IssueCount int
@@ -110,5 +115,5 @@ var mainQuery struct {
 			EndCursor   githubv4.String
 			HasNextPage bool
 		}
-	} `graphql:"graphql: search(first:100, after:$openPrsCursor, query: $prOpenQueryArg, type: ISSUE)"`
+	} `graphql:"graphql: search(first:100, after:$openPrsCursor, query: $prOpenQueryArg, type: ISSUE) @include(if: $includeOpenPrs)"`
 }
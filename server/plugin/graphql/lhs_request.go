@@ -17,95 +17,133 @@ const (
 	queryParamOpenPRQueryArg   = "prOpenQueryArg"
 	queryParamReviewPRQueryArg = "prReviewQueryArg"
 	queryParamAssigneeQueryArg = "assigneeQueryArg"
+
+	queryParamIncludeReviews     = "includeReviews"
+	queryParamIncludeAssignments = "includeAssignments"
+	queryParamIncludeOpenPRs     = "includeOpenPrs"
+
+	// lhsMaxResults is the number of items fetched at most for each list of the sidebar. The totals are still
+	// the number of all the matching items.
+	lhsMaxResults = 500
+
+	mergeStateStatusBlocked = "BLOCKED"
 )
 
-func (c *Client) GetLHSData(ctx context.Context) ([]*github.Issue, []*github.Issue, []*github.Issue, error) {
+// LHSItem is an item of the sidebar, with the state of the pull request.
+type LHSItem struct {
+	*github.Issue
+	Draft   bool `json:"draft"`
+	Blocked bool `json:"blocked"`
+}
+
+// LHSData is the content of the sidebar.
+type LHSData struct {
+	Reviews          []*LHSItem
+	Assignments      []*LHSItem
+	OpenPRs          []*LHSItem
+	ReviewsTotal     int
+	AssignmentsTotal int
+	OpenPRsTotal     int
+}
+
+func (c *Client) GetLHSData(ctx context.Context) (*LHSData, error) {
 	orgsList := c.getOrganizations()
-	var resultReview, resultAssignee, resultOpenPR []*github.Issue
+	if len(orgsList) == 0 {
+		orgsList = []string{""}
+	}
+
+	data := &LHSData{}
 	for _, org := range orgsList {
-		params := map[string]interface{}{
-			queryParamOpenPRQueryArg:    githubv4.String(fmt.Sprintf("author:%s is:pr is:%s archived:false", c.username, githubv4.PullRequestStateOpen)),
-			queryParamReviewPRQueryArg:  githubv4.String(fmt.Sprintf("review-requested:%s is:pr is:%s archived:false", c.username, githubv4.PullRequestStateOpen)),
-			queryParamAssigneeQueryArg:  githubv4.String(fmt.Sprintf("assignee:%s is:%s archived:false", c.username, githubv4.PullRequestStateOpen)),
-			queryParamReviewsCursor:     (*githubv4.String)(nil),
-			queryParamAssignmentsCursor: (*githubv4.String)(nil),
-			queryParamOpenPRsCursor:     (*githubv4.String)(nil),
+		orgFilter := ""
+		if org != "" {
+			orgFilter = fmt.Sprintf("org:%s ", org)
 		}
 
-		params[queryParamOpenPRQueryArg] = githubv4.String(fmt.Sprintf("org:%s %s", org, params[queryParamOpenPRQueryArg]))
-		params[queryParamReviewPRQueryArg] = githubv4.String(fmt.Sprintf("org:%s %s", org, params[queryParamReviewPRQueryArg]))
-		params[queryParamAssigneeQueryArg] = githubv4.String(fmt.Sprintf("org:%s %s", org, params[queryParamAssigneeQueryArg]))
-
-		allReviewRequestsFetched, allAssignmentsFetched, allOpenPRsFetched := false, false, false
+		params := map[string]interface{}{
+			queryParamOpenPRQueryArg:     githubv4.String(fmt.Sprintf("%sauthor:%s is:pr is:%s archived:false", orgFilter, c.username, githubv4.PullRequestStateOpen)),
+			queryParamReviewPRQueryArg:   githubv4.String(fmt.Sprintf("%sreview-requested:%s is:pr is:%s archived:false", orgFilter, c.username, githubv4.PullRequestStateOpen)),
+			queryParamAssigneeQueryArg:   githubv4.String(fmt.Sprintf("%sassignee:%s is:%s archived:false", orgFilter, c.username, githubv4.PullRequestStateOpen)),
+			queryParamReviewsCursor:      (*githubv4.String)(nil),
+			queryParamAssignmentsCursor:  (*githubv4.String)(nil),
+			queryParamOpenPRsCursor:      (*githubv4.String)(nil),
+			queryParamIncludeReviews:     githubv4.Boolean(true),
+			queryParamIncludeAssignments: githubv4.Boolean(true),
+			queryParamIncludeOpenPRs:     githubv4.Boolean(true),
+		}
 
-		for {
-			if allReviewRequestsFetched && allAssignmentsFetched && allOpenPRsFetched {
-				break
+		firstPage := true
+		for params[queryParamIncludeReviews] == githubv4.Boolean(true) ||
+			params[queryParamIncludeAssignments] == githubv4.Boolean(true) ||
+			params[queryParamIncludeOpenPRs] == githubv4.Boolean(true) {
+			var query lhsQuery
+			if err := c.executeQuery(ctx, &query, params); err != nil {
+				return nil, errors.Wrap(err, "Not able to excute the query")
 			}
 
-			if err := c.executeQuery(ctx, &mainQuery, params); err != nil {
-				return nil, nil, nil, errors.Wrap(err, "Not able to excute the query")
+			// the totals are read from the first page only, as the following ones don't include every search.
+			if firstPage {
+				data.ReviewsTotal += query.ReviewRequests.IssueCount
+				data.AssignmentsTotal += query.Assignments.IssueCount
+				data.OpenPRsTotal += query.OpenPullRequests.IssueCount
 			}
+			firstPage = false
 
-			if !allReviewRequestsFetched {
-				for i := range mainQuery.ReviewRequests.Nodes {
-					resp := mainQuery.ReviewRequests.Nodes[i]
-					pr := getPR(&resp)
-					resultReview = append(resultReview, pr)
+			if params[queryParamIncludeReviews] == githubv4.Boolean(true) {
+				for i := range query.ReviewRequests.Nodes {
+					data.Reviews = append(data.Reviews, getPR(&query.ReviewRequests.Nodes[i]))
 				}
 
-				if !mainQuery.ReviewRequests.PageInfo.HasNextPage {
-					allReviewRequestsFetched = true
-				}
-
-				params[queryParamReviewsCursor] = githubv4.NewString(mainQuery.ReviewRequests.PageInfo.EndCursor)
+				hasNextPage := query.ReviewRequests.PageInfo.HasNextPage && len(data.Reviews) < lhsMaxResults
+				params[queryParamIncludeReviews] = githubv4.Boolean(hasNextPage)
+				params[queryParamReviewsCursor] = githubv4.NewString(query.ReviewRequests.PageInfo.EndCursor)
 			}
 
-			if !allAssignmentsFetched {
-				for i := range mainQuery.Assignments.Nodes {
-					resp := mainQuery.Assignments.Nodes[i]
-					issue := newIssueFromAssignmentResponse(&resp)
-					resultAssignee = append(resultAssignee, issue)
-				}
-
-				if !mainQuery.Assignments.PageInfo.HasNextPage {
-					allAssignmentsFetched = true
+			if params[queryParamIncludeAssignments] == githubv4.Boolean(true) {
+				for i := range query.Assignments.Nodes {
+					data.Assignments = append(data.Assignments, newIssueFromAssignmentResponse(&query.Assignments.Nodes[i]))
 				}
 
-				params[queryParamAssignmentsCursor] = githubv4.NewString(mainQuery.Assignments.PageInfo.EndCursor)
+				hasNextPage := query.Assignments.PageInfo.HasNextPage && len(data.Assignments) < lhsMaxResults
+				params[queryParamIncludeAssignments] = githubv4.Boolean(hasNextPage)
+				params[queryParamAssignmentsCursor] = githubv4.NewString(query.Assignments.PageInfo.EndCursor)
 			}
 
-			if !allOpenPRsFetched {
-				for i := range mainQuery.OpenPullRequests.Nodes {
-					resp := mainQuery.OpenPullRequests.Nodes[i]
-					pr := getPR(&resp)
-					resultOpenPR = append(resultOpenPR, pr)
-				}
-
-				if !mainQuery.OpenPullRequests.PageInfo.HasNextPage {
-					allOpenPRsFetched = true
+			if params[queryParamIncludeOpenPRs] == githubv4.Boolean(true) {
+				for i := range query.OpenPullRequests.Nodes {
+					data.OpenPRs = append(data.OpenPRs, getPR(&query.OpenPullRequests.Nodes[i]))
 				}
 
-				params[queryParamOpenPRsCursor] = githubv4.NewString(mainQuery.OpenPullRequests.PageInfo.EndCursor)
+				hasNextPage := query.OpenPullRequests.PageInfo.HasNextPage && len(data.OpenPRs) < lhsMaxResults
+				params[queryParamIncludeOpenPRs] = githubv4.Boolean(hasNextPage)
+				params[queryParamOpenPRsCursor] = githubv4.NewString(query.OpenPullRequests.PageInfo.EndCursor)
 			}
 		}
 	}
 
-	return resultReview, resultAssignee, resultOpenPR, nil
+	return data, nil
 }
 
-func getPR(prResp *prSearchNodes) *github.Issue {
+func getPR(prResp *prSearchNodes) *LHSItem {
 	resp := prResp.PullRequest
 	labels := getGithubLabels(resp.Labels.Nodes)
 
-	return newGithubIssue(resp.Number, resp.Title, resp.Author.Login, resp.Repository.URL, resp.URL, resp.CreatedAt, resp.UpdatedAt, labels, resp.Milestone.Title)
+	return &LHSItem{
+		Issue:   newGithubIssue(resp.Number, resp.Title, resp.Author.Login, resp.Repository.URL, resp.URL, resp.CreatedAt, resp.UpdatedAt, labels, resp.Milestone.Title),
+		Draft:   bool(resp.IsDraft),
+		Blocked: resp.MergeStateStatus == mergeStateStatusBlocked,
+	}
 }
 
-func newIssueFromAssignmentResponse(assignmentResp *assignmentSearchNodes) *github.Issue {
+func newIssueFromAssignmentResponse(assignmentResp *assignmentSearchNodes) *LHSItem {
+	// both fragments are decoded from the same node, the pull request fields are empty for issues.
 	resp := assignmentResp.PullRequest
 	labels := getGithubLabels(resp.Labels.Nodes)
 
-	return newGithubIssue(resp.Number, resp.Title, resp.Author.Login, resp.Repository.URL, resp.URL, resp.CreatedAt, resp.UpdatedAt, labels, resp.Milestone.Title)
+	return &LHSItem{
+		Issue:   newGithubIssue(resp.Number, resp.Title, resp.Author.Login, resp.Repository.URL, resp.URL, resp.CreatedAt, resp.UpdatedAt, labels, resp.Milestone.Title),
+		Draft:   bool(resp.IsDraft),
+		Blocked: resp.MergeStateStatus == mergeStateStatusBlocked,
+	}
 }
 
 func getGithubLabels(labels []labelNode) []*github.Label {
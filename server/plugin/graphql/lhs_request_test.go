@@ -0,0 +1,76 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestGetLHSData(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests++
+
+		assert.Equal(t, "review-requested:user is:pr is:OPEN archived:false", body.Variables[queryParamReviewPRQueryArg])
+
+		// the review requests always have a next page, until the cap is reached.
+		reviews := make([]string, 100)
+		for i := range reviews {
+			reviews[i] = fmt.Sprintf(`{"number": %d, "title": "PR", "url": "https://github.com/o/r/pull/%d", "repository": {"url": "https://github.com/o/r"}}`, i, i)
+		}
+		response := fmt.Sprintf(`"pullRequest": {"issueCount": 1000, "nodes": [%s], "pageInfo": {"endCursor": "c%d", "hasNextPage": true}}`, strings.Join(reviews, ","), requests)
+
+		if requests == 1 {
+			assert.Equal(t, true, body.Variables[queryParamIncludeAssignments])
+			response += `,
+				"assignee": {"issueCount": 2, "nodes": [
+					{"number": 1, "title": "Issue", "url": "https://github.com/o/r/issues/1", "repository": {"url": "https://github.com/o/r"}},
+					{"number": 2, "title": "Draft", "url": "https://github.com/o/r/pull/2", "isDraft": true, "repository": {"url": "https://github.com/o/r"}}
+				], "pageInfo": {"endCursor": "a1", "hasNextPage": false}},
+				"graphql": {"issueCount": 1, "nodes": [
+					{"number": 3, "title": "Blocked", "url": "https://github.com/o/r/pull/3", "mergeStateStatus": "BLOCKED", "repository": {"url": "https://github.com/o/r"}}
+				], "pageInfo": {"endCursor": "p1", "hasNextPage": false}}`
+		} else {
+			assert.Equal(t, false, body.Variables[queryParamIncludeAssignments])
+			assert.Equal(t, false, body.Variables[queryParamIncludeOpenPRs])
+			assert.Equal(t, fmt.Sprintf("c%d", requests-1), body.Variables[queryParamReviewsCursor])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {` + response + `}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(pluginapi.LogService{}, func() []string { return []string{} }, oauth2.Token{AccessToken: "token"}, "user", "", server.URL)
+
+	data, err := client.GetLHSData(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, lhsMaxResults/100, requests)
+	assert.Len(t, data.Reviews, lhsMaxResults)
+	assert.Equal(t, 1000, data.ReviewsTotal)
+
+	require.Len(t, data.Assignments, 2)
+	assert.Equal(t, 2, data.AssignmentsTotal)
+	assert.False(t, data.Assignments[0].Draft)
+	assert.True(t, data.Assignments[1].Draft)
+
+	require.Len(t, data.OpenPRs, 1)
+	assert.Equal(t, 1, data.OpenPRsTotal)
+	assert.True(t, data.OpenPRs[0].Blocked)
+	assert.Equal(t, "Blocked", data.OpenPRs[0].GetTitle())
+}
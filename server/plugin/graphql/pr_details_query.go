@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"github.com/shurcooL/githubv4"
+)
+
+type (
+	prDetailsReview struct {
+		State  githubv4.String
+		Author struct {
+			Login githubv4.String
+		}
+	}
+
+	prDetailsNode struct {
+		Mergeable      githubv4.String
+		ReviewDecision githubv4.String
+		IsDraft        githubv4.Boolean
+		Merged         githubv4.Boolean
+		Additions      githubv4.Int
+		Deletions      githubv4.Int
+		ChangedFiles   githubv4.Int
+		BaseRefName    githubv4.String
+		HeadRefName    githubv4.String
+		Author         struct {
+			AvatarURL githubv4.URI
+			URL       githubv4.URI
+		}
+		Commits struct {
+			Nodes []struct {
+				Commit struct {
+					StatusCheckRollup struct {
+						State githubv4.String
+					}
+				}
+			}
+		} `graphql:"commits(last:1)"`
+		ReviewRequests struct {
+			Nodes []struct {
+				RequestedReviewer struct {
+					User struct {
+						Login githubv4.String
+					} `graphql:"... on User"`
+					Team struct {
+						Name githubv4.String
+					} `graphql:"... on Team"`
+				}
+			}
+		} `graphql:"reviewRequests(first:100)"`
+		Reviews struct {
+			Nodes []prDetailsReview
+		} `graphql:"reviews(last:100)"`
+	}
+
+	prDetailsResource struct {
+		PullRequest prDetailsNode `graphql:"... on PullRequest"`
+	}
+)
+
+// prDetailsQuery fetches the details of several pull requests at once. Each pull request is only included
+// if the query has one for its position.
+type prDetailsQuery struct {
+	PR0 *prDetailsResource `graphql:"pr0: resource(url:$url0) @include(if:$include0)"`
+	PR1 *prDetailsResource `graphql:"pr1: resource(url:$url1) @include(if:$include1)"`
+	PR2 *prDetailsResource `graphql:"pr2: resource(url:$url2) @include(if:$include2)"`
+	PR3 *prDetailsResource `graphql:"pr3: resource(url:$url3) @include(if:$include3)"`
+	PR4 *prDetailsResource `graphql:"pr4: resource(url:$url4) @include(if:$include4)"`
+	PR5 *prDetailsResource `graphql:"pr5: resource(url:$url5) @include(if:$include5)"`
+	PR6 *prDetailsResource `graphql:"pr6: resource(url:$url6) @include(if:$include6)"`
+	PR7 *prDetailsResource `graphql:"pr7: resource(url:$url7) @include(if:$include7)"`
+	PR8 *prDetailsResource `graphql:"pr8: resource(url:$url8) @include(if:$include8)"`
+	PR9 *prDetailsResource `graphql:"pr9: resource(url:$url9) @include(if:$include9)"`
+}
+
+// maxPRDetailsPerQuery is the number of pull requests a single PR details query fetches.
+const maxPRDetailsPerQuery = 10
+
+func (q *prDetailsQuery) pullRequests() []*prDetailsResource {
+	return []*prDetailsResource{q.PR0, q.PR1, q.PR2, q.PR3, q.PR4, q.PR5, q.PR6, q.PR7, q.PR8, q.PR9}
+}
@@ -0,0 +1,162 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	mergeableStateMergeable = "MERGEABLE"
+	statusStateExpected     = "EXPECTED"
+	statusStatePending      = "PENDING"
+)
+
+// PRDetails is the state of a pull request shown in the sidebar and in the link previews.
+type PRDetails struct {
+	// Status is the combined state of the checks and commit statuses of the last commit, in lower case.
+	// It's empty if the commit has neither.
+	Status    string
+	Mergeable bool
+	// ReviewDecision is APPROVED, CHANGES_REQUESTED or REVIEW_REQUIRED, if the repository requires reviews.
+	ReviewDecision     string
+	RequestedReviewers []*string
+	RequestedTeams     []string
+	Reviews            []*github.PullRequestReview
+
+	Draft           bool
+	Merged          bool
+	Additions       int
+	Deletions       int
+	ChangedFiles    int
+	BaseRef         string
+	HeadRef         string
+	AuthorAvatarURL string
+	AuthorURL       string
+}
+
+// GetPRDetails fetches the details of the pull requests with the given web URLs, running a GraphQL query
+// for every ten pull requests. The details are in the order of the URLs, and are nil for the pull requests
+// the user can't access. If a query fails, the details of its pull requests are nil too, and the first
+// error is returned along with the details of the other pull requests.
+func (c *Client) GetPRDetails(ctx context.Context, prURLs []string) ([]*PRDetails, error) {
+	var firstErr error
+	details := make([]*PRDetails, 0, len(prURLs))
+	for start := 0; start < len(prURLs); start += maxPRDetailsPerQuery {
+		end := start + maxPRDetailsPerQuery
+		if end > len(prURLs) {
+			end = len(prURLs)
+		}
+
+		chunk, err := c.getPRDetailsChunk(ctx, prURLs[start:end])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			chunk = make([]*PRDetails, end-start)
+		}
+		details = append(details, chunk...)
+	}
+
+	return details, firstErr
+}
+
+func (c *Client) getPRDetailsChunk(ctx context.Context, prURLs []string) ([]*PRDetails, error) {
+	params := map[string]interface{}{}
+	for i := 0; i < maxPRDetailsPerQuery; i++ {
+		// the variables of the pull requests that aren't included still need a valid URL.
+		prURL := prURLs[0]
+		if i < len(prURLs) {
+			prURL = prURLs[i]
+		}
+
+		parsed, err := url.Parse(prURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pull request URL %s", prURL)
+		}
+
+		params[fmt.Sprintf("url%d", i)] = githubv4.URI{URL: parsed}
+		params[fmt.Sprintf("include%d", i)] = githubv4.Boolean(i < len(prURLs))
+	}
+
+	var query prDetailsQuery
+	if err := c.executeQuery(ctx, &query, params); err != nil {
+		return nil, errors.Wrap(err, "failed to run the PR details query")
+	}
+
+	details := make([]*PRDetails, len(prURLs))
+	for i, resource := range query.pullRequests()[:len(prURLs)] {
+		if resource == nil {
+			continue
+		}
+		details[i] = newPRDetails(resource.PullRequest)
+	}
+
+	return details, nil
+}
+
+func newPRDetails(node prDetailsNode) *PRDetails {
+	status := ""
+	if len(node.Commits.Nodes) > 0 {
+		status = string(node.Commits.Nodes[0].Commit.StatusCheckRollup.State)
+		// the checks required by a branch protection rule are expected before they are reported.
+		if status == statusStateExpected {
+			status = statusStatePending
+		}
+	}
+
+	// Initialize to non-nil slices to simplify JSON handling semantics
+	requestedReviewers := []*string{}
+	var requestedTeams []string
+	for _, request := range node.ReviewRequests.Nodes {
+		login := string(request.RequestedReviewer.User.Login)
+		if login == "" {
+			// teams are requested as a whole, they aren't a single reviewer.
+			if name := string(request.RequestedReviewer.Team.Name); name != "" {
+				requestedTeams = append(requestedTeams, name)
+			}
+			continue
+		}
+		requestedReviewers = append(requestedReviewers, &login)
+	}
+
+	reviews := []*github.PullRequestReview{}
+	for _, review := range node.Reviews.Nodes {
+		reviews = append(reviews, &github.PullRequestReview{
+			State: github.String(string(review.State)),
+			User:  &github.User{Login: github.String(string(review.Author.Login))},
+		})
+	}
+
+	return &PRDetails{
+		Status:             strings.ToLower(status),
+		Mergeable:          node.Mergeable == mergeableStateMergeable,
+		ReviewDecision:     string(node.ReviewDecision),
+		RequestedReviewers: requestedReviewers,
+		RequestedTeams:     requestedTeams,
+		Reviews:            reviews,
+		Draft:              bool(node.IsDraft),
+		Merged:             bool(node.Merged),
+		Additions:          int(node.Additions),
+		Deletions:          int(node.Deletions),
+		ChangedFiles:       int(node.ChangedFiles),
+		BaseRef:            string(node.BaseRefName),
+		HeadRef:            string(node.HeadRefName),
+		AuthorAvatarURL:    uriString(node.Author.AvatarURL),
+		AuthorURL:          uriString(node.Author.URL),
+	}
+}
+
+// uriString returns the URI as a string, or an empty string if it wasn't set, e.g. for a deleted author.
+func uriString(uri githubv4.URI) string {
+	if uri.URL == nil {
+		return ""
+	}
+
+	return uri.String()
+}
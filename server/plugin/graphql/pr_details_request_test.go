@@ -0,0 +1,152 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestGetPRDetails(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests++
+
+		prs := []string{}
+		for i := 0; i < maxPRDetailsPerQuery; i++ {
+			if body.Variables[fmt.Sprintf("include%d", i)] != true {
+				continue
+			}
+
+			prURL := body.Variables[fmt.Sprintf("url%d", i)].(string)
+			switch prURL {
+			case "https://github.com/o/r/pull/1":
+				prs = append(prs, fmt.Sprintf(`"pr%d": {
+					"mergeable": "CONFLICTING",
+					"reviewDecision": "CHANGES_REQUESTED",
+					"isDraft": true,
+					"additions": 10,
+					"baseRefName": "master",
+					"author": {"avatarUrl": "https://avatars.githubusercontent.com/u/1", "url": "https://github.com/author"},
+					"commits": {"nodes": [{"commit": {"statusCheckRollup": {"state": "EXPECTED"}}}]},
+					"reviewRequests": {"nodes": [{"requestedReviewer": {"login": "reviewer"}}, {"requestedReviewer": {"name": "core"}}, {"requestedReviewer": {}}]},
+					"reviews": {"nodes": [{"state": "CHANGES_REQUESTED", "author": {"login": "author"}}]}
+				}`, i))
+			case "https://github.com/o/r/pull/2":
+				prs = append(prs, fmt.Sprintf(`"pr%d": null`, i))
+			case "https://github.com/o/r/pull/3":
+				prs = append(prs, fmt.Sprintf(`"pr%d": {
+					"mergeable": "UNKNOWN",
+					"commits": {"nodes": []},
+					"reviewRequests": {"nodes": []},
+					"reviews": {"nodes": []}
+				}`, i))
+			default:
+				prs = append(prs, fmt.Sprintf(`"pr%d": {
+					"mergeable": "MERGEABLE",
+					"commits": {"nodes": [{"commit": {"statusCheckRollup": null}}]},
+					"reviewRequests": {"nodes": []},
+					"reviews": {"nodes": []}
+				}`, i))
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {` + strings.Join(prs, ",") + `}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(pluginapi.LogService{}, func() []string { return []string{} }, oauth2.Token{AccessToken: "token"}, "user", "", server.URL)
+
+	prURLs := []string{}
+	for i := 1; i <= maxPRDetailsPerQuery+2; i++ {
+		prURLs = append(prURLs, fmt.Sprintf("https://github.com/o/r/pull/%d", i))
+	}
+
+	details, err := client.GetPRDetails(context.Background(), prURLs)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, requests)
+	require.Len(t, details, len(prURLs))
+
+	require.NotNil(t, details[0])
+	assert.Equal(t, "pending", details[0].Status)
+	assert.False(t, details[0].Mergeable)
+	assert.Equal(t, "CHANGES_REQUESTED", details[0].ReviewDecision)
+	require.Len(t, details[0].RequestedReviewers, 1)
+	assert.Equal(t, "reviewer", *details[0].RequestedReviewers[0])
+	assert.Equal(t, []string{"core"}, details[0].RequestedTeams)
+	assert.True(t, details[0].Draft)
+	assert.Equal(t, 10, details[0].Additions)
+	assert.Equal(t, "master", details[0].BaseRef)
+	assert.Equal(t, "https://github.com/author", details[0].AuthorURL)
+	require.Len(t, details[0].Reviews, 1)
+	assert.Equal(t, "CHANGES_REQUESTED", details[0].Reviews[0].GetState())
+	assert.Equal(t, "author", details[0].Reviews[0].GetUser().GetLogin())
+
+	assert.Nil(t, details[1])
+
+	// GitHub didn't compute yet if the pull request can be merged.
+	require.NotNil(t, details[2])
+	assert.False(t, details[2].Mergeable)
+
+	for _, detail := range details[3:] {
+		require.NotNil(t, detail)
+		assert.Empty(t, detail.Status)
+		assert.True(t, detail.Mergeable)
+		assert.Empty(t, detail.RequestedReviewers)
+	}
+}
+
+func TestGetPRDetailsFailedQuery(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		prs := []string{}
+		for i := 0; i < 2; i++ {
+			prs = append(prs, fmt.Sprintf(`"pr%d": {"mergeable": "MERGEABLE", "commits": {"nodes": []}, "reviewRequests": {"nodes": []}, "reviews": {"nodes": []}}`, i))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {` + strings.Join(prs, ",") + `}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(pluginapi.LogService{}, func() []string { return []string{} }, oauth2.Token{AccessToken: "token"}, "user", "", server.URL)
+
+	prURLs := []string{}
+	for i := 1; i <= maxPRDetailsPerQuery+2; i++ {
+		prURLs = append(prURLs, fmt.Sprintf("https://github.com/o/r/pull/%d", i))
+	}
+
+	// the pull requests of the failed query are left out, the others are still returned.
+	details, err := client.GetPRDetails(context.Background(), prURLs)
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+	require.Len(t, details, len(prURLs))
+	for _, detail := range details[:maxPRDetailsPerQuery] {
+		assert.Nil(t, detail)
+	}
+	for _, detail := range details[maxPRDetailsPerQuery:] {
+		require.NotNil(t, detail)
+		assert.True(t, detail.Mergeable)
+	}
+}
@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"github.com/shurcooL/githubv4"
+)
+
+type (
+	todoSearchNodes struct {
+		Issue struct {
+			Title githubv4.String
+			URL   githubv4.URI
+		} `graphql:"... on Issue"`
+
+		PullRequest struct {
+			Title githubv4.String
+			URL   githubv4.URI
+		} `graphql:"... on PullRequest"`
+	}
+
+	todoSearch struct {
+		IssueCount int
+		Nodes      []todoSearchNodes
+		PageInfo   struct {
+			EndCursor   githubv4.String
+			HasNextPage bool
+		}
+	}
+)
+
+// todoQuery runs the searches of the todo list at once. Each search is only included while it has pages to fetch.
+type todoQuery struct {
+	Search0 todoSearch `graphql:"search0: search(first:100, after:$cursor0, query:$query0, type:ISSUE) @include(if:$include0)"`
+	Search1 todoSearch `graphql:"search1: search(first:100, after:$cursor1, query:$query1, type:ISSUE) @include(if:$include1)"`
+	Search2 todoSearch `graphql:"search2: search(first:100, after:$cursor2, query:$query2, type:ISSUE) @include(if:$include2)"`
+	Search3 todoSearch `graphql:"search3: search(first:100, after:$cursor3, query:$query3, type:ISSUE) @include(if:$include3)"`
+	Search4 todoSearch `graphql:"search4: search(first:100, after:$cursor4, query:$query4, type:ISSUE) @include(if:$include4)"`
+	Search5 todoSearch `graphql:"search5: search(first:100, after:$cursor5, query:$query5, type:ISSUE) @include(if:$include5)"`
+	Search6 todoSearch `graphql:"search6: search(first:100, after:$cursor6, query:$query6, type:ISSUE) @include(if:$include6)"`
+	Search7 todoSearch `graphql:"search7: search(first:100, after:$cursor7, query:$query7, type:ISSUE) @include(if:$include7)"`
+}
+
+// MaxToDoSearches is the number of searches a single todo query runs.
+const MaxToDoSearches = 8
+
+func (q *todoQuery) searches() []*todoSearch {
+	return []*todoSearch{&q.Search0, &q.Search1, &q.Search2, &q.Search3, &q.Search4, &q.Search5, &q.Search6, &q.Search7}
+}
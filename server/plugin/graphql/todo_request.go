@@ -0,0 +1,91 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/githubv4"
+)
+
+// todoMaxResults is the number of items fetched at most for each search of the todo list. The totals are still
+// the number of all the matching items.
+const todoMaxResults = 500
+
+// ToDoSearchResult is the result of a search of the todo list.
+type ToDoSearchResult struct {
+	Total  int
+	Issues []*github.Issue
+}
+
+// GetToDoData runs the search queries of the todo list in a single GraphQL query, fetching the pages of the results
+// until todoMaxResults items are found. The results are in the order of the queries.
+func (c *Client) GetToDoData(ctx context.Context, queries []string) ([]*ToDoSearchResult, error) {
+	if len(queries) > MaxToDoSearches {
+		return nil, errors.Errorf("a todo query runs at most %d searches", MaxToDoSearches)
+	}
+
+	params := map[string]interface{}{}
+	results := make([]*ToDoSearchResult, len(queries))
+	for i := 0; i < MaxToDoSearches; i++ {
+		query := ""
+		if i < len(queries) {
+			query = queries[i]
+			results[i] = &ToDoSearchResult{}
+		}
+
+		params[fmt.Sprintf("query%d", i)] = githubv4.String(query)
+		params[fmt.Sprintf("cursor%d", i)] = (*githubv4.String)(nil)
+		params[fmt.Sprintf("include%d", i)] = githubv4.Boolean(i < len(queries))
+	}
+
+	for remaining := len(queries); remaining > 0; {
+		var query todoQuery
+		if err := c.executeQuery(ctx, &query, params); err != nil {
+			return nil, errors.Wrap(err, "failed to run the todo query")
+		}
+
+		for i, search := range query.searches()[:len(queries)] {
+			if !params[fmt.Sprintf("include%d", i)].(githubv4.Boolean) {
+				continue
+			}
+
+			results[i].Total = search.IssueCount
+			for _, node := range search.Nodes {
+				if len(results[i].Issues) == todoMaxResults {
+					break
+				}
+				results[i].Issues = append(results[i].Issues, newIssueFromToDoSearchNode(node))
+			}
+
+			if !search.PageInfo.HasNextPage || len(results[i].Issues) >= todoMaxResults {
+				params[fmt.Sprintf("include%d", i)] = githubv4.Boolean(false)
+				remaining--
+				continue
+			}
+
+			params[fmt.Sprintf("cursor%d", i)] = githubv4.NewString(search.PageInfo.EndCursor)
+		}
+	}
+
+	return results, nil
+}
+
+func newIssueFromToDoSearchNode(node todoSearchNodes) *github.Issue {
+	title, uri := node.Issue.Title, node.Issue.URL
+	if uri.URL == nil {
+		title, uri = node.PullRequest.Title, node.PullRequest.URL
+	}
+
+	url := ""
+	if uri.URL != nil {
+		url = uri.String()
+	}
+	issueTitle := string(title)
+
+	return &github.Issue{
+		Title:   &issueTitle,
+		HTMLURL: &url,
+	}
+}
@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// todoPageSize is the number of items in a page of the searches of todoQuery.
+const todoPageSize = 100
+
+// todoSearchPage returns a search result of a full page of the todo query, starting at the item first.
+func todoSearchPage(name string, total, first int, endCursor string, hasNextPage bool) string {
+	nodes := make([]string, 0, todoPageSize)
+	for i := first; i < first+todoPageSize && i < total; i++ {
+		nodes = append(nodes, fmt.Sprintf(`{"title": "PR %d", "url": "https://github.com/o/r/pull/%d"}`, i, i))
+	}
+
+	return fmt.Sprintf(`%q: {"issueCount": %d, "nodes": [%s], "pageInfo": {"endCursor": %q, "hasNextPage": %t}}`, name, total, strings.Join(nodes, ","), endCursor, hasNextPage)
+}
+
+func TestGetToDoData(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/graphql", r.URL.Path)
+
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests++
+
+		var response string
+		switch requests {
+		case 1:
+			assert.Equal(t, true, body.Variables["include0"])
+			assert.Equal(t, true, body.Variables["include1"])
+			assert.Equal(t, false, body.Variables["include2"])
+			assert.Equal(t, "is:pr review-requested:user", body.Variables["query0"])
+			response = `{"data": {` + todoSearchPage("search0", 150, 0, "c1", true) + `, ` + todoSearchPage("search1", 0, 0, "", false) + `}}`
+		case 2:
+			assert.Equal(t, true, body.Variables["include0"])
+			assert.Equal(t, false, body.Variables["include1"])
+			assert.Equal(t, "c1", body.Variables["cursor0"])
+			response = `{"data": {` + todoSearchPage("search0", 150, todoPageSize, "c2", false) + `}}`
+		default:
+			t.Fatal("unexpected request")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := NewClient(pluginapi.LogService{}, func() []string { return nil }, oauth2.Token{AccessToken: "token"}, "user", "", server.URL)
+
+	results, err := client.GetToDoData(context.Background(), []string{"is:pr review-requested:user", "assignee:user"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, 150, results[0].Total)
+	require.Len(t, results[0].Issues, 150)
+	assert.Equal(t, "PR 0", results[0].Issues[0].GetTitle())
+	assert.Equal(t, "https://github.com/o/r/pull/149", results[0].Issues[149].GetHTMLURL())
+
+	assert.Equal(t, 0, results[1].Total)
+	assert.Empty(t, results[1].Issues)
+	assert.Equal(t, 2, requests)
+
+	_, err = client.GetToDoData(context.Background(), make([]string, MaxToDoSearches+1))
+	assert.Error(t, err)
+}
+
+func TestGetToDoDataMaxResults(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data": {%s}}`, todoSearchPage("search0", 1000, (requests-1)*todoPageSize, fmt.Sprintf("c%d", requests), true))
+	}))
+	defer server.Close()
+
+	client := NewClient(pluginapi.LogService{}, func() []string { return nil }, oauth2.Token{AccessToken: "token"}, "user", "", server.URL)
+
+	results, err := client.GetToDoData(context.Background(), []string{"is:pr review-requested:user"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, 1000, results[0].Total)
+	assert.Len(t, results[0].Issues, todoMaxResults)
+	assert.Equal(t, todoMaxResults/todoPageSize, requests)
+}
@@ -0,0 +1,418 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	inboxPollJobKey = "inbox_poll"
+
+	// inboxPollInterval is how often the job checks for the inboxes to poll. GitHub asks to poll each inbox
+	// at most every X-Poll-Interval seconds, which is usually a minute.
+	inboxPollInterval = time.Minute
+
+	// inboxDefaultPollInterval is the wait before polling an inbox again if GitHub doesn't say how long to wait.
+	inboxDefaultPollInterval = time.Minute
+
+	// inboxPollStateKeyPrefix is the prefix of the poll states of the users who turned the inbox on.
+	inboxPollStateKeyPrefix = "_inboxpoll_"
+
+	// inboxUsersKey stores the users who turned the inbox on, so they are listed without going through all
+	// the keys of the plugin.
+	inboxUsersKey = "_inboxusers"
+
+	// inboxMaxPollsPerRun is the number of inboxes polled at most by a run of the job. The other inboxes are
+	// polled at the next runs.
+	inboxMaxPollsPerRun = 200
+
+	// inboxPollWorkers is the number of inboxes polled at the same time by a run of the job.
+	inboxPollWorkers = 8
+
+	// inboxPageSize is the number of notification threads fetched per page.
+	inboxPageSize = 50
+
+	// inboxMaxPages is the number of pages fetched at most by a poll. Older threads updated since the previous
+	// poll are only shown in the todo list.
+	inboxMaxPages = 5
+
+	settingInbox        = "inbox"
+	inboxSettingReasons = "reasons"
+	inboxSettingRepos   = "repos"
+	inboxSettingShow    = "show"
+	inboxSettingNone    = "none"
+)
+
+// inboxReasons are the reasons GitHub notifies a user about a thread.
+var inboxReasons = []string{
+	"approval_requested",
+	"assign",
+	"author",
+	"ci_activity",
+	"comment",
+	"invitation",
+	"manual",
+	"member_feature_requested",
+	"mention",
+	"review_requested",
+	"security_advisory_credit",
+	"security_alert",
+	"state_change",
+	notificationReasonSubscribed,
+	"team_mention",
+}
+
+// inboxDefaultExcludedReasons are the reasons not forwarded without a reason filter. The threads of the watched
+// repositories aren't forwarded, as for the unread messages, and the mentions, review requests and assignments
+// are already sent by the webhook notifications.
+var inboxDefaultExcludedReasons = []string{"assign", "mention", "review_requested", notificationReasonSubscribed}
+
+// inboxPollState is the progress of the poller of the inbox of a user.
+type inboxPollState struct {
+	// LastModified is sent back to GitHub, which answers 304 Not Modified if the inbox didn't change.
+	LastModified string
+	// LastUpdatedAt is when the newest thread already forwarded was updated, in milliseconds.
+	LastUpdatedAt int64
+	// NextPollAt is when GitHub allows to poll the inbox again, in milliseconds.
+	NextPollAt int64
+}
+
+func getInboxPollStateKey(userID string) string {
+	return inboxPollStateKeyPrefix + userID
+}
+
+// matchesInboxFilters returns true if the notification is forwarded to the user. Without a reason filter,
+// the inboxDefaultExcludedReasons aren't forwarded.
+func matchesInboxFilters(settings *UserSettings, n *github.Notification) bool {
+	if len(settings.InboxReasons) == 0 {
+		if containsValue(inboxDefaultExcludedReasons, n.GetReason()) {
+			return false
+		}
+	} else if !containsValue(settings.InboxReasons, n.GetReason()) {
+		return false
+	}
+
+	if len(settings.InboxRepos) == 0 {
+		return true
+	}
+
+	fullName := strings.ToLower(n.GetRepository().GetFullName())
+	owner, _, _ := strings.Cut(fullName, "/")
+	for _, repo := range settings.InboxRepos {
+		if repo = strings.ToLower(repo); repo == fullName || repo == owner {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getNotificationHTMLURL returns the web URL of the latest comment of a notification thread, or of its
+// subject. Threads without a subject URL, like CI activity, link to their repository.
+func getNotificationHTMLURL(n *github.Notification) string {
+	issueURL := n.GetSubject().GetURL()
+	if issueURL == "" {
+		return n.GetRepository().GetHTMLURL()
+	}
+
+	issueNum := issueURL[strings.LastIndex(issueURL, "/")+1:]
+	subjectURL := issueURL
+	if n.GetSubject().GetLatestCommentURL() != "" {
+		subjectURL = n.GetSubject().GetLatestCommentURL()
+	}
+
+	return fixGithubNotificationSubjectURL(subjectURL, issueNum)
+}
+
+func formatInboxNotification(n *github.Notification) string {
+	return fmt.Sprintf("[%s](%s) [%s](%s) (`%s`)", n.GetRepository().GetFullName(), n.GetRepository().GetHTMLURL(),
+		n.GetSubject().GetTitle(), getNotificationHTMLURL(n), n.GetReason())
+}
+
+// listInboxUserIDs returns the users who turned the inbox on.
+func (p *Plugin) listInboxUserIDs() ([]string, error) {
+	return p.listIndexedUserIDs(inboxUsersKey)
+}
+
+// setInboxUserListed adds the user to the users who turned the inbox on, or removes them.
+func (p *Plugin) setInboxUserListed(userID string, listed bool) error {
+	return p.setUserIndexed(inboxUsersKey, userID, listed)
+}
+
+// pollInboxes forwards the new notifications of the users who turned the inbox on. Only a single plugin
+// instance in the cluster runs the job.
+func (p *Plugin) pollInboxes() {
+	userIDs, err := p.listInboxUserIDs()
+	if err != nil {
+		p.client.Log.Warn("Failed to list the users to poll their inbox", "error", err.Error())
+		return
+	}
+
+	// the inboxes left over by a run aren't always the same ones.
+	rand.Shuffle(len(userIDs), func(i, j int) {
+		userIDs[i], userIDs[j] = userIDs[j], userIDs[i]
+	})
+
+	// a run doesn't last longer than the interval of the job, the inboxes left over are polled by the next run.
+	ctx, cancel := context.WithTimeout(p.lifetimeCtx, inboxPollInterval)
+	defer cancel()
+	ctx = withRateLimitRetries(ctx)
+
+	var polls atomic.Int32
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < inboxPollWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range queue {
+				polled, err := p.pollInbox(ctx, userID, time.Now())
+				if err != nil {
+					p.client.Log.Warn("Failed to poll the inbox", "userID", userID, "error", err.Error())
+				}
+				if polled {
+					polls.Add(1)
+				}
+			}
+		}()
+	}
+
+	for i, userID := range userIDs {
+		if polls.Load() >= inboxMaxPollsPerRun || ctx.Err() != nil {
+			p.client.Log.Debug("Delaying the inbox polls over the limit of a run", "remaining", len(userIDs)-i)
+			break
+		}
+
+		select {
+		case queue <- userID:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// pollInbox forwards the notification threads of the user updated since the previous poll, if GitHub
+// allows to poll the inbox again. polled is true if GitHub was asked for the notifications.
+// Only the inbox of the account on the default GitHub instance is polled.
+func (p *Plugin) pollInbox(ctx context.Context, userID string, now time.Time) (polled bool, err error) {
+	info, apiErr := p.getGitHubUserInfo(userID)
+	if apiErr != nil && apiErr.ID != apiErrorIDNotConnected {
+		return false, apiErr
+	}
+	if info == nil || info.Settings == nil || !info.Settings.InboxNotifications {
+		// the user disconnected or turned the inbox off, their inbox isn't listed anymore.
+		if err = p.store.Delete(getInboxPollStateKey(userID)); err != nil {
+			return false, errors.Wrap(err, "failed to delete the inbox poll state")
+		}
+		return false, p.setInboxUserListed(userID, false)
+	}
+
+	var state *inboxPollState
+	if err = p.store.Get(getInboxPollStateKey(userID), &state); err != nil {
+		return false, errors.Wrap(err, "failed to get the inbox poll state")
+	}
+	if state == nil {
+		// the threads updated before the inbox was turned on aren't forwarded.
+		state = &inboxPollState{LastUpdatedAt: now.UnixMilli()}
+	}
+
+	if now.Before(time.UnixMilli(state.NextPollAt)) {
+		return false, nil
+	}
+
+	// the inbox is polled at the next run of the job.
+	if !p.hasGitHubQuota(ctx, info) {
+		p.client.Log.Debug("Delaying the inbox poll of a rate limited user", "userID", userID)
+		return false, nil
+	}
+
+	githubClient := p.githubConnectUser(ctx, info)
+	notifications, resp, err := p.listInboxNotifications(ctx, githubClient, userID, state)
+	if resp == nil || (err != nil && resp.StatusCode != http.StatusNotModified) {
+		return true, errors.Wrap(err, "failed to list the notifications")
+	}
+
+	pollInterval := inboxDefaultPollInterval
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("X-Poll-Interval")); parseErr == nil && seconds > 0 {
+		pollInterval = time.Duration(seconds) * time.Second
+	}
+	state.NextPollAt = now.Add(pollInterval).UnixMilli()
+
+	if resp.StatusCode != http.StatusNotModified {
+		state.LastModified = resp.Header.Get("Last-Modified")
+
+		// the threads are forwarded from the oldest to the newest.
+		sort.Slice(notifications, func(i, j int) bool {
+			return notifications[i].GetUpdatedAt().Before(notifications[j].GetUpdatedAt().Time)
+		})
+
+		lastUpdatedAt := state.LastUpdatedAt
+		for _, n := range notifications {
+			updatedAt := n.GetUpdatedAt().UnixMilli()
+			if updatedAt <= state.LastUpdatedAt {
+				continue
+			}
+			if updatedAt > lastUpdatedAt {
+				lastUpdatedAt = updatedAt
+			}
+
+			if n.GetRepository() == nil || p.checkOrg(n.GetRepository().GetOwner().GetLogin()) != nil {
+				continue
+			}
+			if !matchesInboxFilters(info.Settings, n) {
+				continue
+			}
+
+			p.CreateBotDMPost(userID, formatInboxNotification(n), "custom_git_inbox")
+		}
+		state.LastUpdatedAt = lastUpdatedAt
+	}
+
+	if _, err := p.store.Set(getInboxPollStateKey(userID), state); err != nil {
+		return true, errors.Wrap(err, "failed to store the inbox poll state")
+	}
+
+	return true, nil
+}
+
+// listInboxNotifications returns the notification threads of the inbox, from the newest to the oldest. The
+// next pages are fetched until they only have threads already forwarded, up to inboxMaxPages. The response
+// of the first page tells if the inbox changed since the previous poll, and when to poll it again.
+func (p *Plugin) listInboxNotifications(ctx context.Context, githubClient *github.Client, userID string, state *inboxPollState) ([]*github.Notification, *github.Response, error) {
+	var notifications []*github.Notification
+	var firstResp *github.Response
+	for page := 1; page <= inboxMaxPages; page++ {
+		req, err := githubClient.NewRequest(http.MethodGet, fmt.Sprintf("notifications?per_page=%d&page=%d", inboxPageSize, page), nil)
+		if err != nil {
+			return nil, firstResp, errors.Wrap(err, "failed to create the notifications request")
+		}
+		if page == 1 && state.LastModified != "" {
+			req.Header.Set("If-Modified-Since", state.LastModified)
+		}
+
+		var pageNotifications []*github.Notification
+		resp, err := githubClient.Do(ctx, req, &pageNotifications)
+		if page == 1 {
+			firstResp = resp
+			if err != nil {
+				return nil, resp, err
+			}
+		} else if err != nil {
+			// the threads of the pages already fetched are forwarded, the older ones are only in the todo list.
+			p.client.Log.Debug("Failed to list the next notifications", "userID", userID, "page", page, "error", err.Error())
+			break
+		}
+
+		notifications = append(notifications, pageNotifications...)
+		if resp.NextPage == 0 || len(pageNotifications) == 0 ||
+			pageNotifications[len(pageNotifications)-1].GetUpdatedAt().UnixMilli() <= state.LastUpdatedAt {
+			break
+		}
+	}
+
+	return notifications, firstResp, nil
+}
+
+// updateInboxSettings applies the parameters of `/github settings inbox` to the settings of the user.
+// It returns the message to show instead of "Settings updated." and false if the settings aren't changed.
+func (p *Plugin) updateInboxSettings(userID string, settings *UserSettings, parameters []string) (message string, updated bool) {
+	const usage = "Please use `/github settings inbox on|off`, `/github settings inbox reasons|repos <values>` or `/github settings inbox show`."
+
+	switch {
+	case len(parameters) == 1 && parameters[0] == settingOn:
+		// the threads updated before the inbox was turned on aren't forwarded.
+		state := &inboxPollState{LastUpdatedAt: time.Now().UnixMilli()}
+		if _, err := p.store.Set(getInboxPollStateKey(userID), state, pluginapi.SetAtomic(nil)); err != nil {
+			p.client.Log.Warn("Failed to store the inbox poll state", "userID", userID, "error", err.Error())
+			return "Failed to turn the inbox on.", false
+		}
+		if err := p.setInboxUserListed(userID, true); err != nil {
+			p.client.Log.Warn("Failed to list the inbox", "userID", userID, "error", err.Error())
+			return "Failed to turn the inbox on.", false
+		}
+		settings.InboxNotifications = true
+		return "", true
+	case len(parameters) == 1 && parameters[0] == settingOff:
+		settings.InboxNotifications = false
+		// the threads updated while the inbox was off aren't forwarded when it's turned on again.
+		if err := p.store.Delete(getInboxPollStateKey(userID)); err != nil {
+			p.client.Log.Warn("Failed to delete the inbox poll state", "userID", userID, "error", err.Error())
+		}
+		if err := p.setInboxUserListed(userID, false); err != nil {
+			p.client.Log.Warn("Failed to remove the inbox from the list", "userID", userID, "error", err.Error())
+		}
+		return "", true
+	case len(parameters) == 1 && parameters[0] == inboxSettingShow:
+		return getInboxSettingsText(settings), false
+	case len(parameters) != 2:
+		return usage, false
+	}
+
+	var values []string
+	if parameters[1] != inboxSettingNone {
+		for _, value := range strings.Split(parameters[1], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	switch parameters[0] {
+	case inboxSettingReasons:
+		for _, reason := range values {
+			if !containsValue(inboxReasons, reason) {
+				return fmt.Sprintf("Unknown reason `%s`. Available reasons are: %s.", reason, strings.Join(inboxReasons, ", ")), false
+			}
+		}
+		settings.InboxReasons = values
+	case inboxSettingRepos:
+		for _, repo := range values {
+			owner, name, hasName := strings.Cut(repo, "/")
+			if owner == "" || (hasName && name == "") {
+				return fmt.Sprintf("Invalid repository `%s`. Please use the `owner` or `owner/repo` format.", repo), false
+			}
+			if err := p.checkOrg(owner); err != nil {
+				return fmt.Sprintf("Repository `%s` isn't supported: %s.", repo, err.Error()), false
+			}
+		}
+		settings.InboxRepos = values
+	default:
+		return usage, false
+	}
+
+	return "", true
+}
+
+// getInboxSettingsText describes the inbox settings of the user.
+func getInboxSettingsText(settings *UserSettings) string {
+	status := settingOff
+	if settings.InboxNotifications {
+		status = settingOn
+	}
+
+	reasons := "all but " + strings.Join(inboxDefaultExcludedReasons, ",")
+	if len(settings.InboxReasons) > 0 {
+		reasons = strings.Join(settings.InboxReasons, ",")
+	}
+
+	repos := "all"
+	if len(settings.InboxRepos) > 0 {
+		repos = strings.Join(settings.InboxRepos, ",")
+	}
+
+	return fmt.Sprintf("Your inbox settings:\n* Inbox: `%s`\n* Reasons: `%s`\n* Repositories: `%s`", status, reasons, repos)
+}
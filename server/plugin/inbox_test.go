@@ -0,0 +1,258 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestMatchesInboxFilters(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		settings *UserSettings
+		reason   string
+		repo     string
+		expected bool
+	}{
+		{name: "no filters", settings: &UserSettings{}, reason: "ci_activity", repo: "owner/repo", expected: true},
+		{name: "subscribed without a reason filter", settings: &UserSettings{}, reason: notificationReasonSubscribed, repo: "owner/repo"},
+		{name: "mention without a reason filter", settings: &UserSettings{}, reason: "mention", repo: "owner/repo"},
+		{name: "mention with a reason filter", settings: &UserSettings{InboxReasons: []string{"mention"}}, reason: "mention", repo: "owner/repo", expected: true},
+		{name: "matching reason", settings: &UserSettings{InboxReasons: []string{"security_alert", notificationReasonSubscribed}}, reason: notificationReasonSubscribed, repo: "owner/repo", expected: true},
+		{name: "other reason", settings: &UserSettings{InboxReasons: []string{"security_alert"}}, reason: "ci_activity", repo: "owner/repo"},
+		{name: "matching repository", settings: &UserSettings{InboxRepos: []string{"Owner/Repo"}}, reason: "author", repo: "owner/repo", expected: true},
+		{name: "matching organization", settings: &UserSettings{InboxRepos: []string{"owner"}}, reason: "author", repo: "owner/repo", expected: true},
+		{name: "other repository", settings: &UserSettings{InboxRepos: []string{"owner/other"}}, reason: "author", repo: "owner/repo"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := &github.Notification{
+				Reason:     github.String(tc.reason),
+				Repository: &github.Repository{FullName: github.String(tc.repo)},
+			}
+			assert.Equal(t, tc.expected, matchesInboxFilters(tc.settings, n))
+		})
+	}
+}
+
+func TestUpdateInboxSettings(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{})
+	p.store = &pluginapi.MemoryStore{}
+
+	for _, tc := range []struct {
+		name            string
+		parameters      []string
+		expected        *UserSettings
+		expectedUpdated bool
+	}{
+		{name: "on", parameters: []string{"on"}, expected: &UserSettings{InboxNotifications: true}, expectedUpdated: true},
+		{name: "reasons", parameters: []string{"reasons", "ci_activity, security_alert"}, expected: &UserSettings{InboxReasons: []string{"ci_activity", "security_alert"}}, expectedUpdated: true},
+		{name: "unknown reason", parameters: []string{"reasons", "unknown"}, expected: &UserSettings{}},
+		{name: "repos", parameters: []string{"repos", "owner,owner/repo"}, expected: &UserSettings{InboxRepos: []string{"owner", "owner/repo"}}, expectedUpdated: true},
+		{name: "invalid repo", parameters: []string{"repos", "owner/"}, expected: &UserSettings{}},
+		{name: "missing value", parameters: []string{"repos"}, expected: &UserSettings{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := &UserSettings{}
+			message, updated := p.updateInboxSettings("user1", settings, tc.parameters)
+			assert.Equal(t, tc.expectedUpdated, updated, message)
+			assert.Equal(t, tc.expected, settings)
+		})
+	}
+
+	t.Run("the inbox is listed while it's on", func(t *testing.T) {
+		settings := &UserSettings{}
+		_, updated := p.updateInboxSettings("user2", settings, []string{"on"})
+		require.True(t, updated)
+
+		userIDs, err := p.listInboxUserIDs()
+		require.NoError(t, err)
+		assert.Contains(t, userIDs, "user2")
+
+		_, updated = p.updateInboxSettings("user2", settings, []string{"off"})
+		require.True(t, updated)
+
+		userIDs, err = p.listInboxUserIDs()
+		require.NoError(t, err)
+		assert.NotContains(t, userIDs, "user2")
+	})
+}
+
+func TestPollInbox(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+
+	var notifications, lastModified, ifModifiedSince string
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/rate_limit" {
+			writeTestRateLimits(w, 4000)
+			return
+		}
+
+		assert.Equal(t, "/api/v3/notifications", r.URL.Path)
+		requests++
+		ifModifiedSince = r.Header.Get("If-Modified-Since")
+
+		w.Header().Set("X-Poll-Interval", "60")
+		if ifModifiedSince == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = w.Write([]byte(notifications))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{
+		EncryptionKey:       "abcdefghijklmnopqrstuvwxyz012345",
+		EnterpriseBaseURL:   ts.URL,
+		EnterpriseUploadURL: ts.URL,
+	})
+	p.BotUserID = "bot"
+	p.store = &pluginapi.MemoryStore{}
+
+	var posts []string
+	api := &plugintest.API{}
+	api.On("GetDirectChannel", "user1", "bot").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("CreatePost", mock.Anything).Run(func(args mock.Arguments) {
+		posts = append(posts, args.Get(0).(*model.Post).Message)
+	}).Return(&model.Post{}, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+		UserID:         "user1",
+		GitHubUsername: "gh-user",
+		Token:          &oauth2.Token{AccessToken: "token"},
+		Settings:       &UserSettings{InboxNotifications: true},
+	}))
+	require.NoError(t, p.setInboxUserListed("user1", true))
+
+	thread := func(title, reason string, updatedAt time.Time) string {
+		return `{"reason": "` + reason + `", "updated_at": "` + updatedAt.UTC().Format(time.RFC3339) + `",
+			"subject": {"title": "` + title + `", "url": "https://api.github.com/repos/owner/repo/issues/1"},
+			"repository": {"full_name": "owner/repo", "html_url": "https://github.com/owner/repo", "owner": {"login": "owner"}}}`
+	}
+
+	// the threads updated before the inbox was turned on aren't forwarded.
+	notifications, lastModified = `[`+thread("Old", "mention", start.Add(-time.Hour))+`]`, "v1"
+	polled, err := p.pollInbox(context.Background(), "user1", start)
+	require.NoError(t, err)
+	assert.True(t, polled)
+	assert.Equal(t, 1, requests)
+	assert.Empty(t, posts)
+
+	// the inbox isn't polled again before the poll interval.
+	polled, err = p.pollInbox(context.Background(), "user1", start.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.False(t, polled)
+	assert.Equal(t, 1, requests)
+
+	lastModified = "v2"
+	notifications = `[` + thread("Watched", notificationReasonSubscribed, start.Add(time.Minute)) + `,` +
+		thread("Failed", "ci_activity", start.Add(time.Minute)) + `,` + thread("Old", "mention", start.Add(-time.Hour)) + `]`
+	_, err = p.pollInbox(context.Background(), "user1", start.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"[owner/repo](https://github.com/owner/repo) [Failed](https://github.com/owner/repo/issues/1) (`ci_activity`)"}, posts)
+
+	// an unchanged inbox isn't forwarded again.
+	_, err = p.pollInbox(context.Background(), "user1", start.Add(4*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, "v2", ifModifiedSince)
+	assert.Len(t, posts, 1)
+
+	// the inbox of a disconnected user isn't listed anymore.
+	require.NoError(t, p.store.Delete(getUserInfoKey("user1", "")))
+	polled, err = p.pollInbox(context.Background(), "user1", start.Add(6*time.Minute))
+	require.NoError(t, err)
+	assert.False(t, polled)
+
+	userIDs, err := p.listInboxUserIDs()
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+}
+
+func TestPollInboxPages(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+
+	thread := func(title string, updatedAt time.Time) string {
+		return `{"reason": "ci_activity", "updated_at": "` + updatedAt.UTC().Format(time.RFC3339) + `",
+			"subject": {"title": "` + title + `", "url": "https://api.github.com/repos/owner/repo/issues/1"},
+			"repository": {"full_name": "owner/repo", "html_url": "https://github.com/owner/repo", "owner": {"login": "owner"}}}`
+	}
+
+	// the threads of the third page were already forwarded, so the fourth one isn't fetched.
+	pages := map[string]string{
+		"1": `[` + thread("Third", start.Add(3*time.Minute)) + `]`,
+		"2": `[` + thread("Second", start.Add(2*time.Minute)) + `]`,
+		"3": `[` + thread("First", start.Add(time.Minute)) + `,` + thread("Old", start.Add(-time.Hour)) + `]`,
+		"4": `[` + thread("Older", start.Add(-2*time.Hour)) + `]`,
+	}
+	var requestedPages []string
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/rate_limit" {
+			writeTestRateLimits(w, 4000)
+			return
+		}
+
+		page := r.URL.Query().Get("page")
+		requestedPages = append(requestedPages, page)
+		next, _ := strconv.Atoi(page)
+		w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/notifications?page=%d>; rel="next"`, ts.URL, next+1))
+		_, _ = w.Write([]byte(pages[page]))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{
+		EncryptionKey:       "abcdefghijklmnopqrstuvwxyz012345",
+		EnterpriseBaseURL:   ts.URL,
+		EnterpriseUploadURL: ts.URL,
+	})
+	p.BotUserID = "bot"
+	p.store = &pluginapi.MemoryStore{}
+
+	var posts []string
+	api := &plugintest.API{}
+	api.On("GetDirectChannel", "user1", "bot").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("CreatePost", mock.Anything).Run(func(args mock.Arguments) {
+		posts = append(posts, args.Get(0).(*model.Post).Message)
+	}).Return(&model.Post{}, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+		UserID:         "user1",
+		GitHubUsername: "gh-user",
+		Token:          &oauth2.Token{AccessToken: "token"},
+		Settings:       &UserSettings{InboxNotifications: true},
+	}))
+	_, err := p.store.Set(getInboxPollStateKey("user1"), &inboxPollState{LastUpdatedAt: start.UnixMilli()})
+	require.NoError(t, err)
+
+	polled, err := p.pollInbox(context.Background(), "user1", start.Add(4*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, polled)
+	assert.Equal(t, []string{"1", "2", "3"}, requestedPages)
+	require.Len(t, posts, 3)
+	assert.Contains(t, posts[0], "First")
+	assert.Contains(t, posts[2], "Third")
+}
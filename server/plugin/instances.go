@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// flagInstance is the command flag selecting one of the additional GitHub instances.
+const flagInstance = "instance"
+
+// instanceNameRegex matches the names of the additional GitHub instances, used in commands and webhook URLs.
+var instanceNameRegex = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// githubInstance is an additional GitHub host used along with the default one, e.g. a GitHub Enterprise Server
+// used along with github.com. Each instance has its own OAuth app, webhook secret and organizations.
+type githubInstance struct {
+	Name              string `json:"name"`
+	BaseURL           string `json:"base_url"`
+	UploadURL         string `json:"upload_url"`
+	OAuthClientID     string `json:"oauth_client_id"`
+	OAuthClientSecret string `json:"oauth_client_secret"`
+	WebhookSecret     string `json:"webhook_secret"`
+	Org               string `json:"org"`
+}
+
+// getInstances returns the additional GitHub instances configured in addition to the default one.
+func (c *Configuration) getInstances() ([]*githubInstance, error) {
+	if strings.TrimSpace(c.GitHubInstances) == "" {
+		return nil, nil
+	}
+
+	var instances []*githubInstance
+	if err := json.Unmarshal([]byte(c.GitHubInstances), &instances); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the github instances")
+	}
+
+	return instances, nil
+}
+
+// validateInstances checks that the additional GitHub instances can be used.
+func (c *Configuration) validateInstances() error {
+	instances, err := c.getInstances()
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for _, instance := range instances {
+		if !instanceNameRegex.MatchString(instance.Name) {
+			return errors.Errorf("the github instance name %q must only contain lowercase letters, digits and dashes", instance.Name)
+		}
+		if names[instance.Name] {
+			return errors.Errorf("the github instance name %q is used more than once", instance.Name)
+		}
+		names[instance.Name] = true
+
+		if _, err := url.ParseRequestURI(instance.BaseURL); err != nil {
+			return errors.Errorf("the github instance %q must have a valid base url", instance.Name)
+		}
+		if instance.OAuthClientID == "" || instance.OAuthClientSecret == "" {
+			return errors.Errorf("the github instance %q must have an oauth client id and secret", instance.Name)
+		}
+		if instance.WebhookSecret == "" {
+			return errors.Errorf("the github instance %q must have a webhook secret", instance.Name)
+		}
+	}
+
+	return nil
+}
+
+// forInstance returns the configuration used for the GitHub instance with the given name.
+// The default instance is configured by the main settings and has an empty name.
+func (c *Configuration) forInstance(name string) (*Configuration, error) {
+	if name == "" {
+		return c, nil
+	}
+
+	instances, err := c.getInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		if instance.Name != name {
+			continue
+		}
+
+		config := c.Clone()
+		config.EnterpriseBaseURL = strings.TrimRight(instance.BaseURL, "/")
+		config.EnterpriseUploadURL = strings.TrimRight(instance.UploadURL, "/")
+		if config.EnterpriseUploadURL == "" {
+			config.EnterpriseUploadURL = config.EnterpriseBaseURL
+		}
+		if isGitHubDotCom(config.EnterpriseBaseURL) {
+			config.EnterpriseBaseURL = ""
+			config.EnterpriseUploadURL = ""
+		}
+		config.GitHubOAuthClientID = instance.OAuthClientID
+		config.GitHubOAuthClientSecret = instance.OAuthClientSecret
+		config.WebhookSecret = instance.WebhookSecret
+		config.GitHubOrg = instance.Org
+		// the pre-registered application and the GitHub App only apply to the default instance.
+		config.UsePreregisteredApplication = false
+		config.GitHubAppID = ""
+		config.GitHubAppPrivateKey = ""
+		config.GitHubInstances = ""
+
+		return config, nil
+	}
+
+	return nil, errors.Errorf("unknown github instance %s", name)
+}
+
+func isGitHubDotCom(baseURL string) bool {
+	parsed, err := url.Parse(baseURL)
+	return err == nil && strings.EqualFold(strings.TrimPrefix(parsed.Host, "www."), "github.com")
+}
+
+// getUserInfoKey returns the key storing the account of a user connected to a GitHub instance.
+func getUserInfoKey(userID, instance string) string {
+	if instance == "" {
+		return userID + githubTokenKey
+	}
+
+	return userID + "_" + instance + githubTokenKey
+}
+
+// getGitHubUsernameKey returns the key mapping a GitHub username of a GitHub instance to the Mattermost user.
+func getGitHubUsernameKey(githubUsername, instance string) string {
+	if instance == "" {
+		return githubUsername + githubUsernameKey
+	}
+
+	return githubUsername + "_" + instance + githubUsernameKey
+}
+
+// parseUserInfoKey returns the user and the GitHub instance of a key storing the account of a user.
+func parseUserInfoKey(key string) (userID, instance string) {
+	userID, instance, _ = strings.Cut(strings.TrimSuffix(key, githubTokenKey), "_")
+	return userID, instance
+}
+
+// parseInstanceFlag removes the --instance flag from the command parameters and returns its value.
+func parseInstanceFlag(parameters []string) (instance string, rest []string, err error) {
+	for i := 0; i < len(parameters); i++ {
+		if !isFlag(parameters[i]) || parseFlag(parameters[i]) != flagInstance {
+			rest = append(rest, parameters[i])
+			continue
+		}
+		if i+1 == len(parameters) {
+			return "", nil, errors.New("please specify the name of the GitHub instance")
+		}
+		instance = parameters[i+1]
+		i++
+	}
+
+	return instance, rest, nil
+}
+
+// getInstanceCommandSuffix returns the flag to add to commands run for the GitHub instance.
+func getInstanceCommandSuffix(instance string) string {
+	if instance == "" {
+		return ""
+	}
+
+	return " --" + flagInstance + " " + instance
+}
@@ -0,0 +1,194 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGitHubInstances = `[
+	{
+		"name": "ghes",
+		"base_url": "https://github.example.com/",
+		"oauth_client_id": "ghes-client-id",
+		"oauth_client_secret": "ghes-client-secret",
+		"webhook_secret": "ghes-webhook-secret",
+		"org": "ghes-org"
+	},
+	{
+		"name": "dotcom",
+		"base_url": "https://github.com",
+		"oauth_client_id": "dotcom-client-id",
+		"oauth_client_secret": "dotcom-client-secret",
+		"webhook_secret": "dotcom-webhook-secret"
+	}
+]`
+
+func TestValidateInstances(t *testing.T) {
+	for _, testCase := range []struct {
+		description string
+		instances   string
+		errMsg      string
+	}{
+		{
+			description: "no instances",
+		},
+		{
+			description: "valid instances",
+			instances:   testGitHubInstances,
+		},
+		{
+			description: "invalid JSON",
+			instances:   `{"name": "ghes"}`,
+			errMsg:      "failed to parse the github instances",
+		},
+		{
+			description: "invalid name",
+			instances:   `[{"name": "My GHES", "base_url": "https://github.example.com", "oauth_client_id": "id", "oauth_client_secret": "secret", "webhook_secret": "secret"}]`,
+			errMsg:      "must only contain lowercase letters, digits and dashes",
+		},
+		{
+			description: "duplicate name",
+			instances: `[{"name": "ghes", "base_url": "https://github.example.com", "oauth_client_id": "id", "oauth_client_secret": "secret", "webhook_secret": "secret"},
+				{"name": "ghes", "base_url": "https://github.example.org", "oauth_client_id": "id", "oauth_client_secret": "secret", "webhook_secret": "secret"}]`,
+			errMsg: "is used more than once",
+		},
+		{
+			description: "missing base url",
+			instances:   `[{"name": "ghes", "oauth_client_id": "id", "oauth_client_secret": "secret", "webhook_secret": "secret"}]`,
+			errMsg:      "must have a valid base url",
+		},
+		{
+			description: "missing OAuth credentials",
+			instances:   `[{"name": "ghes", "base_url": "https://github.example.com", "webhook_secret": "secret"}]`,
+			errMsg:      "must have an oauth client id and secret",
+		},
+		{
+			description: "missing webhook secret",
+			instances:   `[{"name": "ghes", "base_url": "https://github.example.com", "oauth_client_id": "id", "oauth_client_secret": "secret"}]`,
+			errMsg:      "must have a webhook secret",
+		},
+	} {
+		t.Run(testCase.description, func(t *testing.T) {
+			config := &Configuration{GitHubInstances: testCase.instances}
+
+			err := config.validateInstances()
+			if testCase.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), testCase.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestForInstance(t *testing.T) {
+	config := &Configuration{
+		GitHubOAuthClientID:     "client-id",
+		GitHubOAuthClientSecret: "client-secret",
+		WebhookSecret:           "webhook-secret",
+		EnterpriseBaseURL:       "https://enterprise.example.com",
+		EnterpriseUploadURL:     "https://enterprise.example.com",
+		GitHubOrg:               "org",
+		EncryptionKey:           "abcd",
+		GitHubInstances:         testGitHubInstances,
+	}
+
+	t.Run("default instance", func(t *testing.T) {
+		instanceConfig, err := config.forInstance("")
+		require.NoError(t, err)
+		assert.Equal(t, config, instanceConfig)
+	})
+
+	t.Run("enterprise instance", func(t *testing.T) {
+		instanceConfig, err := config.forInstance("ghes")
+		require.NoError(t, err)
+		assert.Equal(t, "https://github.example.com", instanceConfig.EnterpriseBaseURL)
+		assert.Equal(t, "https://github.example.com", instanceConfig.EnterpriseUploadURL)
+		assert.Equal(t, "ghes-client-id", instanceConfig.GitHubOAuthClientID)
+		assert.Equal(t, "ghes-client-secret", instanceConfig.GitHubOAuthClientSecret)
+		assert.Equal(t, "ghes-webhook-secret", instanceConfig.WebhookSecret)
+		assert.Equal(t, "ghes-org", instanceConfig.GitHubOrg)
+		assert.Equal(t, "abcd", instanceConfig.EncryptionKey)
+		assert.Empty(t, instanceConfig.GitHubInstances)
+
+		// the default configuration isn't changed.
+		assert.Equal(t, "client-id", config.GitHubOAuthClientID)
+	})
+
+	t.Run("github.com instance", func(t *testing.T) {
+		instanceConfig, err := config.forInstance("dotcom")
+		require.NoError(t, err)
+		assert.Empty(t, instanceConfig.EnterpriseBaseURL)
+		assert.Empty(t, instanceConfig.EnterpriseUploadURL)
+		assert.Empty(t, instanceConfig.GitHubOrg)
+		assert.Equal(t, "https://github.com/", instanceConfig.getBaseURL())
+	})
+
+	t.Run("unknown instance", func(t *testing.T) {
+		_, err := config.forInstance("unknown")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseInstanceFlag(t *testing.T) {
+	for _, testCase := range []struct {
+		description      string
+		parameters       []string
+		expectedInstance string
+		expectedRest     []string
+		expectError      bool
+	}{
+		{
+			description:  "no flag",
+			parameters:   []string{"private"},
+			expectedRest: []string{"private"},
+		},
+		{
+			description:      "flag after parameters",
+			parameters:       []string{"private", "--instance", "ghes"},
+			expectedInstance: "ghes",
+			expectedRest:     []string{"private"},
+		},
+		{
+			description:      "flag before parameters",
+			parameters:       []string{"--instance", "ghes", "device"},
+			expectedInstance: "ghes",
+			expectedRest:     []string{"device"},
+		},
+		{
+			description: "flag without value",
+			parameters:  []string{"--instance"},
+			expectError: true,
+		},
+	} {
+		t.Run(testCase.description, func(t *testing.T) {
+			instance, rest, err := parseInstanceFlag(testCase.parameters)
+			if testCase.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedInstance, instance)
+			assert.Equal(t, testCase.expectedRest, rest)
+		})
+	}
+}
+
+func TestParseUserInfoKey(t *testing.T) {
+	userID, instance := parseUserInfoKey(getUserInfoKey("user1", ""))
+	assert.Equal(t, "user1", userID)
+	assert.Empty(t, instance)
+
+	userID, instance = parseUserInfoKey(getUserInfoKey("user1", "ghes"))
+	assert.Equal(t, "user1", userID)
+	assert.Equal(t, "ghes", instance)
+}
+
+func TestGetGitHubUsernameKey(t *testing.T) {
+	assert.Equal(t, "octocat_githubusername", getGitHubUsernameKey("octocat", ""))
+	assert.Equal(t, "octocat_ghes_githubusername", getGitHubUsernameKey("octocat", "ghes"))
+}
@@ -0,0 +1,241 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v54/github"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-github/server/plugin/graphql"
+)
+
+// maxLinkPreviews sets the maximum number of pull request and issue URLs
+// previewed as attachments in a single message.
+const maxLinkPreviews = 3
+
+const (
+	linkPreviewColorOpen   = "#2cbe4e"
+	linkPreviewColorMerged = "#6f42c1"
+	linkPreviewColorClosed = "#cb2431"
+	linkPreviewColorDraft  = "#6a737d"
+)
+
+const checksStatusFailure = "failure"
+
+// getLinkPreviewAttachments returns the attachments previewing the pull request and issue URLs of a message.
+// The items are fetched with the token of the user posting the message, unless the previews of the reference
+// pass already have them. The details of the pull requests are fetched with a single GraphQL query.
+func (p *Plugin) getLinkPreviewAttachments(ctx context.Context, references []issueReference, previews map[string]*referencePreview, ghClient *github.Client, repos *repositoryLookup, info *GitHubUserInfo, channelID string) []*model.SlackAttachment {
+	type linkPreview struct {
+		r       issueReference
+		preview *referencePreview
+	}
+
+	var links []linkPreview
+	var prURLs []string
+	previewed := map[string]bool{}
+	for _, r := range references {
+		if !r.isURL || previewed[r.key()] {
+			continue
+		}
+		// have a limit on the number of links to preview
+		if len(previewed) >= maxLinkPreviews {
+			break
+		}
+		previewed[r.key()] = true
+
+		preview, ok := previews[r.key()]
+		if !ok {
+			var err error
+			preview, err = p.getReferencePreview(ctx, ghClient, repos, info.UserID, r)
+			if err != nil {
+				p.client.Log.Debug("Error while fetching linked issue", "error", err.Error(), "reference", r.key())
+				continue
+			}
+			if preview.IsPrivate && !p.isPrivatePreviewAllowed(ctx, channelID, r.owner, r.repo) {
+				continue
+			}
+		}
+		if preview == nil {
+			// the reference pass couldn't fetch it, or its preview isn't allowed in the channel.
+			continue
+		}
+
+		links = append(links, linkPreview{r: r, preview: preview})
+		if preview.IsPullRequest {
+			prURLs = append(prURLs, preview.URL)
+		}
+	}
+
+	details := p.getLinkedPullRequestDetails(ctx, info, prURLs)
+
+	var attachments []*model.SlackAttachment
+	for _, link := range links {
+		// the pull requests whose details couldn't be fetched are previewed like issues.
+		if prDetails := details[link.preview.URL]; link.preview.IsPullRequest && prDetails != nil {
+			attachments = append(attachments, getPullRequestPreviewAttachment(link.r, link.preview, prDetails))
+			continue
+		}
+		attachments = append(attachments, getIssueLinkPreviewAttachment(link.r, link.preview))
+	}
+
+	return attachments
+}
+
+// getLinkedPullRequestDetails fetches the state, reviews and checks of the linked pull requests, by URL.
+func (p *Plugin) getLinkedPullRequestDetails(ctx context.Context, info *GitHubUserInfo, prURLs []string) map[string]*graphql.PRDetails {
+	details := map[string]*graphql.PRDetails{}
+	if len(prURLs) == 0 {
+		return details
+	}
+
+	graphQLClient := p.graphQLConnect(info)
+	if graphQLClient == nil {
+		return details
+	}
+
+	// the checks can be unavailable, like on older GitHub Enterprise servers, the previews are shown without them.
+	prDetails, err := graphQLClient.GetPRDetails(ctx, prURLs)
+	if err != nil {
+		p.client.Log.Debug("Error while fetching the details of the linked pull requests", "error", err.Error())
+	}
+	for i, prURL := range prURLs {
+		if i < len(prDetails) && prDetails[i] != nil {
+			details[prURL] = prDetails[i]
+		}
+	}
+
+	return details
+}
+
+func getIssueLinkPreviewAttachment(r issueReference, preview *referencePreview) *model.SlackAttachment {
+	color := linkPreviewColorOpen
+	if preview.State == "closed" {
+		color = linkPreviewColorClosed
+	}
+
+	fields := []*model.SlackAttachmentField{{
+		Title: "State",
+		Value: preview.State,
+		Short: true,
+	}}
+	if len(preview.Labels) > 0 {
+		fields = append(fields, &model.SlackAttachmentField{
+			Title: "Labels",
+			Value: "`" + strings.Join(preview.Labels, "` `") + "`",
+			Short: true,
+		})
+	}
+
+	return &model.SlackAttachment{
+		Color:      color,
+		AuthorName: preview.Author,
+		Title:      fmt.Sprintf("#%d %s", r.number, preview.Title),
+		TitleLink:  preview.URL,
+		Fields:     fields,
+		Footer:     fullNameFromOwnerAndRepo(r.owner, r.repo),
+	}
+}
+
+func getPullRequestPreviewAttachment(r issueReference, preview *referencePreview, details *graphql.PRDetails) *model.SlackAttachment {
+	state, color := preview.State, linkPreviewColorOpen
+	switch {
+	case details.Merged:
+		state, color = "merged", linkPreviewColorMerged
+	case preview.State == "closed":
+		color = linkPreviewColorClosed
+	case details.Draft:
+		state, color = "draft", linkPreviewColorDraft
+	}
+
+	fields := []*model.SlackAttachmentField{
+		{
+			Title: "State",
+			Value: state,
+			Short: true,
+		},
+		{
+			Title: "Changes",
+			Value: fmt.Sprintf("+%d -%d in %d files", details.Additions, details.Deletions, details.ChangedFiles),
+			Short: true,
+		},
+	}
+
+	if checksStatus := getChecksStatus(details.Status); checksStatus != "" {
+		fields = append(fields, &model.SlackAttachmentField{
+			Title: "Checks",
+			Value: checksStatus,
+			Short: true,
+		})
+	}
+
+	if reviewers := getReviewersSummary(details); reviewers != "" {
+		fields = append(fields, &model.SlackAttachmentField{
+			Title: "Reviewers",
+			Value: reviewers,
+			Short: true,
+		})
+	}
+
+	return &model.SlackAttachment{
+		Color:      color,
+		AuthorName: preview.Author,
+		AuthorIcon: details.AuthorAvatarURL,
+		AuthorLink: details.AuthorURL,
+		Title:      fmt.Sprintf("#%d %s", r.number, preview.Title),
+		TitleLink:  preview.URL,
+		Fields:     fields,
+		Footer:     fmt.Sprintf("%s | %s ← %s", fullNameFromOwnerAndRepo(r.owner, r.repo), details.BaseRef, details.HeadRef),
+	}
+}
+
+// getReviewersSummary lists the latest review state of each reviewer, followed by the pending review requests.
+func getReviewersSummary(details *graphql.PRDetails) string {
+	var reviewers []string
+	latestStates := map[string]string{}
+	for _, review := range details.Reviews {
+		login := review.GetUser().GetLogin()
+		state := review.GetState()
+		// Comments don't override an approval or a change request.
+		if state == "COMMENTED" && latestStates[login] != "" {
+			continue
+		}
+		if _, ok := latestStates[login]; !ok {
+			reviewers = append(reviewers, login)
+		}
+		latestStates[login] = state
+	}
+
+	var summary []string
+	for _, login := range reviewers {
+		switch latestStates[login] {
+		case "APPROVED":
+			summary = append(summary, login+" :white_check_mark:")
+		case "CHANGES_REQUESTED":
+			summary = append(summary, login+" :x:")
+		default:
+			summary = append(summary, login+" :speech_balloon:")
+		}
+	}
+
+	for _, login := range details.RequestedReviewers {
+		summary = append(summary, *login+" :hourglass:")
+	}
+	for _, team := range details.RequestedTeams {
+		summary = append(summary, team+" :hourglass:")
+	}
+
+	return strings.Join(summary, ", ")
+}
+
+// getChecksStatus returns the status of the checks shown in the preview, from the status rollup of the last commit.
+func getChecksStatus(status string) string {
+	if status == "error" {
+		return checksStatusFailure
+	}
+
+	return status
+}
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+
+	"github.com/mattermost/mattermost-plugin-github/server/plugin/graphql"
+)
+
+func TestGetChecksStatus(t *testing.T) {
+	assert.Equal(t, "", getChecksStatus(""))
+	assert.Equal(t, "success", getChecksStatus("success"))
+	assert.Equal(t, "pending", getChecksStatus("pending"))
+	assert.Equal(t, checksStatusFailure, getChecksStatus("failure"))
+	assert.Equal(t, checksStatusFailure, getChecksStatus("error"))
+}
+
+func TestGetReviewersSummary(t *testing.T) {
+	review := func(login, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: github.String(login)}, State: github.String(state)}
+	}
+
+	details := &graphql.PRDetails{
+		RequestedReviewers: []*string{github.String("carol")},
+		RequestedTeams:     []string{"core"},
+		Reviews: []*github.PullRequestReview{
+			review("alice", "CHANGES_REQUESTED"),
+			review("bob", "COMMENTED"),
+			review("alice", "APPROVED"),
+			review("alice", "COMMENTED"),
+		},
+	}
+
+	assert.Equal(t, "alice :white_check_mark:, bob :speech_balloon:, carol :hourglass:, core :hourglass:", getReviewersSummary(details))
+	assert.Equal(t, "", getReviewersSummary(&graphql.PRDetails{}))
+}
+
+func TestGetPullRequestPreviewAttachment(t *testing.T) {
+	r := issueReference{owner: "mattermost", repo: "mattermost-server", number: 7}
+	preview := &referencePreview{
+		Title:         "Add previews",
+		State:         "closed",
+		Author:        "octocat",
+		URL:           "https://github.com/mattermost/mattermost-server/pull/7",
+		IsPullRequest: true,
+	}
+	details := &graphql.PRDetails{
+		Status:       "success",
+		Merged:       true,
+		Additions:    10,
+		Deletions:    2,
+		ChangedFiles: 3,
+		BaseRef:      "master",
+		HeadRef:      "previews",
+	}
+
+	attachment := getPullRequestPreviewAttachment(r, preview, details)
+	assert.Equal(t, linkPreviewColorMerged, attachment.Color)
+	assert.Equal(t, "#7 Add previews", attachment.Title)
+	assert.Equal(t, "octocat", attachment.AuthorName)
+	assert.Equal(t, "mattermost/mattermost-server | master ← previews", attachment.Footer)
+	assert.Len(t, attachment.Fields, 3)
+	assert.Equal(t, "merged", attachment.Fields[0].Value)
+	assert.Equal(t, "+10 -2 in 3 files", attachment.Fields[1].Value)
+	assert.Equal(t, "success", attachment.Fields[2].Value)
+}
+
+func TestGetLinkPreviewAttachments(t *testing.T) {
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/issues/2":
+			_, _ = w.Write([]byte(`{"number": 2, "title": "Bug", "state": "open", "html_url": "https://github.com/owner/repo/issues/2"}`))
+		case "/api/v3/repos/owner/repo":
+			_, _ = w.Write([]byte(`{"private": false}`))
+		default:
+			// the GraphQL API of older GitHub Enterprise servers doesn't have the status rollup.
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EnterpriseBaseURL: ts.URL, EnterpriseUploadURL: ts.URL})
+	p.store = &pluginapi.MemoryStore{}
+	api := &plugintest.API{}
+	api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything).Once()
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	info := &GitHubUserInfo{UserID: "user1", Token: &oauth2.Token{AccessToken: "token"}}
+	ghClient := p.githubConnectUser(context.Background(), info)
+	require.NotNil(t, ghClient)
+
+	references := getIssueReferences("https://github.com/owner/repo/pull/1 https://github.com/owner/repo/issues/2", "", "https://github.com/")
+	// the pull request was already fetched by the reference pass.
+	previews := map[string]*referencePreview{
+		"owner/repo#1": {Title: "Fix", State: "open", Author: "octocat", URL: "https://github.com/owner/repo/pull/1", IsPullRequest: true},
+	}
+
+	attachments := p.getLinkPreviewAttachments(context.Background(), references, previews, ghClient, newRepositoryLookup(), info, "channel1")
+	require.Len(t, attachments, 2)
+
+	// the pull request whose details couldn't be fetched is previewed like an issue.
+	assert.Equal(t, "#1 Fix", attachments[0].Title)
+	assert.Len(t, attachments[0].Fields, 1)
+	assert.Equal(t, "#2 Bug", attachments[1].Title)
+
+	assert.Zero(t, requests["/api/v3/repos/owner/repo/issues/1"])
+	assert.Equal(t, 1, requests["/api/v3/repos/owner/repo/issues/2"])
+	assert.Equal(t, 1, requests["/api/graphql"])
+	api.AssertExpectations(t)
+}
@@ -2,7 +2,10 @@ package plugin
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"path"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,12 +16,21 @@ import (
 // permalink replacements that can be performed on a single message.
 const maxPermalinkReplacements = 10
 
-const permalinkReqTimeout = 5 * time.Second
+// messagePreviewTimeout is the time given to all the previews of a message, since they are made while the
+// message is being posted.
+const messagePreviewTimeout = 5 * time.Second
 
 // maxPreviewLines sets the maximum number of preview lines that will be shown
 // while replacing a permalink.
 const maxPreviewLines = 10
 
+// maxPermalinkRefSegments sets the maximum number of path segments tried as part
+// of the name of a branch or tag containing slashes.
+const maxPermalinkRefSegments = 3
+
+// commitSHARegex matches full commit SHAs, which don't need to be resolved.
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 // permalinkLineContext shows the number of lines before and after to show
 // if the link points to a single line.
 const permalinkLineContext = 3
@@ -41,9 +53,29 @@ type replacement struct {
 // getReplacements returns the permalink replacements that needs to be performed
 // on a message. The returned slice is sorted by the index in ascending order.
 func (p *Plugin) getReplacements(msg string) []replacement {
+	return getPermalinkReplacements(msg, p.githubPermalinkRegex)
+}
+
+// getGitHubHost returns the host of the GitHub instance with the given base URL, or an empty string if the URL
+// isn't valid.
+func getGitHubHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+}
+
+// newPermalinkRegex returns the regex matching the permalinks of the given GitHub host.
+func newPermalinkRegex(host string) *regexp.Regexp {
+	return regexp.MustCompile(`https?://(?P<haswww>www\.)?` + regexp.QuoteMeta(host) + `/(?P<user>[\w-]+)/(?P<repo>[\w-.]+)/blob/(?P<commit>[\w-.]+)/(?P<path>[\w-/.]+)#(?P<line>[\w-]+)?`)
+}
+
+func getPermalinkReplacements(msg string, permalinkRegex *regexp.Regexp) []replacement {
 	// find the permalinks from the msg using a regex
-	matches := p.githubPermalinkRegex.FindAllStringSubmatch(msg, -1)
-	indices := p.githubPermalinkRegex.FindAllStringIndex(msg, -1)
+	matches := permalinkRegex.FindAllStringSubmatch(msg, -1)
+	indices := permalinkRegex.FindAllStringIndex(msg, -1)
 	var replacements []replacement
 	for i, m := range matches {
 		// have a limit on the number of replacements to do
@@ -61,7 +93,7 @@ func (p *Plugin) getReplacements(msg string) []replacement {
 			continue
 		}
 		// populate the permalinkInfo with the extracted groups of the regex
-		for j, name := range p.githubPermalinkRegex.SubexpNames() {
+		for j, name := range permalinkRegex.SubexpNames() {
 			if j == 0 {
 				continue
 			}
@@ -87,39 +119,38 @@ func (p *Plugin) getReplacements(msg string) []replacement {
 
 // makeReplacements perform the given replacements on the msg and returns
 // the new msg. The replacements slice needs to be sorted by the index in ascending order.
-func (p *Plugin) makeReplacements(msg string, replacements []replacement, ghClient *github.Client) string {
-	config := p.getConfiguration()
-
+func (p *Plugin) makeReplacements(ctx context.Context, msg string, replacements []replacement, ghClient *github.Client, repos *repositoryLookup, channelID string) string {
 	// iterating the slice in reverse to preserve the replacement indices.
 	for i := len(replacements) - 1; i >= 0; i-- {
 		r := replacements[i]
 
-		ctx, cancel := context.WithTimeout(context.Background(), permalinkReqTimeout)
-		defer cancel()
-
-		// Check if repo is public
-		if config.EnableCodePreview != "privateAndPublic" {
-			repo, _, err := ghClient.Repositories.Get(ctx, r.permalinkInfo.user, r.permalinkInfo.repo)
-			if err != nil {
-				p.client.Log.Warn("Error while fetching repository information",
-					"error", err.Error(),
-					"repo", r.permalinkInfo.repo,
-					"user", r.permalinkInfo.user)
-				continue
-			}
-
-			if repo.GetPrivate() {
-				continue
+		allowed, userCanAccess := p.isCodePreviewAllowed(ctx, ghClient, repos, channelID, r.permalinkInfo.user, r.permalinkInfo.repo)
+		if !allowed {
+			continue
+		}
+		previewClient := p.getPreviewClient(ctx, ghClient, repos, r.permalinkInfo.user, r.permalinkInfo.repo, userCanAccess)
+
+		// branch and tag links are resolved to the commit they currently point to.
+		ref, filePath, link := r.permalinkInfo.commit, r.permalinkInfo.path, r.word
+		resolvedSHA := ""
+		if !commitSHARegex.MatchString(ref) {
+			if sha, resolvedRef, resolvedPath := resolvePermalinkRef(ctx, previewClient, r.permalinkInfo.user, r.permalinkInfo.repo, ref, filePath); sha != "" {
+				resolvedSHA = sha
+				link = strings.Replace(link, "/blob/"+resolvedRef+"/", "/blob/"+sha+"/", 1)
+				ref, filePath = resolvedRef, resolvedPath
 			}
 		}
 
 		// get the file contents
 		opts := github.RepositoryContentGetOptions{
-			Ref: r.permalinkInfo.commit,
+			Ref: ref,
+		}
+		if resolvedSHA != "" {
+			opts.Ref = resolvedSHA
 		}
 		// TODO: make all of these requests concurrently.
-		fileContent, _, _, err := ghClient.Repositories.GetContents(ctx,
-			r.permalinkInfo.user, r.permalinkInfo.repo, r.permalinkInfo.path, &opts)
+		fileContent, _, _, err := previewClient.Repositories.GetContents(ctx,
+			r.permalinkInfo.user, r.permalinkInfo.repo, filePath, &opts)
 		if err != nil {
 			p.client.Log.Warn("Error while fetching file contents", "error", err.Error(), "path", r.permalinkInfo.path)
 			continue
@@ -154,10 +185,67 @@ func (p *Plugin) makeReplacements(msg string, replacements []replacement, ghClie
 			p.client.Log.Warn("Line numbers out of range. Skipping.", "file", r.permalinkInfo.path, "start", start, "end", end)
 			continue
 		}
-		final := getCodeMarkdown(r.permalinkInfo.user, r.permalinkInfo.repo, r.permalinkInfo.path, r.word, lines, isTruncated)
+		note := ""
+		if resolvedSHA != "" {
+			// note which commit the branch or tag pointed to when the message was posted.
+			note = fmt.Sprintf("(`%s` at `%s`)", ref, resolvedSHA[:7])
+		}
+		final := getCodeMarkdown(r.permalinkInfo.user, r.permalinkInfo.repo, filePath, link, lines, note, isTruncated)
 
 		// replace word in msg starting from r.index only once.
 		msg = msg[:r.index] + strings.Replace(msg[r.index:], r.word, final, 1)
 	}
 	return msg
 }
+
+// makeInstanceReplacements replaces the permalinks and diff links of the additional GitHub instances the user
+// is connected to, using the account of the user on each instance.
+func (p *Plugin) makeInstanceReplacements(ctx context.Context, msg string, repos *repositoryLookup, userID, channelID string) string {
+	instances, err := p.getConfiguration().getInstances()
+	if err != nil {
+		return msg
+	}
+
+	for _, instance := range instances {
+		info, apiErr := p.getGitHubUserInfoForInstance(userID, instance.Name)
+		if apiErr != nil {
+			continue
+		}
+
+		host := getGitHubHost(instance.BaseURL)
+		if host == "" {
+			continue
+		}
+
+		replacements := getPermalinkReplacements(msg, newPermalinkRegex(host))
+		if len(replacements) == 0 && len(getDiffReplacements(msg, host)) == 0 {
+			continue
+		}
+
+		ghClient := p.githubConnectUser(ctx, info)
+		if ghClient == nil {
+			continue
+		}
+		msg = p.makeReplacements(ctx, msg, replacements, ghClient, repos, channelID)
+		// the permalinks were replaced, so the indices of the diff links are found again.
+		msg = p.makeDiffReplacements(ctx, msg, getDiffReplacements(msg, host), ghClient, repos, channelID)
+	}
+
+	return msg
+}
+
+// resolvePermalinkRef resolves the branch or tag of a link to the commit it points to. Since branch names
+// can contain slashes, the first segments of the file path are tried as part of the ref name too.
+// An empty SHA is returned if the ref couldn't be resolved.
+func resolvePermalinkRef(ctx context.Context, ghClient *github.Client, owner, repo, ref, filePath string) (sha, resolvedRef, resolvedPath string) {
+	segments := strings.Split(filePath, "/")
+	for i := 0; i < len(segments) && i <= maxPermalinkRefSegments; i++ {
+		candidate := strings.Join(append([]string{ref}, segments[:i]...), "/")
+		sha, _, err := ghClient.Repositories.GetCommitSHA1(ctx, owner, repo, candidate, "")
+		if err == nil && commitSHARegex.MatchString(sha) {
+			return sha, candidate, strings.Join(segments[i:], "/")
+		}
+	}
+
+	return "", ref, filePath
+}
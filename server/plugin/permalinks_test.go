@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -379,6 +380,56 @@ func TestMakeReplacements(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "link with tag name resolved to a commit",
+			input:  "start https://github.com/mattermost/mattermost-server/blob/v1.2.0/app/authentication.go#L15-L22 lorem ipsum",
+			output: "start \n[mattermost/mattermost-server/app/authentication.go](https://github.com/mattermost/mattermost-server/blob/cbb25838a61872b624ac512556d7bc932486a64c/app/authentication.go#L15-L22) (`v1.2.0` at `cbb2583`)\n```go\ntype TokenLocation int\n\nconst (\n\tTokenLocationNotFound TokenLocation = iota\n\tTokenLocationHeader\n\tTokenLocationCookie\n\tTokenLocationQueryString\n)\n```\n lorem ipsum",
+			replacements: []replacement{
+				{
+					index: 6,
+					word:  "https://github.com/mattermost/mattermost-server/blob/v1.2.0/app/authentication.go#L15-L22",
+					permalinkInfo: struct {
+						haswww string
+						commit string
+						user   string
+						repo   string
+						path   string
+						line   string
+					}{
+						commit: "v1.2.0",
+						line:   "L15-L22",
+						path:   "app/authentication.go",
+						user:   "mattermost",
+						repo:   "mattermost-server",
+					},
+				},
+			},
+		},
+		{
+			name:   "link with branch name containing a slash",
+			input:  "start https://github.com/mattermost/mattermost-server/blob/feature/app/app/authentication.go#L15-L22 lorem ipsum",
+			output: "start \n[mattermost/mattermost-server/app/authentication.go](https://github.com/mattermost/mattermost-server/blob/cbb25838a61872b624ac512556d7bc932486a64c/app/authentication.go#L15-L22) (`feature/app` at `cbb2583`)\n```go\ntype TokenLocation int\n\nconst (\n\tTokenLocationNotFound TokenLocation = iota\n\tTokenLocationHeader\n\tTokenLocationCookie\n\tTokenLocationQueryString\n)\n```\n lorem ipsum",
+			replacements: []replacement{
+				{
+					index: 6,
+					word:  "https://github.com/mattermost/mattermost-server/blob/feature/app/app/authentication.go#L15-L22",
+					permalinkInfo: struct {
+						haswww string
+						commit string
+						user   string
+						repo   string
+						path   string
+						line   string
+					}{
+						commit: "feature",
+						line:   "L15-L22",
+						path:   "app/app/authentication.go",
+						user:   "mattermost",
+						repo:   "mattermost-server",
+					},
+				},
+			},
+		},
 		{
 			name:   "bad line range",
 			input:  "start https://github.com/mattermost/mattermost-server/blob/cbb25838a61872b624ac512556d7bc932486a64c/app/authentication.go#L22-L15 lorem ipsum",
@@ -437,7 +488,7 @@ func TestMakeReplacements(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			msg := p.makeReplacements(tc.input, tc.replacements, client)
+			msg := p.makeReplacements(context.Background(), tc.input, tc.replacements, client, nil, "")
 			assert.Equalf(t, tc.output, msg, "mismatched output")
 		})
 	}
@@ -472,6 +523,9 @@ func getClient() (*github.Client, func()) {
     "html": "https://github.com/mattermost/mattermost-server/blob/cbb25838a61872b624ac512556d7bc932486a64c/app/authentication.go"
   }
 }`)
+		case "/api-v3/repos/mattermost/mattermost-server/commits/v1.2.0",
+			"/api-v3/repos/mattermost/mattermost-server/commits/feature/app":
+			fmt.Fprint(w, "cbb25838a61872b624ac512556d7bc932486a64c")
 		case "/api-v3/repos/badorg/badrepo/path/file.go":
 			fmt.Fprintln(w, `{
   "sha": "c5c4ebf9077d04306ce8eca1e451421e4df7ca3c",
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/go-github/v54/github"
 	"github.com/gorilla/mux"
@@ -20,6 +21,7 @@ import (
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/bot/logger"
 	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/bot/poster"
 	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/telemetry"
@@ -28,10 +30,11 @@ import (
 )
 
 const (
-	githubTokenKey       = "_githubtoken"
-	githubOauthKey       = "githuboauthkey_"
-	githubUsernameKey    = "_githubusername"
-	githubPrivateRepoKey = "_githubprivate"
+	githubTokenKey        = "_githubtoken"
+	githubOauthKey        = "githuboauthkey_"
+	githubUsernameKey     = "_githubusername"
+	githubPrivateRepoKey  = "_githubprivate"
+	channelDefaultRepoKey = "_githubdefaultrepo"
 
 	mm34646MutexKey = "mm34646_token_reset_mutex"
 	mm34646DoneKey  = "mm34646_token_reset_done"
@@ -51,6 +54,8 @@ const (
 	settingOn            = "on"
 	settingOff           = "off"
 	settingOnChange      = "on-change"
+	settingAt            = "at"
+	settingToDo          = "todo"
 
 	notificationReasonSubscribed = "subscribed"
 	dailySummary                 = "_dailySummary"
@@ -69,6 +74,7 @@ type kvStore interface {
 	ListKeys(page int, count int, options ...pluginapi.ListKeysOption) ([]string, error)
 	Get(key string, o any) error
 	Delete(key string) error
+	SetAtomicWithRetries(key string, valueFunc func(oldValue []byte) (newValue any, err error)) error
 }
 
 type Plugin struct {
@@ -84,6 +90,9 @@ type Plugin struct {
 	// setConfiguration for usage.
 	configuration *Configuration
 
+	// rateLimits are the rate limits of the GitHub accounts, by user info key.
+	rateLimits sync.Map
+
 	chimeraURL string
 
 	router *mux.Router
@@ -103,14 +112,26 @@ type Plugin struct {
 	webhookBroker *WebhookBroker
 	oauthBroker   *OAuthBroker
 
+	installationTokens *installationTokenCache
+
+	tokenHealthCheckJob *cluster.Job
+	dailyReminderJob    *cluster.Job
+	inboxPollJob        *cluster.Job
+
 	emojiMap map[string]string
+
+	// lifetimeCtx is cancelled when the plugin is deactivated, to stop the work done in the background.
+	lifetimeCtx    context.Context
+	cancelLifetime context.CancelFunc
 }
 
 // NewPlugin returns an instance of a Plugin.
 func NewPlugin() *Plugin {
 	p := &Plugin{
-		githubPermalinkRegex: regexp.MustCompile(`https?://(?P<haswww>www\.)?github\.com/(?P<user>[\w-]+)/(?P<repo>[\w-.]+)/blob/(?P<commit>[\w-]+)/(?P<path>[\w-/.]+)#(?P<line>[\w-]+)?`),
+		githubPermalinkRegex: newPermalinkRegex("github.com"),
+		installationTokens:   newInstallationTokenCache(),
 	}
+	p.lifetimeCtx, p.cancelLifetime = context.WithCancel(context.Background())
 
 	p.CommandHandlers = map[string]CommandHandleFunc{
 		"subscriptions": p.handleSubscriptions,
@@ -124,6 +145,9 @@ func NewPlugin() *Plugin {
 		"":              p.handleHelp,
 		"settings":      p.handleSettings,
 		"issue":         p.handleIssue,
+		"pr":            p.handlePR,
+		"channel":       p.handleChannel,
+		"watch":         p.handleWatch,
 	}
 
 	p.createGithubEmojiMap()
@@ -171,18 +195,46 @@ func (p *Plugin) GetGitHubClient(ctx context.Context, userID string) (*github.Cl
 }
 
 func (p *Plugin) githubConnectUser(ctx context.Context, info *GitHubUserInfo) *github.Client {
-	tok := *info.Token
-	return p.githubConnectToken(tok)
+	config, err := p.getConfiguration().forInstance(info.Instance)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the configuration of the GitHub instance", "instance", info.Instance, "error", err.Error())
+		return nil
+	}
+
+	// expiring tokens are refreshed transparently.
+	tc := oauth2.NewClient(context.Background(), p.getUserTokenSource(ctx, info))
+	tc.Transport = &rateLimitTransport{base: tc.Transport, state: p.getRateLimitState(info), rejected: info.rateLimitRejected}
+	client, err := getGitHubClient(tc, config)
+	if err != nil {
+		p.client.Log.Warn("Failed to create GitHub client", "error", err.Error())
+		return nil
+	}
+
+	return client
 }
 
 func (p *Plugin) graphQLConnect(info *GitHubUserInfo) *graphql.Client {
-	conf := p.getConfiguration()
-	return graphql.NewClient(p.client.Log, p.configuration.getOrganizations, *info.Token, info.GitHubUsername, conf.GitHubOrg, conf.EnterpriseBaseURL)
+	conf, err := p.getConfiguration().forInstance(info.Instance)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the configuration of the GitHub instance", "instance", info.Instance, "error", err.Error())
+		return nil
+	}
+
+	tok, err := p.getUserTokenSource(context.Background(), info).Token()
+	if err != nil {
+		p.client.Log.Warn("Failed to get the token of the user", "userID", info.UserID, "error", err.Error())
+		tok = info.Token
+	}
+
+	return graphql.NewClient(p.client.Log, conf.getOrganizations, *tok, info.GitHubUsername, conf.GitHubOrg, conf.EnterpriseBaseURL)
 }
 
 func (p *Plugin) githubConnectToken(token oauth2.Token) *github.Client {
-	config := p.getConfiguration()
+	return p.githubConnectTokenWithConfig(token, p.getConfiguration())
+}
 
+// githubConnectTokenWithConfig returns a client of the GitHub instance the configuration is for.
+func (p *Plugin) githubConnectTokenWithConfig(token oauth2.Token, config *Configuration) *github.Client {
 	client, err := GetGitHubClient(token, config)
 	if err != nil {
 		p.client.Log.Warn("Failed to create GitHub client", "error", err.Error())
@@ -200,6 +252,13 @@ func GetGitHubClient(token oauth2.Token, config *Configuration) (*github.Client,
 }
 
 func getGitHubClient(authenticatedClient *http.Client, config *Configuration) (*github.Client, error) {
+	if _, ok := authenticatedClient.Transport.(*rateLimitTransport); !ok {
+		// the client is copied, as it can be shared, e.g. http.DefaultClient.
+		rateLimitedClient := *authenticatedClient
+		rateLimitedClient.Transport = &rateLimitTransport{base: authenticatedClient.Transport}
+		authenticatedClient = &rateLimitedClient
+	}
+
 	if config.EnterpriseBaseURL == "" || config.EnterpriseUploadURL == "" {
 		return github.NewClient(authenticatedClient), nil
 	}
@@ -293,12 +352,44 @@ func (p *Plugin) OnActivate() error {
 			p.client.Log.Debug("failed to reset user tokens", "error", resetErr.Error())
 		}
 	}()
+
+	p.tokenHealthCheckJob, err = cluster.Schedule(p.API, tokenHealthCheckJobKey, cluster.MakeWaitForRoundedInterval(tokenHealthCheckInterval), p.checkAllTokens)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the token health check job")
+	}
+
+	p.dailyReminderJob, err = cluster.Schedule(p.API, dailyReminderJobKey, cluster.MakeWaitForRoundedInterval(dailyReminderInterval), p.sendDailyReminders)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the daily reminder job")
+	}
+
+	p.inboxPollJob, err = cluster.Schedule(p.API, inboxPollJobKey, cluster.MakeWaitForRoundedInterval(inboxPollInterval), p.pollInboxes)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the inbox poll job")
+	}
+
 	return nil
 }
 
 func (p *Plugin) OnDeactivate() error {
+	p.cancelLifetime()
 	p.webhookBroker.Close()
 	p.oauthBroker.Close()
+	if p.tokenHealthCheckJob != nil {
+		if err := p.tokenHealthCheckJob.Close(); err != nil {
+			p.client.Log.Warn("Failed to close the token health check job", "error", err.Error())
+		}
+	}
+	if p.dailyReminderJob != nil {
+		if err := p.dailyReminderJob.Close(); err != nil {
+			p.client.Log.Warn("Failed to close the daily reminder job", "error", err.Error())
+		}
+	}
+	if p.inboxPollJob != nil {
+		if err := p.inboxPollJob.Close(); err != nil {
+			p.client.Log.Warn("Failed to close the inbox poll job", "error", err.Error())
+		}
+	}
 	if err := p.telemetryClient.Close(); err != nil {
 		p.client.Log.Warn("Telemetry client failed to close", "error", err.Error())
 	}
@@ -491,7 +582,7 @@ func (p *Plugin) registerChimeraURL() {
 func (p *Plugin) MessageWillBePosted(c *plugin.Context, post *model.Post) (*model.Post, string) {
 	// If not enabled in config, ignore.
 	config := p.getConfiguration()
-	if config.EnableCodePreview == "disable" {
+	if config.EnableCodePreview == "disable" && !config.EnableReferencePreview {
 		return nil, ""
 	}
 
@@ -517,23 +608,58 @@ func (p *Plugin) MessageWillBePosted(c *plugin.Context, post *model.Post) (*mode
 		}
 		return nil, ""
 	}
+
+	// the message is posted once the previews are made, so all of them share a single deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), messagePreviewTimeout)
+	defer cancel()
+
 	// TODO: make this part of the Plugin struct and reuse it.
-	ghClient := p.githubConnectUser(context.Background(), info)
+	ghClient := p.githubConnectUser(ctx, info)
+	repos := newRepositoryLookup()
+
+	if config.EnableCodePreview != "disable" {
+		msg = p.makeInstanceReplacements(ctx, msg, repos, post.UserId, post.ChannelId)
+
+		replacements := p.getReplacements(msg)
+		msg = p.makeReplacements(ctx, msg, replacements, ghClient, repos, post.ChannelId)
+		msg = p.makeDiffReplacements(ctx, msg, getDiffReplacements(msg, getGitHubHost(config.getBaseURL())), ghClient, repos, post.ChannelId)
+		msg = p.makeGistReplacements(ctx, msg, getGistReplacements(msg), ghClient, post.ChannelId)
+	}
 
-	replacements := p.getReplacements(msg)
-	post.Message = p.makeReplacements(msg, replacements, ghClient)
+	if config.EnableReferencePreview {
+		defaultRepo, err := p.getChannelDefaultRepo(post.ChannelId)
+		if err != nil {
+			p.client.Log.Warn("Error while getting the channel default repository", "error", err.Error())
+		}
+		references := getIssueReferences(msg, defaultRepo, config.getBaseURL())
+		var previews map[string]*referencePreview
+		msg, previews = p.makeReferenceReplacements(ctx, msg, references, ghClient, repos, post.UserId, post.ChannelId)
+
+		if attachments := p.getLinkPreviewAttachments(ctx, references, previews, ghClient, repos, info, post.ChannelId); len(attachments) > 0 {
+			model.ParseSlackAttachment(post, append(post.Attachments(), attachments...))
+		}
+	}
+
+	post.Message = msg
 	return post, ""
 }
 
 func (p *Plugin) getOAuthConfig(privateAllowed bool) *oauth2.Config {
-	config := p.getConfiguration()
+	return p.getOAuthConfigWithConfig(p.getConfiguration(), privateAllowed)
+}
 
+// getOAuthConfigWithConfig returns the OAuth configuration of the GitHub instance the configuration is for.
+func (p *Plugin) getOAuthConfigWithConfig(config *Configuration, privateAllowed bool) *oauth2.Config {
 	repo := github.ScopePublicRepo
 	if config.EnablePrivateRepo && privateAllowed {
 		// means that asks scope for private repositories
 		repo = github.ScopeRepo
 	}
 	scopes := []string{string(repo), string(github.ScopeNotifications), string(github.ScopeReadOrg), string(github.ScopeAdminOrgHook)}
+	if config.IsGitHubAppConfigured() {
+		// The access of user-to-server tokens is defined by the permissions of the GitHub App instead of scopes.
+		scopes = nil
+	}
 
 	if config.UsePreregisteredApplication {
 		p.client.Log.Debug("Using Chimera Proxy OAuth configuration")
@@ -548,17 +674,21 @@ func (p *Plugin) getOAuthConfig(privateAllowed bool) *oauth2.Config {
 	authURL, _ := url.Parse(baseURL)
 	tokenURL, _ := url.Parse(baseURL)
 
+	deviceAuthURL, _ := url.Parse(baseURL)
+
 	authURL.Path = path.Join(authURL.Path, "login", "oauth", "authorize")
 	tokenURL.Path = path.Join(tokenURL.Path, "login", "oauth", "access_token")
+	deviceAuthURL.Path = path.Join(deviceAuthURL.Path, "login", "device", "code")
 
 	return &oauth2.Config{
 		ClientID:     config.GitHubOAuthClientID,
 		ClientSecret: config.GitHubOAuthClientSecret,
 		Scopes:       scopes,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:   authURL.String(),
-			TokenURL:  tokenURL.String(),
-			AuthStyle: oauth2.AuthStyleInHeader,
+			AuthURL:       authURL.String(),
+			TokenURL:      tokenURL.String(),
+			DeviceAuthURL: deviceAuthURL.String(),
+			AuthStyle:     oauth2.AuthStyleInHeader,
 		},
 	}
 }
@@ -587,35 +717,50 @@ func (p *Plugin) getOAuthConfigForChimeraApp(scopes []string) *oauth2.Config {
 }
 
 type GitHubUserInfo struct {
-	UserID              string
-	Token               *oauth2.Token
-	GitHubUsername      string
-	LastToDoPostAt      int64
-	Settings            *UserSettings
+	UserID         string
+	Token          *oauth2.Token
+	GitHubUsername string
+	LastToDoPostAt int64
+	Settings       *UserSettings
+	// LastReminderAt is when the daily reminder was last handled, even if there was nothing to post.
+	LastReminderAt      int64
 	AllowedPrivateRepos bool
 
+	// Instance is the name of the additional GitHub instance the account belongs to, empty for the default instance.
+	Instance string
+
 	// MM34646ResetTokenDone is set for a user whose token has been reset for MM-34646.
 	MM34646ResetTokenDone bool
+
+	// rateLimitRejected is set when a request of the account isn't sent because it's rate limited. It's only
+	// tracked while a slash command runs.
+	rateLimitRejected *atomic.Bool
 }
 
 type UserSettings struct {
-	SidebarButtons        string `json:"sidebar_buttons"`
-	DailyReminder         bool   `json:"daily_reminder"`
-	DailyReminderOnChange bool   `json:"daily_reminder_on_change"`
-	Notifications         bool   `json:"notifications"`
+	SidebarButtons        string   `json:"sidebar_buttons"`
+	DailyReminder         bool     `json:"daily_reminder"`
+	DailyReminderOnChange bool     `json:"daily_reminder_on_change"`
+	ReminderTime          string   `json:"reminder_time,omitempty"`
+	ReminderDays          string   `json:"reminder_days,omitempty"`
+	Notifications         bool     `json:"notifications"`
+	ToDoSections          []string `json:"todo_sections,omitempty"`
+	ToDoOrgs              []string `json:"todo_orgs,omitempty"`
+	ToDoRepos             []string `json:"todo_repos,omitempty"`
+	ToDoLabels            []string `json:"todo_labels,omitempty"`
+	InboxNotifications    bool     `json:"inbox_notifications"`
+	InboxReasons          []string `json:"inbox_reasons,omitempty"`
+	InboxRepos            []string `json:"inbox_repos,omitempty"`
 }
 
 func (p *Plugin) storeGitHubUserInfo(info *GitHubUserInfo) error {
 	config := p.getConfiguration()
 
-	encryptedToken, err := encrypt([]byte(config.EncryptionKey), info.Token.AccessToken)
-	if err != nil {
-		return errors.Wrap(err, "error occurred while encrypting access token")
+	if err := encryptUserInfoTokens(info, config.EncryptionKey); err != nil {
+		return err
 	}
 
-	info.Token.AccessToken = encryptedToken
-
-	if _, err := p.store.Set(info.UserID+githubTokenKey, info); err != nil {
+	if _, err := p.store.Set(getUserInfoKey(info.UserID, info.Instance), info); err != nil {
 		return errors.Wrap(err, "error occurred while trying to store user info into KV store")
 	}
 
@@ -623,30 +768,73 @@ func (p *Plugin) storeGitHubUserInfo(info *GitHubUserInfo) error {
 }
 
 func (p *Plugin) getGitHubUserInfo(userID string) (*GitHubUserInfo, *APIErrorResponse) {
+	return p.getGitHubUserInfoForInstance(userID, "")
+}
+
+// getGitHubUserInfoForInstance returns the account of the user on a GitHub instance.
+func (p *Plugin) getGitHubUserInfoForInstance(userID, instance string) (*GitHubUserInfo, *APIErrorResponse) {
 	config := p.getConfiguration()
 
-	var userInfo *GitHubUserInfo
-	err := p.store.Get(userID+githubTokenKey, &userInfo)
+	if _, err := config.forInstance(instance); err != nil {
+		return nil, &APIErrorResponse{ID: apiErrorIDNotConnected, Message: "The GitHub instance " + instance + " isn't configured.", StatusCode: http.StatusBadRequest}
+	}
+
+	// the stored value is kept to re-encrypt the tokens only if they weren't changed in the meantime.
+	var data []byte
+	err := p.store.Get(getUserInfoKey(userID, instance), &data)
 	if err != nil {
 		return nil, &APIErrorResponse{ID: "", Message: "Unable to get user info.", StatusCode: http.StatusInternalServerError}
 	}
+
+	var userInfo *GitHubUserInfo
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &userInfo); err != nil {
+			return nil, &APIErrorResponse{ID: "", Message: "Unable to get user info.", StatusCode: http.StatusInternalServerError}
+		}
+	}
 	if userInfo == nil {
 		return nil, &APIErrorResponse{ID: apiErrorIDNotConnected, Message: "Must connect user account to GitHub first.", StatusCode: http.StatusBadRequest}
 	}
 
-	unencryptedToken, err := decrypt([]byte(config.EncryptionKey), userInfo.Token.AccessToken)
+	reencrypt, err := decryptUserInfoTokens(userInfo, config.EncryptionKey, config.PreviousEncryptionKey)
 	if err != nil {
-		p.client.Log.Warn("Failed to decrypt access token", "error", err.Error())
+		p.client.Log.Warn("Failed to decrypt token", "error", err.Error())
 		return nil, &APIErrorResponse{ID: "", Message: "Unable to decrypt access token.", StatusCode: http.StatusInternalServerError}
 	}
 
-	userInfo.Token.AccessToken = unencryptedToken
+	if reencrypt {
+		if err := p.reencryptGitHubUserInfo(getUserInfoKey(userID, instance), data, userInfo, config.EncryptionKey); err != nil {
+			p.client.Log.Warn("Failed to re-encrypt token", "userID", userID, "error", err.Error())
+		}
+	}
 
 	return userInfo, nil
 }
 
-func (p *Plugin) storeGitHubToUserIDMapping(githubUsername, userID string) error {
-	_, err := p.store.Set(githubUsername+githubUsernameKey, []byte(userID))
+// listUserInfoKeys returns the keys storing the accounts of the connected users. All the keys are listed first,
+// as changing the accounts while paging would skip keys.
+func (p *Plugin) listUserInfoKeys() ([]string, error) {
+	checker := func(key string) (keep bool, err error) {
+		return strings.HasSuffix(key, githubTokenKey), nil
+	}
+
+	var keys []string
+	for page := 0; ; page++ {
+		pageKeys, err := p.store.ListKeys(page, keysPerPage, pluginapi.WithChecker(checker))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list keys - page, %d", page)
+		}
+
+		keys = append(keys, pageKeys...)
+
+		if len(pageKeys) < keysPerPage {
+			return keys, nil
+		}
+	}
+}
+
+func (p *Plugin) storeGitHubToUserIDMapping(githubUsername, instance, userID string) error {
+	_, err := p.store.Set(getGitHubUsernameKey(githubUsername, instance), []byte(userID))
 	if err != nil {
 		return errors.Wrap(err, "encountered error saving github username mapping")
 	}
@@ -655,8 +843,13 @@ func (p *Plugin) storeGitHubToUserIDMapping(githubUsername, userID string) error
 }
 
 func (p *Plugin) getGitHubToUserIDMapping(githubUsername string) string {
+	return p.getGitHubToUserIDMappingForInstance(githubUsername, "")
+}
+
+// getGitHubToUserIDMappingForInstance returns the Mattermost user connected to the GitHub username on a GitHub instance.
+func (p *Plugin) getGitHubToUserIDMappingForInstance(githubUsername, instance string) string {
 	var data []byte
-	err := p.store.Get(githubUsername+githubUsernameKey, &data)
+	err := p.store.Get(getGitHubUsernameKey(githubUsername, instance), &data)
 	if err != nil {
 		p.client.Log.Warn("Error occurred while getting the user ID from KV store using the Github username", "error", err.Error())
 		return ""
@@ -685,7 +878,7 @@ func (p *Plugin) disconnectGitHubAccount(userID string) {
 		p.client.Log.Warn("Failed to delete github token from KV store", "userID", userID, "error", err.Error())
 	}
 
-	if err := p.store.Delete(userInfo.GitHubUsername + githubUsernameKey); err != nil {
+	if err := p.store.Delete(getGitHubUsernameKey(userInfo.GitHubUsername, "")); err != nil {
 		p.client.Log.Warn("Failed to delete github token from KV store", "userID", userID, "error", err.Error())
 	}
 
@@ -710,12 +903,47 @@ func (p *Plugin) disconnectGitHubAccount(userID string) {
 	)
 }
 
+// disconnectGitHubAccountForInstance disconnects the account of a user on a GitHub instance.
+func (p *Plugin) disconnectGitHubAccountForInstance(userID, instance string) error {
+	if instance == "" {
+		p.disconnectGitHubAccount(userID)
+		return nil
+	}
+
+	if info, _ := p.getGitHubUserInfoForInstance(userID, instance); info != nil {
+		if err := p.store.Delete(getGitHubUsernameKey(info.GitHubUsername, instance)); err != nil {
+			p.client.Log.Warn("Failed to delete the GitHub username mapping", "userID", userID, "instance", instance, "error", err.Error())
+		}
+	}
+
+	if err := p.store.Delete(getUserInfoKey(userID, instance)); err != nil {
+		return errors.Wrap(err, "failed to delete the GitHub account")
+	}
+
+	// the webapp only tracks the connection of the default instance.
+	p.client.Frontend.PublishWebSocketEvent(
+		wsEventDisconnect,
+		map[string]interface{}{
+			"instance": instance,
+		},
+		&model.WebsocketBroadcast{UserId: userID},
+	)
+
+	return nil
+}
+
 func (p *Plugin) openIssueCreateModal(userID string, channelID string, title string) {
+	repo, err := p.getChannelDefaultRepo(channelID)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the channel default repository", "channelID", channelID, "error", err.Error())
+	}
+
 	p.client.Frontend.PublishWebSocketEvent(
 		wsEventCreateIssue,
 		map[string]interface{}{
 			"title":      title,
 			"channel_id": channelID,
+			"repo":       repo,
 		},
 		&model.WebsocketBroadcast{UserId: userID},
 	)
@@ -774,13 +1002,18 @@ func (p *Plugin) GetDailySummaryText(userID string) (string, error) {
 	return string(summaryByte), nil
 }
 
-func (p *Plugin) PostToDo(info *GitHubUserInfo, userID string) error {
-	ctx := context.Background()
-	text, err := p.GetToDo(ctx, info.GitHubUsername, p.githubConnectUser(ctx, info))
+// PostToDo sends the todo list to the user, unless it's empty or, for users only reminded of changes,
+// unchanged since the previous reminder.
+func (p *Plugin) PostToDo(ctx context.Context, info *GitHubUserInfo, userID string) error {
+	results, err := p.getToDoSectionResults(ctx, info)
 	if err != nil {
 		return err
 	}
+	if !hasToDoItems(results) {
+		return nil
+	}
 
+	text := renderToDo(results)
 	if info.Settings.DailyReminderOnChange {
 		isSameSummary, err := p.CheckIfDuplicateDailySummary(userID, text)
 		if err != nil {
@@ -798,190 +1031,17 @@ func (p *Plugin) PostToDo(info *GitHubUserInfo, userID string) error {
 	return nil
 }
 
-func (p *Plugin) GetToDo(ctx context.Context, username string, githubClient *github.Client) (string, error) {
-	config := p.getConfiguration()
-	baseURL := config.getBaseURL()
-	orgList := p.configuration.getOrganizations()
-	issueResults, _, err := githubClient.Search.Issues(ctx, getReviewSearchQuery(username, orgList), &github.SearchOptions{})
+func (p *Plugin) GetToDo(ctx context.Context, info *GitHubUserInfo) (string, error) {
+	results, err := p.getToDoSectionResults(ctx, info)
 	if err != nil {
-		return "", errors.Wrap(err, "Error occurred while searching for reviews")
-	}
-
-	notifications, _, err := githubClient.Activity.ListNotifications(ctx, &github.NotificationListOptions{})
-	if err != nil {
-		return "", errors.Wrap(err, "error occurred while listing notifications")
-	}
-
-	yourPrs, _, err := githubClient.Search.Issues(ctx, getYourPrsSearchQuery(username, orgList), &github.SearchOptions{})
-	if err != nil {
-		return "", errors.Wrap(err, "error occurred while searching for PRs")
-	}
-
-	yourAssignments, _, err := githubClient.Search.Issues(ctx, getYourAssigneeSearchQuery(username, orgList), &github.SearchOptions{})
-	if err != nil {
-		return "", errors.Wrap(err, "error occurred while searching for assignments")
-	}
-
-	text := "##### Unread Messages\n"
-
-	notificationCount := 0
-	notificationContent := ""
-	for _, n := range notifications {
-		if n.GetReason() == notificationReasonSubscribed {
-			continue
-		}
-
-		if n.GetRepository() == nil {
-			p.client.Log.Warn("Unable to get repository for notification in todo list. Skipping.")
-			continue
-		}
-
-		if p.checkOrg(n.GetRepository().GetOwner().GetLogin()) != nil {
-			continue
-		}
-
-		notificationSubject := n.GetSubject()
-		notificationType := notificationSubject.GetType()
-		switch notificationType {
-		case "RepositoryVulnerabilityAlert":
-			message := fmt.Sprintf("[Vulnerability Alert for %v](%v)", n.GetRepository().GetFullName(), fixGithubNotificationSubjectURL(n.GetSubject().GetURL(), ""))
-			notificationContent += fmt.Sprintf("* %v\n", message)
-		default:
-			issueURL := n.GetSubject().GetURL()
-			issueNumIndex := strings.LastIndex(issueURL, "/")
-			issueNum := issueURL[issueNumIndex+1:]
-			subjectURL := n.GetSubject().GetURL()
-			if n.GetSubject().GetLatestCommentURL() != "" {
-				subjectURL = n.GetSubject().GetLatestCommentURL()
-			}
-
-			notificationTitle := notificationSubject.GetTitle()
-			notificationURL := fixGithubNotificationSubjectURL(subjectURL, issueNum)
-			notificationContent += getToDoDisplayText(baseURL, notificationTitle, notificationURL, notificationType, n.GetRepository())
-		}
-
-		notificationCount++
-	}
-
-	if notificationCount == 0 {
-		text += "You don't have any unread messages.\n"
-	} else {
-		text += fmt.Sprintf("You have %v unread messages:\n", notificationCount)
-		text += notificationContent
-	}
-
-	text += "##### Review Requests\n"
-
-	if issueResults.GetTotal() == 0 {
-		text += "You don't have any pull requests awaiting your review.\n"
-	} else {
-		text += fmt.Sprintf("You have %v pull requests awaiting your review:\n", issueResults.GetTotal())
-
-		for _, pr := range issueResults.Issues {
-			text += getToDoDisplayText(baseURL, pr.GetTitle(), pr.GetHTMLURL(), "", nil)
-		}
-	}
-
-	text += "##### Your Open Pull Requests\n"
-
-	if yourPrs.GetTotal() == 0 {
-		text += "You don't have any open pull requests.\n"
-	} else {
-		text += fmt.Sprintf("You have %v open pull requests:\n", yourPrs.GetTotal())
-
-		for _, pr := range yourPrs.Issues {
-			text += getToDoDisplayText(baseURL, pr.GetTitle(), pr.GetHTMLURL(), "", nil)
-		}
-	}
-
-	text += "##### Your Assignments\n"
-
-	if yourAssignments.GetTotal() == 0 {
-		text += "You don't have any assignments.\n"
-	} else {
-		text += fmt.Sprintf("You have %v assignments:\n", yourAssignments.GetTotal())
-
-		for _, assign := range yourAssignments.Issues {
-			text += getToDoDisplayText(baseURL, assign.GetTitle(), assign.GetHTMLURL(), "", nil)
-		}
-	}
-
-	return text, nil
-}
-
-func (p *Plugin) HasUnreads(info *GitHubUserInfo) bool {
-	username := info.GitHubUsername
-	ctx := context.Background()
-	githubClient := p.githubConnectUser(ctx, info)
-	orgList := p.configuration.getOrganizations()
-	query := getReviewSearchQuery(username, orgList)
-	issues, _, err := githubClient.Search.Issues(ctx, query, &github.SearchOptions{})
-	if err != nil {
-		p.client.Log.Warn("Failed to search for review", "query", query, "error", err.Error())
-		return false
-	}
-
-	query = getYourPrsSearchQuery(username, orgList)
-	yourPrs, _, err := githubClient.Search.Issues(ctx, query, &github.SearchOptions{})
-	if err != nil {
-		p.client.Log.Warn("Failed to search for PRs", "query", query, "error", "error", err.Error())
-		return false
-	}
-
-	query = getYourAssigneeSearchQuery(username, orgList)
-	yourAssignments, _, err := githubClient.Search.Issues(ctx, query, &github.SearchOptions{})
-	if err != nil {
-		p.client.Log.Warn("Failed to search for assignments", "query", query, "error", "error", err.Error())
-		return false
-	}
-
-	relevantNotifications := false
-	notifications, _, err := githubClient.Activity.ListNotifications(ctx, &github.NotificationListOptions{})
-	if err != nil {
-		p.client.Log.Warn("Failed to list notifications", "error", err.Error())
-		return false
-	}
-
-	for _, n := range notifications {
-		if n.GetReason() == notificationReasonSubscribed {
-			continue
-		}
-
-		if n.GetRepository() == nil {
-			p.client.Log.Warn("Unable to get repository for notification in todo list. Skipping.")
-			continue
-		}
-
-		if p.checkOrg(n.GetRepository().GetOwner().GetLogin()) != nil {
-			continue
-		}
-
-		relevantNotifications = true
-		break
-	}
-
-	if issues.GetTotal() == 0 && !relevantNotifications && yourPrs.GetTotal() == 0 && yourAssignments.GetTotal() == 0 {
-		return false
+		return "", err
 	}
 
-	return true
+	return renderToDo(results), nil
 }
 
 func (p *Plugin) checkOrg(org string) error {
-	config := p.getConfiguration()
-
-	orgList := config.getOrganizations()
-	if len(orgList) == 0 {
-		return nil
-	}
-
-	for _, configOrg := range orgList {
-		if configOrg == strings.ToLower(org) {
-			return nil
-		}
-	}
-
-	return errors.Errorf("only repositories in the %v organization(s) are supported", config.GitHubOrg)
+	return p.getConfiguration().checkOrg(org)
 }
 
 func (p *Plugin) isUserOrganizationMember(githubClient *github.Client, user *github.User, organization string) bool {
@@ -998,13 +1058,6 @@ func (p *Plugin) isUserOrganizationMember(githubClient *github.Client, user *git
 	return isMember
 }
 
-func (p *Plugin) isOrganizationLocked() bool {
-	config := p.getConfiguration()
-	configOrg := strings.TrimSpace(config.GitHubOrg)
-
-	return configOrg != ""
-}
-
 func (p *Plugin) sendRefreshEvent(userID string) {
 	eventLogger := logger.New(p.API).With(logger.LogContext{
 		"userid": userID,
@@ -0,0 +1,296 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/bot/logger"
+)
+
+const (
+	postActionAssignMe        = "assign_me"
+	postActionAddLabel        = "add_label"
+	postActionRequestReviewMe = "request_review_me"
+	postActionClose           = "close"
+	postActionApprove         = "approve"
+
+	postActionLabelDialogCallbackID = "add_label"
+)
+
+// postActionContext holds the data attached to the interactive buttons of a notification post.
+type postActionContext struct {
+	Action        string `json:"action"`
+	Repo          string `json:"repo"`
+	Number        int    `json:"number"`
+	IsPullRequest bool   `json:"is_pull_request"`
+	// Instance is the GitHub instance that sent the notification, empty for the default one.
+	Instance string `json:"instance,omitempty"`
+}
+
+func (c *postActionContext) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"action":          c.Action,
+		"repo":            c.Repo,
+		"number":          c.Number,
+		"is_pull_request": c.IsPullRequest,
+		"instance":        c.Instance,
+	}
+}
+
+func parsePostActionContext(m map[string]interface{}) (*postActionContext, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var actionContext postActionContext
+	if err = json.Unmarshal(b, &actionContext); err != nil {
+		return nil, err
+	}
+
+	if actionContext.Action == "" || actionContext.Number == 0 {
+		return nil, errors.New("missing action or number")
+	}
+
+	if _, _, err = parseRepo(actionContext.Repo); err != nil {
+		return nil, err
+	}
+
+	return &actionContext, nil
+}
+
+func getPostActionURL(path string) string {
+	return fmt.Sprintf("/plugins/%s/api/v1%s", Manifest.Id, path)
+}
+
+// getPostActionAttachment returns the attachment with the triage buttons shown below new pull request and issue posts.
+func getPostActionAttachment(repo string, number int, isPullRequest bool, instance string) *model.SlackAttachment {
+	newAction := func(name, action string) *model.PostAction {
+		actionContext := &postActionContext{
+			Action:        action,
+			Repo:          repo,
+			Number:        number,
+			IsPullRequest: isPullRequest,
+			Instance:      instance,
+		}
+
+		return &model.PostAction{
+			Id:   strings.ReplaceAll(action, "_", ""),
+			Name: name,
+			Type: model.PostActionTypeButton,
+			Integration: &model.PostActionIntegration{
+				URL:     getPostActionURL("/post-action"),
+				Context: actionContext.toMap(),
+			},
+		}
+	}
+
+	actions := []*model.PostAction{
+		newAction("Assign to me", postActionAssignMe),
+		newAction("Add label", postActionAddLabel),
+	}
+
+	if isPullRequest {
+		actions = append(actions,
+			newAction("Request review from me", postActionRequestReviewMe),
+			newAction("Approve", postActionApprove),
+		)
+	}
+
+	actions = append(actions, newAction("Close", postActionClose))
+
+	return &model.SlackAttachment{
+		Actions: actions,
+	}
+}
+
+func (p *Plugin) addPostActions(post *model.Post, repo string, number int, isPullRequest bool, instance string) {
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{getPostActionAttachment(repo, number, isPullRequest, instance)})
+}
+
+// getActionFailReason turns an error returned by GitHub into a message that can be shown to the user.
+func getActionFailReason(err error, repo, username string) string {
+	var gerr *github.ErrorResponse
+	if !errors.As(err, &gerr) || gerr.Response == nil {
+		return err.Error()
+	}
+
+	switch gerr.Response.StatusCode {
+	case http.StatusForbidden:
+		return fmt.Sprintf("Sorry, you don't have enough permissions to do this action in the repo %s with the user %s", repo, username)
+	case http.StatusUnprocessableEntity:
+		return gerr.Message
+	default:
+		return getFailReason(gerr.Response.StatusCode, repo, username)
+	}
+}
+
+func (p *Plugin) handlePostAction(c *Context, w http.ResponseWriter, r *http.Request) {
+	response := &model.PostActionIntegrationResponse{}
+	defer func() {
+		p.writeJSON(w, response)
+	}()
+
+	var req model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.Log.WithError(err).Warnf("Error decoding PostActionIntegrationRequest JSON body")
+		response.EphemeralText = "Invalid request."
+		return
+	}
+
+	actionContext, err := parsePostActionContext(req.Context)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Invalid post action context")
+		response.EphemeralText = "Invalid request."
+		return
+	}
+
+	// the action is done with the account of the user on the GitHub instance that sent the notification.
+	info, apiErr := p.getGitHubUserInfoForInstance(c.UserID, actionContext.Instance)
+	if apiErr != nil {
+		if apiErr.ID == apiErrorIDNotConnected {
+			response.EphemeralText = "You must connect your account to GitHub first. Either click on the GitHub logo in the bottom left of the screen or enter `/github connect`."
+		} else {
+			response.EphemeralText = "Failed to get your GitHub account information."
+		}
+		return
+	}
+
+	config, err := p.getConfiguration().forInstance(info.Instance)
+	if err != nil {
+		c.Log.WithError(err).Warnf("Failed to get the configuration of the GitHub instance")
+		response.EphemeralText = "Failed to get your GitHub account information."
+		return
+	}
+
+	owner, repo, _ := parseRepo(actionContext.Repo)
+	githubClient := p.githubConnectUser(c.Ctx, info)
+	login := info.GitHubUsername
+	objectName, objectPath := "issue", "issues"
+	if actionContext.IsPullRequest {
+		objectName, objectPath = "pull request", "pull"
+	}
+	objectLink := fmt.Sprintf("[%s#%d](%s%s/%s/%d)", actionContext.Repo, actionContext.Number, config.getBaseURL(), actionContext.Repo, objectPath, actionContext.Number)
+
+	switch actionContext.Action {
+	case postActionAssignMe:
+		_, _, err = githubClient.Issues.AddAssignees(c.Ctx, owner, repo, actionContext.Number, []string{login})
+		response.EphemeralText = fmt.Sprintf("You have been assigned to the %s %s.", objectName, objectLink)
+	case postActionRequestReviewMe:
+		_, _, err = githubClient.PullRequests.RequestReviewers(c.Ctx, owner, repo, actionContext.Number, github.ReviewersRequest{Reviewers: []string{login}})
+		response.EphemeralText = fmt.Sprintf("Your review has been requested on the pull request %s.", objectLink)
+	case postActionApprove:
+		_, _, err = githubClient.PullRequests.CreateReview(c.Ctx, owner, repo, actionContext.Number, &github.PullRequestReviewRequest{Event: github.String("APPROVE")})
+		response.EphemeralText = fmt.Sprintf("You approved the pull request %s.", objectLink)
+	case postActionClose:
+		_, _, err = githubClient.Issues.Edit(c.Ctx, owner, repo, actionContext.Number, &github.IssueRequest{State: github.String("closed")})
+		response.EphemeralText = fmt.Sprintf("You closed the %s %s.", objectName, objectLink)
+	case postActionAddLabel:
+		err = p.openAddLabelDialog(c, githubClient, req.TriggerId, actionContext)
+	default:
+		response.EphemeralText = fmt.Sprintf("Unknown action %s.", actionContext.Action)
+		return
+	}
+
+	if err != nil {
+		c.Log.WithError(err).With(logger.LogContext{"action": actionContext.Action}).Warnf("Failed to perform post action")
+		response.EphemeralText = fmt.Sprintf("Failed to perform the action on %s: %s", objectLink, getActionFailReason(err, actionContext.Repo, login))
+		return
+	}
+
+	p.TrackUserEvent("post_action", c.UserID, map[string]interface{}{"action": actionContext.Action})
+}
+
+func (p *Plugin) openAddLabelDialog(c *Context, githubClient *github.Client, triggerID string, actionContext *postActionContext) error {
+	owner, repo, _ := parseRepo(actionContext.Repo)
+
+	labels, err := listRepoLabels(c.Ctx, githubClient, owner, repo)
+	if err != nil {
+		return errors.Wrap(err, "failed to list labels")
+	}
+
+	var options []*model.PostActionOptions
+	for _, label := range labels {
+		options = append(options, &model.PostActionOptions{Text: label.GetName(), Value: label.GetName()})
+	}
+
+	if len(options) == 0 {
+		return errors.Errorf("the repository %s has no labels", actionContext.Repo)
+	}
+
+	state, err := json.Marshal(actionContext)
+	if err != nil {
+		return err
+	}
+
+	return p.client.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: triggerID,
+		URL:       getPostActionURL("/post-action/label"),
+		Dialog: model.Dialog{
+			CallbackId:  postActionLabelDialogCallbackID,
+			Title:       fmt.Sprintf("Add a label to %s#%d", actionContext.Repo, actionContext.Number),
+			SubmitLabel: "Add",
+			State:       string(state),
+			Elements: []model.DialogElement{{
+				DisplayName: "Label",
+				Name:        "label",
+				Type:        "select",
+				Options:     options,
+			}},
+		},
+	})
+}
+
+func (p *Plugin) handlePostActionAddLabel(c *Context, w http.ResponseWriter, r *http.Request) {
+	var req model.SubmitDialogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.Log.WithError(err).Warnf("Error decoding SubmitDialogRequest JSON body")
+		p.writeAPIError(w, &APIErrorResponse{ID: "", Message: "Please provide a JSON object.", StatusCode: http.StatusBadRequest})
+		return
+	}
+
+	var actionContext postActionContext
+	if err := json.Unmarshal([]byte(req.State), &actionContext); err != nil {
+		p.writeJSON(w, &model.SubmitDialogResponse{Error: "Invalid dialog state."})
+		return
+	}
+
+	owner, repo, err := parseRepo(actionContext.Repo)
+	if err != nil {
+		p.writeJSON(w, &model.SubmitDialogResponse{Error: err.Error()})
+		return
+	}
+
+	label, _ := req.Submission["label"].(string)
+	if label == "" {
+		p.writeJSON(w, &model.SubmitDialogResponse{Errors: map[string]string{"label": "Please select a label."}})
+		return
+	}
+
+	info, apiErr := p.getGitHubUserInfoForInstance(c.UserID, actionContext.Instance)
+	if apiErr != nil {
+		p.writeJSON(w, &model.SubmitDialogResponse{Error: apiErr.Message})
+		return
+	}
+
+	githubClient := p.githubConnectUser(c.Ctx, info)
+	if _, _, err = githubClient.Issues.AddLabelsToIssue(c.Ctx, owner, repo, actionContext.Number, []string{label}); err != nil {
+		c.Log.WithError(err).Warnf("Failed to add label")
+		p.writeJSON(w, &model.SubmitDialogResponse{Error: "Failed to add the label: " + getActionFailReason(err, actionContext.Repo, info.GitHubUsername)})
+		return
+	}
+
+	p.client.Post.SendEphemeralPost(c.UserID, &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: req.ChannelId,
+		Message:   fmt.Sprintf("Added the label `%s` to %s#%d.", label, actionContext.Repo, actionContext.Number),
+	})
+
+	p.writeJSON(w, &model.SubmitDialogResponse{})
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePostActionContext(t *testing.T) {
+	tcs := []struct {
+		name        string
+		input       map[string]interface{}
+		expected    *postActionContext
+		expectError bool
+	}{
+		{
+			name: "valid context",
+			input: map[string]interface{}{
+				"action":          postActionApprove,
+				"repo":            "mattermost/mattermost-server",
+				"number":          float64(42),
+				"is_pull_request": true,
+			},
+			expected: &postActionContext{
+				Action:        postActionApprove,
+				Repo:          "mattermost/mattermost-server",
+				Number:        42,
+				IsPullRequest: true,
+			},
+		},
+		{
+			name: "valid context of an instance",
+			input: map[string]interface{}{
+				"action":          postActionClose,
+				"repo":            "mattermost/mattermost-server",
+				"number":          float64(42),
+				"is_pull_request": false,
+				"instance":        "ghes",
+			},
+			expected: &postActionContext{
+				Action:   postActionClose,
+				Repo:     "mattermost/mattermost-server",
+				Number:   42,
+				Instance: "ghes",
+			},
+		},
+		{
+			name: "missing action",
+			input: map[string]interface{}{
+				"repo":   "mattermost/mattermost-server",
+				"number": float64(42),
+			},
+			expectError: true,
+		},
+		{
+			name: "missing number",
+			input: map[string]interface{}{
+				"action": postActionClose,
+				"repo":   "mattermost/mattermost-server",
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid repository",
+			input: map[string]interface{}{
+				"action": postActionClose,
+				"repo":   "mattermost",
+				"number": float64(42),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actionContext, err := parsePostActionContext(tc.input)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actionContext)
+		})
+	}
+}
+
+func TestGetPostActionAttachment(t *testing.T) {
+	getActionNames := func(isPullRequest bool) []string {
+		attachment := getPostActionAttachment("mattermost/mattermost-server", 42, isPullRequest, "ghes")
+		names := []string{}
+		for _, action := range attachment.Actions {
+			names = append(names, action.Name)
+
+			actionContext, err := parsePostActionContext(action.Integration.Context)
+			require.NoError(t, err)
+			assert.Equal(t, 42, actionContext.Number)
+			assert.Equal(t, isPullRequest, actionContext.IsPullRequest)
+			assert.Equal(t, "ghes", actionContext.Instance)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Assign to me", "Add label", "Close"}, getActionNames(false))
+	assert.Equal(t, []string{"Assign to me", "Add label", "Request review from me", "Approve", "Close"}, getActionNames(true))
+}
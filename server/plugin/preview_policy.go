@@ -0,0 +1,215 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	// privatePreviewPolicyAnyChannel previews private content in any channel.
+	privatePreviewPolicyAnyChannel = "anyChannel"
+	// privatePreviewPolicyPrivateChannels only previews private content in private channels and direct messages.
+	privatePreviewPolicyPrivateChannels = "privateChannels"
+	// privatePreviewPolicyConnectedMembers only previews private content in channels whose members
+	// are all connected to GitHub with access to the repository.
+	privatePreviewPolicyConnectedMembers = "connectedMembers"
+)
+
+// maxPreviewAudienceSize sets the maximum number of channel members checked by the
+// connectedMembers policy. Previews are suppressed in larger channels.
+const maxPreviewAudienceSize = 25
+
+const repositoryAccessKeyPrefix = "_repoaccess_"
+
+// repositoryAccessTTL is the time the access of a user to a repository is kept in the KV store,
+// so the connectedMembers policy doesn't check every member each time a link is posted.
+const repositoryAccessTTL = 5 * time.Minute
+
+// repositoryLookup keeps the repositories fetched with the token of the user posting a message, so each
+// repository is only fetched once by all the previews of the message. A nil lookup fetches them every time.
+type repositoryLookup struct {
+	results map[string]repositoryLookupResult
+}
+
+type repositoryLookupResult struct {
+	repository *github.Repository
+	err        error
+}
+
+func newRepositoryLookup() *repositoryLookup {
+	return &repositoryLookup{
+		results: map[string]repositoryLookupResult{},
+	}
+}
+
+// get returns the repository, fetching it with the given client the first time it's needed.
+func (l *repositoryLookup) get(ctx context.Context, ghClient *github.Client, owner, repo string) (*github.Repository, error) {
+	if l == nil {
+		repository, _, err := ghClient.Repositories.Get(ctx, owner, repo)
+		return repository, err
+	}
+
+	// the additional GitHub instances have their own repositories.
+	key := ghClient.BaseURL.Host + "/" + strings.ToLower(fullNameFromOwnerAndRepo(owner, repo))
+	if result, ok := l.results[key]; ok {
+		return result.repository, result.err
+	}
+
+	repository, _, err := ghClient.Repositories.Get(ctx, owner, repo)
+	l.results[key] = repositoryLookupResult{repository: repository, err: err}
+
+	return repository, err
+}
+
+// isCodePreviewAllowed checks if the code of the repository can be previewed in messages posted in the channel.
+// Public repositories are always previewed, private ones according to the configured policy. userCanAccess is
+// true if the access of the user to the repository was checked on the way.
+func (p *Plugin) isCodePreviewAllowed(ctx context.Context, ghClient *github.Client, repos *repositoryLookup, channelID, owner, repo string) (allowed, userCanAccess bool) {
+	config := p.getConfiguration()
+	if config.EnableCodePreview == "privateAndPublic" && config.getPrivatePreviewPolicy() == privatePreviewPolicyAnyChannel {
+		return true, false
+	}
+
+	repository, err := repos.get(ctx, ghClient, owner, repo)
+	if err != nil {
+		p.client.Log.Warn("Error while fetching repository information",
+			"error", err.Error(),
+			"repo", repo,
+			"user", owner)
+		return false, false
+	}
+
+	if !repository.GetPrivate() {
+		return true, true
+	}
+
+	return p.isPrivatePreviewAllowed(ctx, channelID, owner, repo), true
+}
+
+// isPrivatePreviewAllowed checks if content of a private repository can be previewed in the channel.
+// If repo is empty, the content isn't tied to a repository, e.g. a secret gist, and only the channel audience is checked.
+func (p *Plugin) isPrivatePreviewAllowed(ctx context.Context, channelID, owner, repo string) bool {
+	config := p.getConfiguration()
+	if config.EnableCodePreview != "privateAndPublic" {
+		return false
+	}
+
+	policy := config.getPrivatePreviewPolicy()
+	var allowed bool
+	var reason string
+	switch policy {
+	case privatePreviewPolicyPrivateChannels:
+		allowed, reason = p.isPrivateChannel(channelID)
+	case privatePreviewPolicyConnectedMembers:
+		allowed, reason = p.haveMembersAccess(ctx, channelID, owner, repo)
+	default:
+		return true
+	}
+
+	if !allowed {
+		source := "secret gist"
+		if repo != "" {
+			source = fullNameFromOwnerAndRepo(owner, repo)
+		}
+		p.client.Log.Info("Suppressed the preview of private content",
+			"channel_id", channelID,
+			"source", source,
+			"policy", policy,
+			"reason", reason)
+	}
+
+	return allowed
+}
+
+func (p *Plugin) isPrivateChannel(channelID string) (bool, string) {
+	channel, err := p.client.Channel.Get(channelID)
+	if err != nil {
+		return false, "failed to get the channel: " + err.Error()
+	}
+
+	switch channel.Type {
+	case model.ChannelTypePrivate, model.ChannelTypeDirect, model.ChannelTypeGroup:
+		return true, ""
+	default:
+		return false, "the channel is public"
+	}
+}
+
+// haveMembersAccess checks that all the members of the channel are connected to GitHub and can access the repository.
+func (p *Plugin) haveMembersAccess(ctx context.Context, channelID, owner, repo string) (bool, string) {
+	members, err := p.client.Channel.ListMembers(channelID, 0, maxPreviewAudienceSize+1)
+	if err != nil {
+		return false, "failed to list the channel members: " + err.Error()
+	}
+	if len(members) > maxPreviewAudienceSize {
+		return false, "the channel has too many members to check their access"
+	}
+
+	for _, member := range members {
+		if member.UserId == p.BotUserID {
+			continue
+		}
+
+		info, apiErr := p.getGitHubUserInfo(member.UserId)
+		if apiErr != nil {
+			user, err := p.client.User.Get(member.UserId)
+			if err == nil && user.IsBot {
+				continue
+			}
+			return false, "a channel member is not connected to GitHub"
+		}
+
+		if repo == "" {
+			continue
+		}
+
+		if !p.canAccessRepository(ctx, info, owner, repo) {
+			return false, "a channel member can't access the repository"
+		}
+	}
+
+	return true, ""
+}
+
+func getRepositoryAccessKey(userID, owner, repo string) string {
+	hash := sha256.Sum256([]byte(userID + "\n" + strings.ToLower(fullNameFromOwnerAndRepo(owner, repo))))
+	return repositoryAccessKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+// canAccessRepository checks if the user can access the repository with their token. The result is kept
+// in the KV store for a while, as the members of a channel are checked for every private link posted in it.
+func (p *Plugin) canAccessRepository(ctx context.Context, info *GitHubUserInfo, owner, repo string) bool {
+	key := getRepositoryAccessKey(info.UserID, owner, repo)
+
+	var canAccess *bool
+	if err := p.store.Get(key, &canAccess); err != nil {
+		p.client.Log.Warn("Failed to get the cached repository access", "error", err.Error())
+	} else if canAccess != nil {
+		return *canAccess
+	}
+
+	githubClient := p.githubConnectUser(ctx, info)
+	if githubClient == nil {
+		return false
+	}
+	_, _, err := githubClient.Repositories.Get(ctx, owner, repo)
+	if err != nil && ctx.Err() != nil {
+		// the access wasn't checked, it's not kept.
+		return false
+	}
+
+	access := err == nil
+	if _, err := p.store.Set(key, access, pluginapi.SetExpiry(repositoryAccessTTL)); err != nil {
+		p.client.Log.Warn("Failed to store the repository access", "error", err.Error())
+	}
+
+	return access
+}
@@ -0,0 +1,121 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestIsPrivatePreviewAllowed(t *testing.T) {
+	tcs := []struct {
+		name              string
+		enableCodePreview string
+		policy            string
+		channelType       model.ChannelType
+		members           []*model.ChannelMember
+		want              bool
+	}{
+		{
+			name:              "previews disabled for private repositories",
+			enableCodePreview: "public",
+			policy:            privatePreviewPolicyAnyChannel,
+			want:              false,
+		},
+		{
+			name:              "any channel",
+			enableCodePreview: "privateAndPublic",
+			want:              true,
+		},
+		{
+			name:              "private channels policy in a public channel",
+			enableCodePreview: "privateAndPublic",
+			policy:            privatePreviewPolicyPrivateChannels,
+			channelType:       model.ChannelTypeOpen,
+			want:              false,
+		},
+		{
+			name:              "private channels policy in a direct message",
+			enableCodePreview: "privateAndPublic",
+			policy:            privatePreviewPolicyPrivateChannels,
+			channelType:       model.ChannelTypeDirect,
+			want:              true,
+		},
+		{
+			name:              "connected members policy with a member not connected",
+			enableCodePreview: "privateAndPublic",
+			policy:            privatePreviewPolicyConnectedMembers,
+			members:           []*model.ChannelMember{{UserId: "user1"}},
+			want:              false,
+		},
+		{
+			name:              "connected members policy with only the bot",
+			enableCodePreview: "privateAndPublic",
+			policy:            privatePreviewPolicyConnectedMembers,
+			members:           []*model.ChannelMember{{UserId: "bot"}},
+			want:              true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPlugin()
+			p.setConfiguration(&Configuration{
+				EnableCodePreview:        tc.enableCodePreview,
+				PrivateCodePreviewPolicy: tc.policy,
+			})
+			p.BotUserID = "bot"
+			p.store = &pluginapi.MemoryStore{}
+
+			api := &plugintest.API{}
+			api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("GetChannel", "channel1").Return(&model.Channel{Id: "channel1", Type: tc.channelType}, nil).Maybe()
+			api.On("GetChannelMembers", "channel1", 0, maxPreviewAudienceSize+1).Return(model.ChannelMembers(func() []model.ChannelMember {
+				members := []model.ChannelMember{}
+				for _, member := range tc.members {
+					members = append(members, *member)
+				}
+				return members
+			}()), nil).Maybe()
+			api.On("GetUser", mock.Anything).Return(&model.User{}, nil).Maybe()
+			p.SetAPI(api)
+			p.client = pluginapi.NewClient(p.API, p.Driver)
+
+			assert.Equal(t, tc.want, p.isPrivatePreviewAllowed(context.Background(), "channel1", "mattermost", "private-repo"))
+		})
+	}
+}
+
+func TestCanAccessRepository(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/v3/repos/mattermost/private-repo" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"private": true}`))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EnterpriseBaseURL: ts.URL, EnterpriseUploadURL: ts.URL})
+	p.store = &pluginapi.MemoryStore{}
+
+	info := &GitHubUserInfo{UserID: "user1", Token: &oauth2.Token{AccessToken: "token"}}
+	for i := 0; i < 2; i++ {
+		assert.True(t, p.canAccessRepository(context.Background(), info, "mattermost", "private-repo"))
+		assert.False(t, p.canAccessRepository(context.Background(), info, "mattermost", "other-repo"))
+	}
+
+	// the access of the user is only checked once per repository.
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,306 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	// rateLimitMaxRetries is how many times a request hitting a secondary rate limit is retried.
+	rateLimitMaxRetries = 3
+
+	// rateLimitMaxWait is the longest wait before retrying a request. Longer rate limits fail the request.
+	rateLimitMaxWait = time.Minute
+
+	// rateLimitInitialBackoff is the wait before the first retry if GitHub doesn't say how long to wait.
+	rateLimitInitialBackoff = time.Second
+
+	// rateLimitLowRemaining is the number of remaining requests under which background jobs skip a user
+	// until the rate limit resets, to keep the quota for the user's own actions.
+	rateLimitLowRemaining = 100
+
+	apiErrorIDRateLimited = "rate_limited"
+)
+
+// errGitHubRateLimited is returned without sending the request while the user is rate limited, or when
+// a request made for the user hits a secondary rate limit.
+var errGitHubRateLimited = errors.New("GitHub rate limit exceeded")
+
+type rateLimitRetriesKey struct{}
+
+// withRateLimitRetries marks the requests of a background job, which wait and retry when they hit a
+// secondary rate limit. The other requests are made while the user waits, so they fail right away.
+func withRateLimitRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rateLimitRetriesKey{}, true)
+}
+
+func canRetryRateLimit(ctx context.Context) bool {
+	retry, _ := ctx.Value(rateLimitRetriesKey{}).(bool)
+	return retry
+}
+
+// githubRateLimitState is the last rate limit of a user reported by GitHub to this server.
+type githubRateLimitState struct {
+	lock         sync.Mutex
+	remaining    int
+	reset        time.Time
+	limitedUntil time.Time
+}
+
+func (s *githubRateLimitState) update(header http.Header, now time.Time) {
+	// the search API has its own rate limit, which resets every minute.
+	if resource := header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return
+	}
+
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	s.set(remaining, time.Unix(reset, 0), now)
+}
+
+func (s *githubRateLimitState) set(remaining int, reset, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.remaining = remaining
+	s.reset = reset
+	if remaining == 0 && s.reset.After(now) {
+		s.limitedUntil = s.reset
+	}
+}
+
+func (s *githubRateLimitState) setLimitedUntil(until time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if until.After(s.limitedUntil) {
+		s.limitedUntil = until
+	}
+}
+
+// getLimitedUntil returns the time the rate limit of the user resets, if it's exceeded.
+func (s *githubRateLimitState) getLimitedUntil(now time.Time) (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.limitedUntil, s.limitedUntil.After(now)
+}
+
+// isLow returns true if the user is rate limited or close to it.
+func (s *githubRateLimitState) isLow(now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.limitedUntil.After(now) {
+		return true
+	}
+
+	return !s.reset.IsZero() && s.reset.After(now) && s.remaining < rateLimitLowRemaining
+}
+
+// rateLimitTransport retries the requests of background jobs hitting a secondary rate limit with backoff,
+// see withRateLimitRetries. If state is set, it records the rate limit of the user and fails the requests
+// while the rate limit is exceeded, instead of sending them to GitHub.
+type rateLimitTransport struct {
+	base  http.RoundTripper
+	state *githubRateLimitState
+	// rejected is set when a request isn't sent because the rate limit is exceeded.
+	rejected *atomic.Bool
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.state != nil {
+		if until, limited := t.state.getLimitedUntil(time.Now()); limited {
+			if t.rejected != nil {
+				t.rejected.Store(true)
+			}
+			return nil, errors.Wrapf(errGitHubRateLimited, "until %s", until.Format(time.RFC3339))
+		}
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	backoff := rateLimitInitialBackoff
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.state != nil {
+			t.state.update(resp.Header, time.Now())
+		}
+
+		wait, secondary, err := getSecondaryRateLimitWait(resp)
+		if err != nil {
+			return nil, err
+		}
+		if !secondary {
+			return resp, nil
+		}
+
+		if wait == 0 {
+			wait = backoff
+			backoff *= 2
+		}
+
+		if !canRetryRateLimit(req.Context()) {
+			resp.Body.Close()
+			if t.state != nil {
+				t.state.setLimitedUntil(time.Now().Add(wait))
+			}
+			if t.rejected != nil {
+				t.rejected.Store(true)
+			}
+			return nil, errors.Wrap(errGitHubRateLimited, "secondary rate limit")
+		}
+
+		canRetry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if attempt >= rateLimitMaxRetries || wait > rateLimitMaxWait || !canRetry {
+			if t.state != nil {
+				t.state.setLimitedUntil(time.Now().Add(wait))
+			}
+			return resp, nil
+		}
+
+		resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// getSecondaryRateLimitWait returns true if the response is a secondary rate limit, and how long GitHub asks
+// to wait before retrying. The body of the response is kept readable.
+func getSecondaryRateLimitWait(resp *http.Response) (time.Duration, bool, error) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false, nil
+	}
+
+	// the primary rate limit only resets after up to an hour, it isn't retried.
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false, nil
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			return time.Duration(seconds) * time.Second, true, nil
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to read the response")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return 0, strings.Contains(strings.ToLower(string(body)), "secondary rate limit"), nil
+}
+
+// getRateLimitState returns the rate limit of the GitHub account reported to this server.
+func (p *Plugin) getRateLimitState(info *GitHubUserInfo) *githubRateLimitState {
+	state, _ := p.rateLimits.LoadOrStore(getUserInfoKey(info.UserID, info.Instance), &githubRateLimitState{})
+	return state.(*githubRateLimitState)
+}
+
+// hasGitHubQuota returns false if the user is rate limited or close to it. Background jobs skip the user
+// until the rate limit resets. The requests of the user served by the other servers of the cluster use the
+// same quota, so it's read from GitHub, which doesn't count it against the rate limit.
+func (p *Plugin) hasGitHubQuota(ctx context.Context, info *GitHubUserInfo) bool {
+	state := p.getRateLimitState(info)
+	now := time.Now()
+	if state.isLow(now) {
+		return false
+	}
+
+	githubClient := p.githubConnectUser(ctx, info)
+	if githubClient == nil {
+		return true
+	}
+
+	limits, _, err := githubClient.RateLimits(ctx)
+	if err != nil {
+		// GitHub Enterprise doesn't always have rate limits, and the other failures are left to the job.
+		p.client.Log.Debug("Failed to get the rate limits of the user", "userID", info.UserID, "error", err.Error())
+		return true
+	}
+	if core := limits.GetCore(); core != nil {
+		state.set(core.Remaining, core.Reset.Time, now)
+	}
+
+	return !state.isLow(now)
+}
+
+// getRateLimitedMessage returns the message telling the user until when their account is rate limited,
+// in their timezone. The message is empty if the user isn't rate limited.
+func (p *Plugin) getRateLimitedMessage(info *GitHubUserInfo) string {
+	until, limited := p.getRateLimitState(info).getLimitedUntil(time.Now())
+	if !limited {
+		return ""
+	}
+
+	if user, err := p.client.User.Get(info.UserID); err == nil {
+		until = until.In(user.GetTimezoneLocation())
+	}
+
+	return fmt.Sprintf("Your GitHub account is rate limited until %s. Please try again later.", until.Format("15:04 MST"))
+}
+
+// writeGitHubAPIError writes the error of a failed GitHub call. If the call wasn't sent because the account
+// is rate limited, the user is told until when instead.
+func (p *Plugin) writeGitHubAPIError(w http.ResponseWriter, info *GitHubUserInfo, err error, apiErr *APIErrorResponse) {
+	if errors.Is(err, errGitHubRateLimited) {
+		message := p.getRateLimitedMessage(info)
+		if message == "" {
+			message = "Your GitHub account is rate limited. Please try again later."
+		}
+		apiErr = &APIErrorResponse{ID: apiErrorIDRateLimited, Message: message, StatusCode: http.StatusTooManyRequests}
+	}
+
+	p.writeAPIError(w, apiErr)
+}
+
+// formatRateLimit describes the remaining quota of a rate limit of the user.
+func formatRateLimit(name string, rate *github.Rate, location *time.Location) string {
+	if rate == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("* %s: %d of %d remaining, resets at %s\n", name, rate.Remaining, rate.Limit, rate.Reset.In(location).Format("15:04 MST"))
+}
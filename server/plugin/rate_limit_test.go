@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	t.Run("secondary rate limit is retried", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "body", string(body))
+
+			if requests == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		state := &githubRateLimitState{}
+		client := &http.Client{Transport: &rateLimitTransport{state: state}}
+
+		req, err := http.NewRequestWithContext(withRateLimitRetries(context.Background()), http.MethodPost, server.URL, strings.NewReader("body"))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, requests)
+
+		_, limited := state.getLimitedUntil(time.Now())
+		assert.False(t, limited)
+	})
+
+	t.Run("secondary rate limit fails the requests of the user right away", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		state := &githubRateLimitState{}
+		rejected := &atomic.Bool{}
+		client := &http.Client{Transport: &rateLimitTransport{state: state, rejected: rejected}}
+
+		_, err := client.Get(server.URL)
+		assert.True(t, errors.Is(err, errGitHubRateLimited))
+		assert.True(t, rejected.Load())
+		assert.Equal(t, 1, requests)
+
+		_, limited := state.getLimitedUntil(time.Now())
+		assert.True(t, limited)
+	})
+
+	t.Run("primary rate limit blocks the following requests", func(t *testing.T) {
+		reset := time.Now().Add(time.Hour).Truncate(time.Second)
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		state := &githubRateLimitState{}
+		rejected := &atomic.Bool{}
+		client := &http.Client{Transport: &rateLimitTransport{state: state, rejected: rejected}}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.False(t, rejected.Load())
+		assert.Equal(t, 1, requests)
+
+		until, limited := state.getLimitedUntil(time.Now())
+		assert.True(t, limited)
+		assert.Equal(t, reset, until)
+
+		_, err = client.Get(server.URL)
+		assert.True(t, errors.Is(err, errGitHubRateLimited))
+		assert.Equal(t, 1, requests)
+		assert.True(t, rejected.Load())
+	})
+}
+
+func TestGetSecondaryRateLimitWait(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		statusCode      int
+		header          map[string]string
+		body            string
+		expectSecondary bool
+		expectWait      time.Duration
+	}{
+		{name: "success", statusCode: http.StatusOK},
+		{name: "forbidden", statusCode: http.StatusForbidden, body: "Resource not accessible by integration"},
+		{name: "retry after", statusCode: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "30"}, expectSecondary: true, expectWait: 30 * time.Second},
+		{name: "message", statusCode: http.StatusForbidden, body: `{"message": "You have exceeded a secondary rate limit."}`, expectSecondary: true},
+		{name: "primary rate limit", statusCode: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "Retry-After": "30"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tc.statusCode, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tc.body))}
+			for key, value := range tc.header {
+				resp.Header.Set(key, value)
+			}
+
+			wait, secondary, err := getSecondaryRateLimitWait(resp)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectSecondary, secondary)
+			assert.Equal(t, tc.expectWait, wait)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.body, string(body))
+		})
+	}
+}
+
+func TestGitHubRateLimitStateIsLow(t *testing.T) {
+	now := time.Now()
+
+	for _, tc := range []struct {
+		name     string
+		state    *githubRateLimitState
+		expected bool
+	}{
+		{name: "unknown", state: &githubRateLimitState{}},
+		{name: "enough remaining", state: &githubRateLimitState{remaining: 4000, reset: now.Add(time.Minute)}},
+		{name: "low remaining", state: &githubRateLimitState{remaining: 10, reset: now.Add(time.Minute)}, expected: true},
+		{name: "low remaining after the reset", state: &githubRateLimitState{remaining: 10, reset: now.Add(-time.Minute)}},
+		{name: "rate limited", state: &githubRateLimitState{remaining: 4000, reset: now.Add(time.Minute), limitedUntil: now.Add(time.Minute)}, expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.state.isLow(now))
+		})
+	}
+}
+
+// writeTestRateLimits answers a request for the rate limits of a user with the remaining core requests.
+func writeTestRateLimits(w http.ResponseWriter, remaining int) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"resources": {"core": {"limit": 5000, "remaining": %d, "reset": %d}}}`, remaining, time.Now().Add(time.Hour).Unix())
+}
+
+func TestHasGitHubQuota(t *testing.T) {
+	remaining, requests := 4000, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/rate_limit", r.URL.Path)
+		requests++
+		writeTestRateLimits(w, remaining)
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EnterpriseBaseURL: ts.URL, EnterpriseUploadURL: ts.URL})
+	p.client = pluginapi.NewClient(&plugintest.API{}, nil)
+	info := &GitHubUserInfo{UserID: "user1", Token: &oauth2.Token{AccessToken: "token"}}
+
+	assert.True(t, p.hasGitHubQuota(context.Background(), info))
+
+	// the quota is used by the requests sent by the other servers too.
+	remaining = 10
+	assert.False(t, p.hasGitHubQuota(context.Background(), info))
+	assert.Equal(t, 2, requests)
+
+	// GitHub isn't asked again until the rate limit resets.
+	assert.False(t, p.hasGitHubQuota(context.Background(), info))
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,253 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// maxReferenceReplacements sets the maximum limit to the number of
+// issue and pull request references that are expanded in a single message.
+const maxReferenceReplacements = 10
+
+const referencePreviewKeyPrefix = "_refpreview_"
+
+// referencePreviewTTL is the time an expanded reference is kept in the KV store.
+const referencePreviewTTL = 5 * time.Minute
+
+// shortReferenceRegex matches owner/repo#123 and #123 references. The leading group is the
+// delimiter preceding the reference, since Go regular expressions don't support lookbehinds.
+// GitHub logins can't contain dots, so hosts like example.com/path#3 aren't matched.
+var shortReferenceRegex = regexp.MustCompile(`(^|[\s(])(?:([a-zA-Z\d][a-zA-Z\d-]*)/([\w.-]+))?#(\d+)\b`)
+
+// issueReference holds the information of an issue or pull request mentioned in a message.
+type issueReference struct {
+	index  int    // index of the reference in the message
+	word   string // the reference as written in the message
+	owner  string
+	repo   string
+	number int
+	isURL  bool // true if the reference is a full URL, which doesn't need to be converted to a link
+}
+
+func (r *issueReference) key() string {
+	return fmt.Sprintf("%s/%s#%d", strings.ToLower(r.owner), strings.ToLower(r.repo), r.number)
+}
+
+// referencePreview holds the details shown below a message mentioning an issue or pull request.
+type referencePreview struct {
+	Title         string
+	State         string
+	Author        string
+	URL           string
+	Labels        []string
+	IsPullRequest bool
+	IsPrivate     bool
+}
+
+// issueReferenceURLRegexes caches the regexes returned by getIssueReferenceURLRegex by base URL.
+var issueReferenceURLRegexes sync.Map
+
+// getIssueReferenceURLRegex returns the regex matching issue and pull request URLs of the given GitHub instance.
+func getIssueReferenceURLRegex(baseURL string) *regexp.Regexp {
+	if re, ok := issueReferenceURLRegexes.Load(baseURL); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re := regexp.MustCompile(regexp.QuoteMeta(baseURL) + `([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d+)\b[^\s)\]]*`)
+	issueReferenceURLRegexes.Store(baseURL, re)
+	return re
+}
+
+// getIssueReferences returns the issue and pull request references found in a message, sorted by index
+// in ascending order. Short references like #123 are resolved against defaultRepo, and ignored if it's empty.
+func getIssueReferences(msg, defaultRepo, baseURL string) []issueReference {
+	var references []issueReference
+
+	for _, m := range shortReferenceRegex.FindAllStringSubmatchIndex(msg, -1) {
+		index := m[3] // end of the delimiter
+		if isInsideCode(msg, index) || isInsideLink(msg, index) {
+			continue
+		}
+
+		r := issueReference{
+			index: index,
+			word:  msg[index:m[1]],
+		}
+		r.number, _ = strconv.Atoi(msg[m[8]:m[9]])
+
+		if m[4] != -1 {
+			r.owner = msg[m[4]:m[5]]
+			r.repo = msg[m[6]:m[7]]
+		} else {
+			owner, repo, err := parseRepo(defaultRepo)
+			if err != nil {
+				continue
+			}
+			r.owner, r.repo = owner, repo
+		}
+
+		references = append(references, r)
+	}
+
+	for _, m := range getIssueReferenceURLRegex(baseURL).FindAllStringSubmatchIndex(msg, -1) {
+		// Diff links are previewed as code instead.
+		if isInsideCode(msg, m[0]) || strings.Contains(msg[m[0]:m[1]], "#diff-") {
+			continue
+		}
+
+		number, _ := strconv.Atoi(msg[m[6]:m[7]])
+		references = append(references, issueReference{
+			index:  m[0],
+			word:   msg[m[0]:m[1]],
+			owner:  msg[m[2]:m[3]],
+			repo:   msg[m[4]:m[5]],
+			number: number,
+			isURL:  true,
+		})
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		return references[i].index < references[j].index
+	})
+
+	return references
+}
+
+// isInsideCode returns true if the given index is inside an inline code span or a code block.
+func isInsideCode(msg string, index int) bool {
+	return strings.Count(msg[:index], "`")%2 == 1
+}
+
+func getReferencePreviewKey(userID string, r issueReference) string {
+	hash := sha256.Sum256([]byte(userID + "\n" + r.key()))
+	return referencePreviewKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+// getReferencePreview fetches the details of the referenced issue or pull request. The details are kept in the
+// KV store for a while, so the servers of the cluster don't fetch them every time they are mentioned. They are
+// kept per user, since access depends on the user's token.
+func (p *Plugin) getReferencePreview(ctx context.Context, ghClient *github.Client, repos *repositoryLookup, userID string, r issueReference) (*referencePreview, error) {
+	cacheKey := getReferencePreviewKey(userID, r)
+
+	var cached *referencePreview
+	if err := p.store.Get(cacheKey, &cached); err != nil {
+		p.client.Log.Warn("Failed to get the cached issue reference", "error", err.Error())
+	} else if cached != nil {
+		return cached, nil
+	}
+
+	issue, _, err := ghClient.Issues.Get(ctx, r.owner, r.repo, r.number)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &referencePreview{
+		Title:         issue.GetTitle(),
+		State:         issue.GetState(),
+		Author:        issue.GetUser().GetLogin(),
+		URL:           issue.GetHTMLURL(),
+		IsPullRequest: issue.IsPullRequest(),
+	}
+	for _, label := range issue.Labels {
+		preview.Labels = append(preview.Labels, label.GetName())
+	}
+
+	repo, err := repos.get(ctx, ghClient, r.owner, r.repo)
+	if err != nil {
+		return nil, err
+	}
+	preview.IsPrivate = repo.GetPrivate()
+
+	if _, err := p.store.Set(cacheKey, preview, pluginapi.SetExpiry(referencePreviewTTL)); err != nil {
+		p.client.Log.Warn("Failed to store the issue reference", "error", err.Error())
+	}
+
+	return preview, nil
+}
+
+// getReferencePreviewMarkdown returns the compact preview line of a referenced issue or pull request.
+func getReferencePreviewMarkdown(r issueReference, preview *referencePreview) string {
+	kind := "issue"
+	if preview.IsPullRequest {
+		kind = "pull request"
+	}
+
+	title := escapeMarkdownLinkText(preview.Title)
+	text := fmt.Sprintf("> [%s/%s#%d](%s) %s | %s %s by %s", r.owner, r.repo, r.number, preview.URL, title, preview.State, kind, preview.Author)
+	if len(preview.Labels) > 0 {
+		text += " | `" + strings.Join(preview.Labels, "` `") + "`"
+	}
+
+	return text
+}
+
+// makeReferenceReplacements converts the short issue and pull request references of a message to links and
+// appends a compact preview of each of them. Items also linked with a full URL are left to the link previews.
+// The previews are returned by reference key, and are nil for the references that couldn't be previewed.
+// The references slice needs to be sorted by the index in ascending order.
+func (p *Plugin) makeReferenceReplacements(ctx context.Context, msg string, references []issueReference, ghClient *github.Client, repos *repositoryLookup, userID, channelID string) (string, map[string]*referencePreview) {
+	linked := map[string]bool{}
+	for _, r := range references {
+		if r.isURL {
+			linked[r.key()] = true
+		}
+	}
+
+	previews := map[string]*referencePreview{}
+	var previewLines []string
+	for _, r := range references {
+		key := r.key()
+		if _, ok := previews[key]; ok || r.isURL {
+			continue
+		}
+		// have a limit on the number of references to expand
+		if len(previews) >= maxReferenceReplacements {
+			break
+		}
+
+		preview, err := p.getReferencePreview(ctx, ghClient, repos, userID, r)
+		if err != nil {
+			p.client.Log.Debug("Error while fetching issue reference", "error", err.Error(), "reference", key)
+			previews[key] = nil
+			continue
+		}
+		if preview.IsPrivate && !p.isPrivatePreviewAllowed(ctx, channelID, r.owner, r.repo) {
+			previews[key] = nil
+			continue
+		}
+
+		previews[key] = preview
+		if !linked[key] {
+			previewLines = append(previewLines, getReferencePreviewMarkdown(r, preview))
+		}
+	}
+
+	// iterating the slice in reverse to preserve the replacement indices.
+	for i := len(references) - 1; i >= 0; i-- {
+		r := references[i]
+		preview := previews[r.key()]
+		if preview == nil || r.isURL {
+			continue
+		}
+
+		msg = msg[:r.index] + fmt.Sprintf("[%s](%s)", r.word, preview.URL) + msg[r.index+len(r.word):]
+	}
+
+	if len(previewLines) > 0 {
+		msg += "\n\n" + strings.Join(previewLines, "\n\n")
+	}
+
+	return msg, previews
+}
@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestGetIssueReferences(t *testing.T) {
+	type ref struct {
+		word   string
+		owner  string
+		repo   string
+		number int
+		isURL  bool
+	}
+
+	tcs := []struct {
+		name        string
+		msg         string
+		defaultRepo string
+		want        []ref
+	}{
+		{
+			name: "full reference",
+			msg:  "see mattermost/mattermost-server#123 please",
+			want: []ref{{word: "mattermost/mattermost-server#123", owner: "mattermost", repo: "mattermost-server", number: 123}},
+		},
+		{
+			name:        "short reference with a default repository",
+			msg:         "fixed by #42.",
+			defaultRepo: "mattermost/mattermost-plugin-github",
+			want:        []ref{{word: "#42", owner: "mattermost", repo: "mattermost-plugin-github", number: 42}},
+		},
+		{
+			name: "short reference without a default repository",
+			msg:  "fixed by #42",
+			want: nil,
+		},
+		{
+			name: "pull request URL",
+			msg:  "(https://github.com/mattermost/mattermost-server/pull/7/files)",
+			want: []ref{{word: "https://github.com/mattermost/mattermost-server/pull/7/files", owner: "mattermost", repo: "mattermost-server", number: 7, isURL: true}},
+		},
+		{
+			name:        "references sorted by index",
+			msg:         "https://github.com/a/b/issues/1 and #2",
+			defaultRepo: "c/d",
+			want: []ref{
+				{word: "https://github.com/a/b/issues/1", owner: "a", repo: "b", number: 1, isURL: true},
+				{word: "#2", owner: "c", repo: "d", number: 2},
+			},
+		},
+		{
+			name:        "ignore references inside code",
+			msg:         "`a/b#1` and ```\n#2\n```",
+			defaultRepo: "c/d",
+			want:        nil,
+		},
+		{
+			name: "ignore references inside links",
+			msg:  "[text]( a/b#1)",
+			want: nil,
+		},
+		{
+			name:        "ignore hosts and paths",
+			msg:         "foo.com/a#12 example.com/path#3 (docs.example.com/a#1) a/b/c#4",
+			defaultRepo: "c/d",
+			want:        nil,
+		},
+		{
+			name:        "ignore anchors and hashtags",
+			msg:         "page#12 #12abc",
+			defaultRepo: "c/d",
+			want:        nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []ref
+			for _, r := range getIssueReferences(tc.msg, tc.defaultRepo, "https://github.com/") {
+				assert.Equal(t, r.word, tc.msg[r.index:r.index+len(r.word)])
+				got = append(got, ref{word: r.word, owner: r.owner, repo: r.repo, number: r.number, isURL: r.isURL})
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestGetReferencePreviewMarkdown(t *testing.T) {
+	r := issueReference{owner: "mattermost", repo: "mattermost-server", number: 1}
+	preview := &referencePreview{
+		Title:         "Fix [bug]",
+		State:         "open",
+		Author:        "octocat",
+		URL:           "https://github.com/mattermost/mattermost-server/pull/1",
+		Labels:        []string{"bug", "ui"},
+		IsPullRequest: true,
+	}
+
+	assert.Equal(t, "> [mattermost/mattermost-server#1](https://github.com/mattermost/mattermost-server/pull/1) Fix \\[bug\\] | open pull request by octocat | `bug` `ui`", getReferencePreviewMarkdown(r, preview))
+}
+
+func TestGetReferencePreview(t *testing.T) {
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/issues/1", "/api/v3/repos/owner/repo/issues/2":
+			_, _ = w.Write([]byte(`{"title": "Fix", "state": "open", "html_url": "https://github.com/owner/repo/issues/1"}`))
+		case "/api/v3/repos/owner/repo":
+			_, _ = w.Write([]byte(`{"private": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.store = &pluginapi.MemoryStore{}
+
+	ghClient, err := GetGitHubClient(oauth2.Token{AccessToken: "token"}, &Configuration{EnterpriseBaseURL: ts.URL, EnterpriseUploadURL: ts.URL})
+	require.NoError(t, err)
+
+	repos := newRepositoryLookup()
+	for _, number := range []int{1, 2, 1} {
+		preview, err := p.getReferencePreview(context.Background(), ghClient, repos, "user1", issueReference{owner: "owner", repo: "repo", number: number})
+		require.NoError(t, err)
+		assert.Equal(t, "Fix", preview.Title)
+		assert.True(t, preview.IsPrivate)
+	}
+
+	// the repository is fetched once per message, and the issues are cached in the KV store.
+	assert.Equal(t, 1, requests["/api/v3/repos/owner/repo"])
+	assert.Equal(t, 1, requests["/api/v3/repos/owner/repo/issues/1"])
+	assert.Equal(t, 1, requests["/api/v3/repos/owner/repo/issues/2"])
+}
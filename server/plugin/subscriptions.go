@@ -23,6 +23,7 @@ type SubscriptionFlags struct {
 	ExcludeOrgMembers bool
 	RenderStyle       string
 	ExcludeRepository []string
+	Instance          string
 }
 
 func (s *SubscriptionFlags) AddFlag(flag string, value string) error {
@@ -41,6 +42,8 @@ func (s *SubscriptionFlags) AddFlag(flag string, value string) error {
 			repos[i] = strings.TrimSpace(repos[i])
 		}
 		s.ExcludeRepository = repos
+	case flagInstance:
+		s.Instance = value
 	}
 
 	return nil
@@ -64,6 +67,11 @@ func (s SubscriptionFlags) String() string {
 		flags = append(flags, flag)
 	}
 
+	if s.Instance != "" {
+		flag := "--" + flagInstance + " " + s.Instance
+		flags = append(flags, flag)
+	}
+
 	return strings.Join(flags, ",")
 }
 
@@ -173,16 +181,19 @@ func (p *Plugin) Subscribe(ctx context.Context, githubClient *github.Client, use
 	owner = strings.ToLower(owner)
 	repo = strings.ToLower(repo)
 
-	if err := p.checkOrg(owner); err != nil {
+	config, err := p.getConfiguration().forInstance(flags.Instance)
+	if err != nil {
+		return err
+	}
+
+	if err = config.checkOrg(owner); err != nil {
 		return errors.Wrap(err, "organization not supported")
 	}
 
-	if flags.ExcludeOrgMembers && !p.isOrganizationLocked() {
+	if flags.ExcludeOrgMembers && !config.isOrganizationLocked() {
 		return errors.New("Unable to set --exclude-org-member flag. The GitHub plugin is not locked to a single organization.")
 	}
 
-	var err error
-
 	if repo == "" {
 		var ghOrg *github.Organization
 		ghOrg, _, err = githubClient.Organizations.Get(ctx, owner)
@@ -268,7 +279,7 @@ func (p *Plugin) AddSubscription(repo string, sub *Subscription) error {
 	} else {
 		exists := false
 		for index, s := range repoSubs {
-			if s.ChannelID == sub.ChannelID {
+			if s.ChannelID == sub.ChannelID && s.Flags.Instance == sub.Flags.Instance {
 				repoSubs[index] = sub
 				exists = true
 				break
@@ -314,7 +325,9 @@ func (p *Plugin) StoreSubscriptions(s *Subscriptions) error {
 	return nil
 }
 
-func (p *Plugin) GetSubscribedChannelsForRepository(repo *github.Repository) []*Subscription {
+// GetSubscribedChannelsForRepository returns the subscriptions to the repository made on the GitHub instance
+// that delivered the event.
+func (p *Plugin) GetSubscribedChannelsForRepository(repo *github.Repository, instance string) []*Subscription {
 	name := repo.GetFullName()
 	name = strings.ToLower(name)
 	org := strings.Split(name, "/")[0]
@@ -342,7 +355,10 @@ func (p *Plugin) GetSubscribedChannelsForRepository(repo *github.Repository) []*
 	subsToReturn := []*Subscription{}
 
 	for _, sub := range subsForRepo {
-		if repo.GetPrivate() && !p.permissionToRepo(sub.CreatorID, name) {
+		if sub.Flags.Instance != instance {
+			continue
+		}
+		if repo.GetPrivate() && !p.permissionToRepoForInstance(sub.CreatorID, instance, name) {
 			continue
 		}
 		if sub.excludedRepoForSub(repo) {
@@ -354,7 +370,7 @@ func (p *Plugin) GetSubscribedChannelsForRepository(repo *github.Repository) []*
 	return subsToReturn
 }
 
-func (p *Plugin) Unsubscribe(channelID, repo, owner string) error {
+func (p *Plugin) Unsubscribe(channelID, repo, owner, instance string) error {
 	repoWithOwner := fmt.Sprintf("%s/%s", owner, repo)
 
 	subs, err := p.GetSubscriptions()
@@ -369,7 +385,7 @@ func (p *Plugin) Unsubscribe(channelID, repo, owner string) error {
 
 	removed := false
 	for index, sub := range repoSubs {
-		if sub.ChannelID == channelID {
+		if sub.ChannelID == channelID && sub.Flags.Instance == instance {
 			repoSubs = append(repoSubs[:index], repoSubs[index+1:]...)
 			removed = true
 			break
@@ -39,10 +39,14 @@ func (p *Plugin) SendDailyTelemetry() {
 	p.TrackEvent("stats", map[string]interface{}{
 		"connected_user_count":          connectedUserCount,
 		"is_oauth_configured":           config.IsOAuthConfigured(),
+		"is_github_app_configured":      config.IsGitHubAppConfigured(),
+		"has_github_instances":          config.GitHubInstances != "",
 		"is_sass":                       config.IsSASS(),
 		"is_organization_locked":        config.GitHubOrg != "",
 		"enable_private_repo":           config.EnablePrivateRepo,
 		"enable_code_preview":           config.EnableCodePreview,
+		"private_code_preview_policy":   config.getPrivatePreviewPolicy(),
+		"enable_reference_preview":      config.EnableReferencePreview,
 		"connect_to_private_by_default": config.ConnectToPrivateByDefault,
 		"Use_preregistered_application": config.UsePreregisteredApplication,
 	})
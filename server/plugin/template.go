@@ -387,7 +387,7 @@ Assignees: {{range $i, $el := .Assignees -}} {{- if $i}}, {{end}}{{template "use
 {{else}}{{end}}`))
 
 	template.Must(masterTemplate.New("helpText").Parse("" +
-		"* `/github connect{{if .EnablePrivateRepo}}{{if not .ConnectToPrivateByDefault}} [private]{{end}}{{end}}` - Connect your Mattermost account to your GitHub account.\n" +
+		"* `/github connect{{if .EnablePrivateRepo}}{{if not .ConnectToPrivateByDefault}} [private]{{end}}{{end}}{{if not .UsePreregisteredApplication}} [device]{{end}}` - Connect your Mattermost account to your GitHub account.\n" +
 		"{{if .EnablePrivateRepo}}{{if not .ConnectToPrivateByDefault}}" +
 		"  * `private` is optional. If used, read access to your private repositories will be requested." +
 		"If these repositories send webhook events to this Mattermost server, you'll be notified of changes to those repositories.\n" +
@@ -395,7 +395,13 @@ Assignees: {{range $i, $el := .Assignees -}} {{- if $i}}, {{end}}{{template "use
 		"  * Read access to your private repositories will be requested." +
 		"If these repositories send webhook events to this Mattermost server, you'll be notified of changes to those repositories.\n" +
 		"{{end}}{{end}}" +
-		"* `/github disconnect` - Disconnect your Mattermost account from your GitHub account\n" +
+		"{{if not .UsePreregisteredApplication}}" +
+		"  * `device` is optional. If used, a code to enter on GitHub is sent to you instead of redirecting your browser back to Mattermost.\n" +
+		"{{end}}" +
+		"{{if .GitHubInstances}}" +
+		"  * `--instance name` is optional. If used, your account on the additional GitHub instance with this name is connected.\n" +
+		"{{end}}" +
+		"* `/github disconnect{{if .GitHubInstances}} [--instance name]{{end}}` - Disconnect your Mattermost account from your GitHub account\n" +
 		"* `/github help` - Display Slash Command help text\n" +
 		"* `/github todo` - Get a list of unread messages and pull requests awaiting your review\n" +
 		"* `/github subscriptions list` - Will list the current channel subscriptions\n" +
@@ -419,11 +425,43 @@ Assignees: {{range $i, $el := .Assignees -}} {{- if $i}}, {{end}}{{template "use
 		"    	* Defaults to `pulls,issues,creates,deletes`\n\n" +
 		"    * `--exclude-org-member` - events triggered by organization members will not be delivered (the GitHub organization config should be set, otherwise this flag has not effect)\n" +
 		"    * `--render-style` - notifications will be delivered in the specified style (for example, the body of a pull request will not be displayed). Supported values are `collapsed`, `skip-body` or `default` (same as omitting the flag).\n" +
-		"* `/github subscriptions delete owner[/repo]` - Unsubscribe the current channel from a repository\n" +
-		"* `/github me` - Display the connected GitHub account\n" +
+		"{{if .GitHubInstances}}" +
+		"    * `--instance` - the name of the additional GitHub instance hosting the organization or repository\n" +
+		"{{end}}" +
+		"* `/github subscriptions delete owner[/repo]{{if .GitHubInstances}} [--instance name]{{end}}` - Unsubscribe the current channel from a repository\n" +
+		"* `/github watch [add] owner[/repo] [features] [flags]{{if .GitHubInstances}} [--instance name]{{end}}` - Receive the events of an organization or repository in a direct message, with the same features and flags as subscriptions. Private repositories are only watched while your GitHub account can access them\n" +
+		"* `/github watch list` - List the organizations and repositories you watch\n" +
+		"* `/github watch delete owner[/repo]{{if .GitHubInstances}} [--instance name]{{end}}` - Stop watching an organization or repository\n" +
+		"* `/github issue [command]` - Create and triage issues\n" +
+		"  * `/github issue create [title]` - open a dialog to create a new issue\n" +
+		"  * `/github issue view owner/repo#number` - display a summary of the issue\n" +
+		"  * `/github issue close|reopen owner/repo#number` - close or reopen the issue\n" +
+		"  * `/github issue comment owner/repo#number comment` - comment on the issue\n" +
+		"  * `/github issue assign owner/repo#number username...` - assign GitHub users to the issue\n" +
+		"  * `/github issue label|unlabel owner/repo#number label...` - add or remove labels. Wrap labels containing spaces in double quotes\n" +
+		"  * `/github issue milestone owner/repo#number number|none` - set the milestone of the issue, or remove it\n" +
+		"* `/github pr [command] owner/repo#number` - Act on a pull request\n" +
+		"  * `/github pr view owner/repo#number` - display a summary of the pull request\n" +
+		"  * `/github pr approve owner/repo#number [message]` - approve the pull request\n" +
+		"  * `/github pr request-changes owner/repo#number message` - request changes on the pull request\n" +
+		"  * `/github pr comment owner/repo#number comment` - comment on the pull request\n" +
+		"  * `/github pr merge owner/repo#number [--method merge|squash|rebase]` - merge the pull request, with a merge commit unless another method is given\n" +
+		"  * `/github pr close owner/repo#number` - close the pull request without merging it\n" +
+		"* `/github channel [command]` - Manage the default repository of the current channel. Only the channel admins can change it\n" +
+		"  * `/github channel set-repo owner/repo` - use this repository when a command is given a short reference like `#123`\n" +
+		"  * `/github channel unset-repo` - remove the default repository\n" +
+		"  * `/github channel show-repo` - display the default repository\n" +
+		"* `/github me` - Display the connected GitHub account and its remaining rate limits\n" +
 		"* `/github settings [setting] [value]` - Update your user settings\n" +
 		"  * `setting` can be `notifications` or `reminders`\n" +
 		"  * `value` can be `on` or `off`\n" +
+		"  * `/github settings reminders at HH:MM [days]` - get the daily reminder at this time of your timezone. `days` can be `daily`, `weekdays` or a list of days like `mon,wed,fri`, and defaults to `weekdays`\n" +
+		"  * `/github settings todo sections [sections]` - choose the sections of your todo list among `unreads`, `reviews`, `open-prs`, `assignments`, `failing-checks`, `ready-to-merge`, `mentions` and `stale-drafts`, or `default`\n" +
+		"  * `/github settings todo orgs|repos|labels [values]` - only list items of these comma separated organizations, `owner/repo` repositories or labels, or `none`\n" +
+		"  * `/github settings todo show|reset` - display or reset your todo list settings\n" +
+		"  * `/github settings inbox on|off` - receive the new notifications of your GitHub inbox in a direct message, including the repositories without a webhook to this server{{if .GitHubInstances}}. Only the inbox of your account on the default GitHub instance is forwarded{{end}}\n" +
+		"  * `/github settings inbox reasons|repos [values]` - only forward the notifications with these comma separated reasons, like `ci_activity`, `security_alert` or `team_mention`, or of these organizations or `owner/repo` repositories, or `none`. Without reasons, the mentions, review requests and assignments already sent by the webhook notifications aren't forwarded\n" +
+		"  * `/github settings inbox show` - display your inbox settings\n" +
 		"* `/github mute` - Managed muted GitHub users. You'll not receive notifications for comments in your PRs and issues from those users.\n" +
 		"  * `/github mute list` - list your muted GitHub users\n" +
 		"  * `/github mute add [username]` - add a GitHub user to your muted list\n" +
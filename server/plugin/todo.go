@@ -0,0 +1,414 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-github/server/plugin/graphql"
+)
+
+const (
+	todoSectionUnreads       = "unreads"
+	todoSectionReviews       = "reviews"
+	todoSectionOpenPRs       = "open-prs"
+	todoSectionAssignments   = "assignments"
+	todoSectionFailingChecks = "failing-checks"
+	todoSectionReadyToMerge  = "ready-to-merge"
+	todoSectionMentions      = "mentions"
+	todoSectionStaleDrafts   = "stale-drafts"
+
+	// staleDraftAge is how long a draft pull request wasn't updated before it's listed as stale.
+	staleDraftAge = 14 * 24 * time.Hour
+
+	todoSettingSections = "sections"
+	todoSettingOrgs     = "orgs"
+	todoSettingRepos    = "repos"
+	todoSettingLabels   = "labels"
+	todoSettingShow     = "show"
+	todoSettingReset    = "reset"
+	todoSettingNone     = "none"
+	todoSettingDefault  = "default"
+)
+
+// defaultToDoSections are the sections of the todo list of users who didn't choose theirs.
+var defaultToDoSections = []string{todoSectionUnreads, todoSectionReviews, todoSectionOpenPRs, todoSectionAssignments}
+
+// todoSection is a section of the todo list listing the results of a search.
+type todoSection struct {
+	title      string
+	emptyText  string
+	countText  string
+	searchText string
+}
+
+// todoSections are the sections listing search results. The search text is formatted with the GitHub username
+// and the filters of the user.
+var todoSections = map[string]*todoSection{
+	todoSectionReviews: {
+		title:      "Review Requests",
+		emptyText:  "You don't have any pull requests awaiting your review.",
+		countText:  "You have %v pull requests awaiting your review:",
+		searchText: "is:pr is:open review-requested:%v archived:false %v",
+	},
+	todoSectionOpenPRs: {
+		title:      "Your Open Pull Requests",
+		emptyText:  "You don't have any open pull requests.",
+		countText:  "You have %v open pull requests:",
+		searchText: "is:pr is:open author:%v archived:false %v",
+	},
+	todoSectionAssignments: {
+		title:      "Your Assignments",
+		emptyText:  "You don't have any assignments.",
+		countText:  "You have %v assignments:",
+		searchText: "is:open assignee:%v archived:false %v",
+	},
+	todoSectionFailingChecks: {
+		title:      "Your Pull Requests With Failing Checks",
+		emptyText:  "You don't have any pull requests with failing checks.",
+		countText:  "You have %v pull requests with failing checks:",
+		searchText: "is:pr is:open author:%v status:failure archived:false %v",
+	},
+	todoSectionReadyToMerge: {
+		title:      "Your Pull Requests Ready to Merge",
+		emptyText:  "You don't have any approved pull requests.",
+		countText:  "You have %v approved pull requests ready to merge:",
+		searchText: "is:pr is:open author:%v review:approved -is:draft archived:false %v",
+	},
+	todoSectionMentions: {
+		title:      "Issues Mentioning You",
+		emptyText:  "You don't have any open issues mentioning you.",
+		countText:  "You have %v open issues mentioning you:",
+		searchText: "is:issue is:open mentions:%v archived:false %v",
+	},
+	todoSectionStaleDrafts: {
+		title:      "Your Stale Draft Pull Requests",
+		emptyText:  "You don't have any stale draft pull requests.",
+		countText:  "You have %v draft pull requests without updates for two weeks:",
+		searchText: "is:pr is:open draft:true author:%v archived:false %v",
+	},
+}
+
+// todoSectionResult is the content of a section of the todo list of a user.
+type todoSectionResult struct {
+	title     string
+	emptyText string
+	countText string
+	count     int
+	content   string
+}
+
+func isValidToDoSection(section string) bool {
+	_, ok := todoSections[section]
+	return ok || section == todoSectionUnreads
+}
+
+// getToDoSections returns the sections of the todo list chosen by the user.
+func (s *UserSettings) getToDoSections() []string {
+	if s == nil || len(s.ToDoSections) == 0 {
+		return defaultToDoSections
+	}
+
+	return s.ToDoSections
+}
+
+// getToDoSearchFilters returns the search qualifiers restricting the todo list to the organizations, repositories
+// and labels chosen by the user, or to the organizations of the plugin.
+func getToDoSearchFilters(settings *UserSettings, orgList []string, now time.Time, section string) string {
+	var filters []string
+
+	if settings != nil && (len(settings.ToDoOrgs) > 0 || len(settings.ToDoRepos) > 0) {
+		for _, org := range settings.ToDoOrgs {
+			filters = append(filters, "org:"+org)
+		}
+		for _, repo := range settings.ToDoRepos {
+			filters = append(filters, "repo:"+repo)
+		}
+	} else {
+		for _, org := range orgList {
+			if len(org) != 0 {
+				filters = append(filters, "org:"+org)
+			}
+		}
+	}
+
+	if settings != nil && len(settings.ToDoLabels) > 0 {
+		labels := make([]string, 0, len(settings.ToDoLabels))
+		for _, label := range settings.ToDoLabels {
+			labels = append(labels, fmt.Sprintf("%q", label))
+		}
+		// labels separated by commas match any of them.
+		filters = append(filters, "label:"+strings.Join(labels, ","))
+	}
+
+	if section == todoSectionStaleDrafts {
+		filters = append(filters, "updated:<"+now.Add(-staleDraftAge).Format("2006-01-02"))
+	}
+
+	return strings.Join(filters, " ")
+}
+
+// matchesToDoFilters returns true if the repository belongs to the organizations or repositories chosen by the user.
+func matchesToDoFilters(settings *UserSettings, repo *github.Repository) bool {
+	if settings == nil || (len(settings.ToDoOrgs) == 0 && len(settings.ToDoRepos) == 0) {
+		return true
+	}
+
+	for _, org := range settings.ToDoOrgs {
+		if strings.EqualFold(org, repo.GetOwner().GetLogin()) {
+			return true
+		}
+	}
+
+	for _, fullName := range settings.ToDoRepos {
+		if strings.EqualFold(fullName, repo.GetFullName()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getToDoSectionResults returns the content of the sections of the todo list chosen by the user. The searches
+// run in a single GraphQL query, as the search rate limit of the REST API is quickly exhausted.
+func (p *Plugin) getToDoSectionResults(ctx context.Context, info *GitHubUserInfo) ([]*todoSectionResult, error) {
+	orgList := p.configuration.getOrganizations()
+	baseURL := p.getConfiguration().getBaseURL()
+	now := time.Now()
+
+	var queries []string
+	for _, name := range info.Settings.getToDoSections() {
+		if section, ok := todoSections[name]; ok {
+			queries = append(queries, fmt.Sprintf(section.searchText, info.GitHubUsername, getToDoSearchFilters(info.Settings, orgList, now, name)))
+		}
+	}
+
+	var searchResults []*graphql.ToDoSearchResult
+	if len(queries) > 0 {
+		graphQLClient := p.graphQLConnect(info)
+		if graphQLClient == nil {
+			return nil, errors.New("failed to create the GraphQL client")
+		}
+
+		var err error
+		searchResults, err = graphQLClient.GetToDoData(ctx, queries)
+		if err != nil {
+			return nil, errors.Wrap(err, "error occurred while searching for the todo list")
+		}
+	}
+
+	var results []*todoSectionResult
+	for _, name := range info.Settings.getToDoSections() {
+		if name == todoSectionUnreads {
+			result, err := p.getUnreadsToDoSection(ctx, info.Settings, p.githubConnectUser(ctx, info))
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+			continue
+		}
+
+		section, ok := todoSections[name]
+		if !ok {
+			continue
+		}
+
+		searchResult := searchResults[0]
+		searchResults = searchResults[1:]
+		query := queries[0]
+		queries = queries[1:]
+
+		result := &todoSectionResult{
+			title:     section.title,
+			emptyText: section.emptyText,
+			countText: section.countText,
+			count:     searchResult.Total,
+		}
+		for _, issue := range searchResult.Issues {
+			result.content += getToDoDisplayText(baseURL, issue.GetTitle(), issue.GetHTMLURL(), "", nil)
+		}
+		// the searches return a limited number of items, the others are listed on GitHub.
+		if more := searchResult.Total - len(searchResult.Issues); more > 0 {
+			result.content += fmt.Sprintf("* [and %d more](%s)\n", more, getToDoSearchURL(baseURL, query))
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// getToDoSearchURL returns the link to the results of a search of the todo list on GitHub.
+func getToDoSearchURL(baseURL, query string) string {
+	return baseURL + "search?type=issues&q=" + url.QueryEscape(strings.TrimSpace(query))
+}
+
+// hasToDoItems returns true if any section of the todo list isn't empty.
+func hasToDoItems(results []*todoSectionResult) bool {
+	for _, result := range results {
+		if result.count > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getUnreadsToDoSection returns the unread notifications of the user.
+func (p *Plugin) getUnreadsToDoSection(ctx context.Context, settings *UserSettings, githubClient *github.Client) (*todoSectionResult, error) {
+	baseURL := p.getConfiguration().getBaseURL()
+
+	notifications, _, err := githubClient.Activity.ListNotifications(ctx, &github.NotificationListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error occurred while listing notifications")
+	}
+
+	result := &todoSectionResult{
+		title:     "Unread Messages",
+		emptyText: "You don't have any unread messages.",
+		countText: "You have %v unread messages:",
+	}
+
+	for _, n := range notifications {
+		if n.GetReason() == notificationReasonSubscribed {
+			continue
+		}
+
+		if n.GetRepository() == nil {
+			p.client.Log.Warn("Unable to get repository for notification in todo list. Skipping.")
+			continue
+		}
+
+		if p.checkOrg(n.GetRepository().GetOwner().GetLogin()) != nil {
+			continue
+		}
+
+		if !matchesToDoFilters(settings, n.GetRepository()) {
+			continue
+		}
+
+		notificationSubject := n.GetSubject()
+		notificationType := notificationSubject.GetType()
+		switch notificationType {
+		case "RepositoryVulnerabilityAlert":
+			message := fmt.Sprintf("[Vulnerability Alert for %v](%v)", n.GetRepository().GetFullName(), fixGithubNotificationSubjectURL(n.GetSubject().GetURL(), ""))
+			result.content += fmt.Sprintf("* %v\n", message)
+		default:
+			notificationTitle := notificationSubject.GetTitle()
+			notificationURL := getNotificationHTMLURL(n)
+			result.content += getToDoDisplayText(baseURL, notificationTitle, notificationURL, notificationType, n.GetRepository())
+		}
+
+		result.count++
+	}
+
+	return result, nil
+}
+
+// renderToDo returns the text of the todo list.
+func renderToDo(results []*todoSectionResult) string {
+	text := ""
+	for _, result := range results {
+		text += "##### " + result.title + "\n"
+
+		if result.count == 0 {
+			text += result.emptyText + "\n"
+			continue
+		}
+
+		text += fmt.Sprintf(result.countText, result.count) + "\n"
+		text += result.content
+	}
+
+	return text
+}
+
+// updateToDoSettings updates the todo list settings of the user with the `/github settings todo` parameters.
+// updated is false if the settings weren't changed, and the message is shown to the user instead.
+func (p *Plugin) updateToDoSettings(settings *UserSettings, parameters []string) (message string, updated bool) {
+	const usage = "Please use `/github settings todo sections|orgs|repos|labels <values>`, `/github settings todo show` or `/github settings todo reset`."
+	if len(parameters) == 0 {
+		return usage, false
+	}
+
+	switch parameters[0] {
+	case todoSettingShow:
+		return getToDoSettingsText(settings), false
+	case todoSettingReset:
+		settings.ToDoSections = nil
+		settings.ToDoOrgs = nil
+		settings.ToDoRepos = nil
+		settings.ToDoLabels = nil
+		return "", true
+	}
+
+	if len(parameters) != 2 {
+		return usage, false
+	}
+
+	var values []string
+	if parameters[1] != todoSettingNone && parameters[1] != todoSettingDefault {
+		for _, value := range strings.Split(parameters[1], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	switch parameters[0] {
+	case todoSettingSections:
+		for _, section := range values {
+			if !isValidToDoSection(section) {
+				return fmt.Sprintf("Unknown section `%s`. Available sections are: %s.", section, strings.Join(getToDoSectionNames(), ", ")), false
+			}
+		}
+		settings.ToDoSections = values
+	case todoSettingOrgs:
+		for _, org := range values {
+			if err := p.checkOrg(org); err != nil {
+				return fmt.Sprintf("Organization `%s` isn't supported: %s.", org, err.Error()), false
+			}
+		}
+		settings.ToDoOrgs = values
+	case todoSettingRepos:
+		for _, repo := range values {
+			owner, name, ok := strings.Cut(repo, "/")
+			if !ok || owner == "" || name == "" {
+				return fmt.Sprintf("Invalid repository `%s`. Please use the `owner/repo` format.", repo), false
+			}
+			if err := p.checkOrg(owner); err != nil {
+				return fmt.Sprintf("Repository `%s` isn't supported: %s.", repo, err.Error()), false
+			}
+		}
+		settings.ToDoRepos = values
+	case todoSettingLabels:
+		settings.ToDoLabels = values
+	default:
+		return usage, false
+	}
+
+	return "", true
+}
+
+func getToDoSectionNames() []string {
+	return []string{todoSectionUnreads, todoSectionReviews, todoSectionOpenPRs, todoSectionAssignments,
+		todoSectionFailingChecks, todoSectionReadyToMerge, todoSectionMentions, todoSectionStaleDrafts}
+}
+
+// getToDoSettingsText describes the todo list settings of the user.
+func getToDoSettingsText(settings *UserSettings) string {
+	valueOrNone := func(values []string) string {
+		if len(values) == 0 {
+			return todoSettingNone
+		}
+		return strings.Join(values, ",")
+	}
+
+	return fmt.Sprintf("Your todo list settings:\n* Sections: `%s`\n* Organizations: `%s`\n* Repositories: `%s`\n* Labels: `%s`",
+		strings.Join(settings.getToDoSections(), ","), valueOrNone(settings.ToDoOrgs), valueOrNone(settings.ToDoRepos), valueOrNone(settings.ToDoLabels))
+}
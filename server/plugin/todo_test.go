@@ -0,0 +1,179 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetToDoSearchFilters(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 9, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		settings *UserSettings
+		orgList  []string
+		section  string
+		expected string
+	}{
+		{
+			name:     "no filters",
+			section:  todoSectionReviews,
+			expected: "",
+		},
+		{
+			name:     "plugin organizations",
+			settings: &UserSettings{},
+			orgList:  []string{"mattermost", "", "other"},
+			section:  todoSectionReviews,
+			expected: "org:mattermost org:other",
+		},
+		{
+			name:     "user organizations and repositories replace the plugin organizations",
+			settings: &UserSettings{ToDoOrgs: []string{"mattermost"}, ToDoRepos: []string{"other/repo"}},
+			orgList:  []string{"mattermost", "other"},
+			section:  todoSectionOpenPRs,
+			expected: "org:mattermost repo:other/repo",
+		},
+		{
+			name:     "labels",
+			settings: &UserSettings{ToDoLabels: []string{"bug", "needs review"}},
+			section:  todoSectionAssignments,
+			expected: `label:"bug","needs review"`,
+		},
+		{
+			name:     "stale drafts",
+			settings: &UserSettings{ToDoRepos: []string{"mattermost/repo"}},
+			section:  todoSectionStaleDrafts,
+			expected: "repo:mattermost/repo updated:<2024-05-01",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getToDoSearchFilters(tc.settings, tc.orgList, now, tc.section))
+		})
+	}
+}
+
+func TestMatchesToDoFilters(t *testing.T) {
+	repo := &github.Repository{
+		FullName: github.String("Mattermost/repo"),
+		Owner:    &github.User{Login: github.String("Mattermost")},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		settings *UserSettings
+		expected bool
+	}{
+		{name: "no settings", expected: true},
+		{name: "no filters", settings: &UserSettings{ToDoLabels: []string{"bug"}}, expected: true},
+		{name: "organization", settings: &UserSettings{ToDoOrgs: []string{"mattermost"}}, expected: true},
+		{name: "repository", settings: &UserSettings{ToDoRepos: []string{"mattermost/repo"}}, expected: true},
+		{name: "other organization", settings: &UserSettings{ToDoOrgs: []string{"other"}, ToDoRepos: []string{"mattermost/other"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchesToDoFilters(tc.settings, repo))
+		})
+	}
+}
+
+func TestUpdateToDoSettings(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{GitHubOrg: "mattermost"})
+
+	for _, tc := range []struct {
+		name           string
+		settings       *UserSettings
+		parameters     []string
+		expectUpdated  bool
+		expectSettings *UserSettings
+	}{
+		{
+			name:           "sections",
+			settings:       &UserSettings{},
+			parameters:     []string{"sections", "reviews,failing-checks, stale-drafts"},
+			expectUpdated:  true,
+			expectSettings: &UserSettings{ToDoSections: []string{"reviews", "failing-checks", "stale-drafts"}},
+		},
+		{
+			name:           "default sections",
+			settings:       &UserSettings{ToDoSections: []string{"reviews"}},
+			parameters:     []string{"sections", "default"},
+			expectUpdated:  true,
+			expectSettings: &UserSettings{},
+		},
+		{
+			name:           "unknown section",
+			settings:       &UserSettings{},
+			parameters:     []string{"sections", "reviews,unknown"},
+			expectSettings: &UserSettings{},
+		},
+		{
+			name:           "organizations",
+			settings:       &UserSettings{},
+			parameters:     []string{"orgs", "Mattermost"},
+			expectUpdated:  true,
+			expectSettings: &UserSettings{ToDoOrgs: []string{"Mattermost"}},
+		},
+		{
+			name:           "unsupported organization",
+			settings:       &UserSettings{},
+			parameters:     []string{"orgs", "other"},
+			expectSettings: &UserSettings{},
+		},
+		{
+			name:           "invalid repository",
+			settings:       &UserSettings{},
+			parameters:     []string{"repos", "mattermost"},
+			expectSettings: &UserSettings{},
+		},
+		{
+			name:           "unsupported repository",
+			settings:       &UserSettings{},
+			parameters:     []string{"repos", "other/repo"},
+			expectSettings: &UserSettings{},
+		},
+		{
+			name:           "no labels",
+			settings:       &UserSettings{ToDoLabels: []string{"bug"}},
+			parameters:     []string{"labels", "none"},
+			expectUpdated:  true,
+			expectSettings: &UserSettings{},
+		},
+		{
+			name:           "reset",
+			settings:       &UserSettings{DailyReminder: true, ToDoSections: []string{"reviews"}, ToDoRepos: []string{"mattermost/repo"}},
+			parameters:     []string{"reset"},
+			expectUpdated:  true,
+			expectSettings: &UserSettings{DailyReminder: true},
+		},
+		{
+			name:           "missing value",
+			settings:       &UserSettings{},
+			parameters:     []string{"labels"},
+			expectSettings: &UserSettings{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			message, updated := p.updateToDoSettings(tc.settings, tc.parameters)
+			assert.Equal(t, tc.expectUpdated, updated, message)
+			assert.Equal(t, tc.expectSettings, tc.settings)
+		})
+	}
+}
+
+func TestRenderToDo(t *testing.T) {
+	text := renderToDo([]*todoSectionResult{
+		{title: "Review Requests", emptyText: "You don't have any pull requests awaiting your review.", countText: "You have %v pull requests awaiting your review:"},
+		{title: "Your Assignments", emptyText: "You don't have any assignments.", countText: "You have %v assignments:", count: 1, content: "* an issue\n"},
+	})
+
+	assert.Equal(t, "##### Review Requests\nYou don't have any pull requests awaiting your review.\n##### Your Assignments\nYou have 1 assignments:\n* an issue\n", text)
+}
+
+func TestGetToDoSearchURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/search?type=issues&q=is%3Apr+is%3Aopen+review-requested%3Auser+archived%3Afalse",
+		getToDoSearchURL("https://github.com/", "is:pr is:open review-requested:user archived:false "))
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	tokenHealthCheckJobKey = "token_health_check"
+
+	// tokenHealthCheckInterval is how often the stored tokens are checked.
+	tokenHealthCheckInterval = 24 * time.Hour
+)
+
+// checkAllTokens checks the stored tokens of all the connected users, and disconnects
+// the users whose token was revoked or expired.
+func (p *Plugin) checkAllTokens() {
+	keys, err := p.listUserInfoKeys()
+	if err != nil {
+		p.client.Log.Warn("Failed to list the stored tokens", "error", err.Error())
+		return
+	}
+
+	ctx := withRateLimitRetries(p.lifetimeCtx)
+	for _, key := range keys {
+		userID, instance := parseUserInfoKey(key)
+		if err := p.checkUserToken(ctx, userID, instance); err != nil {
+			p.client.Log.Warn("Failed to check the GitHub token", "userID", userID, "instance", instance, "error", err.Error())
+		}
+
+		// the job stops when the plugin is deactivated.
+		select {
+		case <-p.lifetimeCtx.Done():
+			return
+		case <-time.After(delayBetweenUsers):
+		}
+	}
+}
+
+// checkUserToken disconnects the user if their token isn't valid anymore and asks them to reconnect.
+func (p *Plugin) checkUserToken(ctx context.Context, userID, instance string) error {
+	info, apiErr := p.getGitHubUserInfoForInstance(userID, instance)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	// the token is checked again at the next run.
+	if !p.hasGitHubQuota(ctx, info) {
+		return nil
+	}
+
+	githubClient := p.githubConnectUser(ctx, info)
+	_, resp, err := githubClient.Users.Get(ctx, "")
+	if err == nil {
+		return nil
+	}
+	if !isTokenRevokedError(resp, err) {
+		return errors.Wrap(err, "failed to get the authenticated user")
+	}
+
+	p.client.Log.Info("Disconnecting user with an invalid GitHub token", "userID", userID, "instance", instance, "github_username", info.GitHubUsername)
+	if err := p.disconnectGitHubAccountForInstance(userID, instance); err != nil {
+		return err
+	}
+
+	// the user was already asked to connect again when their refresh token was rejected.
+	if isRefreshTokenRejected(err) {
+		return nil
+	}
+
+	connectCommand := "`/github connect" + getInstanceCommandSuffix(instance) + "`"
+	if info.AllowedPrivateRepos {
+		connectCommand = "`/github connect private" + getInstanceCommandSuffix(instance) + "`"
+	}
+	p.CreateBotDMPost(userID, "Your GitHub account was disconnected because its access token was revoked or expired. To reconnect your account, use the following slash command: "+connectCommand+".", "custom_git_token_revoked")
+
+	return nil
+}
+
+// isTokenRevokedError returns true if GitHub rejected the token of the request, or refused to refresh it.
+func isTokenRevokedError(resp *github.Response, err error) bool {
+	if isRefreshTokenRejected(err) {
+		return true
+	}
+
+	return resp != nil && resp.Response != nil && resp.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestCheckUserToken(t *testing.T) {
+	tcs := []struct {
+		name             string
+		status           int
+		refreshStatus    int
+		refreshResponse  string
+		expectErr        bool
+		expectDisconnect bool
+	}{
+		{
+			name:   "valid token",
+			status: http.StatusOK,
+		},
+		{
+			name:             "revoked token",
+			status:           http.StatusUnauthorized,
+			expectDisconnect: true,
+		},
+		{
+			name:      "GitHub error",
+			status:    http.StatusInternalServerError,
+			expectErr: true,
+		},
+		{
+			name:             "rejected refresh token",
+			refreshStatus:    http.StatusOK,
+			refreshResponse:  `{"error":"bad_refresh_token"}`,
+			expectDisconnect: true,
+		},
+		{
+			name:            "failure of the token endpoint",
+			refreshStatus:   http.StatusBadGateway,
+			refreshResponse: `bad gateway`,
+			expectErr:       true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/login/oauth/access_token" {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(tc.refreshStatus)
+					_, _ = w.Write([]byte(tc.refreshResponse))
+					return
+				}
+
+				if r.URL.Path == "/api/v3/rate_limit" {
+					writeTestRateLimits(w, 4000)
+					return
+				}
+
+				assert.Equal(t, "/api/v3/user", r.URL.Path)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"login":"gh-user"}`))
+			}))
+			defer ts.Close()
+
+			p := NewPlugin()
+			p.setConfiguration(&Configuration{
+				EncryptionKey:       "abcdefghijklmnopqrstuvwxyz012345",
+				EnterpriseBaseURL:   ts.URL,
+				EnterpriseUploadURL: ts.URL,
+			})
+			p.BotUserID = "bot"
+			p.store = &pluginapi.MemoryStore{}
+
+			api := &plugintest.API{}
+			api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+			api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("GetUser", "user1").Return(&model.User{Id: "user1", Props: model.StringMap{}}, nil).Maybe()
+			api.On("PublishWebSocketEvent", wsEventDisconnect, mock.Anything, mock.Anything).Maybe()
+			api.On("GetDirectChannel", "user1", "bot").Return(&model.Channel{Id: "dm"}, nil).Maybe()
+			api.On("CreatePost", mock.Anything).Return(&model.Post{}, nil).Maybe()
+			p.SetAPI(api)
+			p.client = pluginapi.NewClient(p.API, p.Driver)
+
+			token := &oauth2.Token{AccessToken: "token"}
+			if tc.refreshStatus != 0 {
+				token = &oauth2.Token{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+			}
+			require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+				UserID:         "user1",
+				GitHubUsername: "gh-user",
+				Token:          token,
+			}))
+
+			err := p.checkUserToken(context.Background(), "user1", "")
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			info, apiErr := p.getGitHubUserInfo("user1")
+			if tc.expectDisconnect {
+				assert.Nil(t, info)
+				require.NotNil(t, apiErr)
+				assert.Equal(t, apiErrorIDNotConnected, apiErr.ID)
+				api.AssertNumberOfCalls(t, "CreatePost", 1)
+			} else {
+				assert.NotNil(t, info)
+				api.AssertNumberOfCalls(t, "CreatePost", 0)
+			}
+		})
+	}
+}
+
+func TestCheckUserTokenOnInstance(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{
+		EncryptionKey:   "abcdefghijklmnopqrstuvwxyz012345",
+		GitHubInstances: `[{"name": "ghes", "base_url": "` + ts.URL + `", "oauth_client_id": "id", "oauth_client_secret": "secret", "webhook_secret": "secret"}]`,
+	})
+	p.BotUserID = "bot"
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("PublishWebSocketEvent", wsEventDisconnect, map[string]interface{}{"instance": "ghes"}, &model.WebsocketBroadcast{UserId: "user1"}).Once()
+	api.On("GetDirectChannel", "user1", "bot").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("CreatePost", mock.Anything).Return(&model.Post{}, nil).Once()
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+		UserID:         "user1",
+		GitHubUsername: "gh-user",
+		Instance:       "ghes",
+		Token:          &oauth2.Token{AccessToken: "token"},
+	}))
+	require.NoError(t, p.storeGitHubToUserIDMapping("gh-user", "ghes", "user1"))
+
+	require.NoError(t, p.checkUserToken(context.Background(), "user1", "ghes"))
+
+	info, apiErr := p.getGitHubUserInfoForInstance("user1", "ghes")
+	assert.Nil(t, info)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apiErrorIDNotConnected, apiErr.ID)
+	// webhook events of the GitHub account aren't sent to the user anymore.
+	assert.Empty(t, p.getGitHubToUserIDMappingForInstance("gh-user", "ghes"))
+	api.AssertExpectations(t)
+}
+
+func TestCheckAllTokensStopsWhenDeactivated(t *testing.T) {
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{EncryptionKey: "abcdefghijklmnopqrstuvwxyz012345"})
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("LogDebug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	for _, userID := range []string{"user1", "user2"} {
+		require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{
+			UserID:         userID,
+			GitHubUsername: "gh-" + userID,
+			Token:          &oauth2.Token{AccessToken: "token"},
+		}))
+	}
+
+	p.cancelLifetime()
+
+	start := time.Now()
+	p.checkAllTokens()
+	assert.Less(t, time.Since(start), delayBetweenUsers)
+}
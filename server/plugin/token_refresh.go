@@ -0,0 +1,174 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	githubTokenRefreshMutexKey  = "_githubtokenrefresh"
+	githubTokenRefreshFailedKey = "_githubtokenrefreshfailed"
+
+	// tokenRefreshFailedNotificationTTL is how long users aren't notified again after a failed token refresh.
+	tokenRefreshFailedNotificationTTL = 24 * time.Hour
+)
+
+// refreshingTokenSource refreshes the expiring user-to-server token of a user and persists the new token,
+// as the refresh tokens issued by GitHub Apps can only be used once.
+type refreshingTokenSource struct {
+	p     *Plugin
+	ctx   context.Context
+	info  *GitHubUserInfo
+	lock  sync.Mutex
+	token *oauth2.Token
+}
+
+// getUserTokenSource returns the token source of a user. Tokens without refresh token are returned as is.
+func (p *Plugin) getUserTokenSource(ctx context.Context, info *GitHubUserInfo) oauth2.TokenSource {
+	tok := *info.Token
+	if tok.RefreshToken == "" {
+		return oauth2.StaticTokenSource(&tok)
+	}
+
+	return &refreshingTokenSource{
+		p:     p,
+		ctx:   ctx,
+		info:  info,
+		token: &tok,
+	}
+}
+
+// Token returns the current token of the user, refreshing it if it expired.
+func (s *refreshingTokenSource) Token() (*oauth2.Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	token, err := s.p.refreshUserToken(s.ctx, s.info)
+	if err != nil {
+		return nil, err
+	}
+
+	// the user info is shared by the callers, the ones storing it read it again with updateGitHubUserInfo.
+	s.token = token
+	return token, nil
+}
+
+// updateGitHubUserInfo applies the update to the stored account of the user and stores it. The account is read
+// again under the refresh mutex of the user, so a token refreshed in the meantime isn't rolled back.
+func (p *Plugin) updateGitHubUserInfo(userID, instance string, update func(info *GitHubUserInfo)) error {
+	m, err := cluster.NewMutex(p.API, getUserInfoKey(userID, instance)+githubTokenRefreshMutexKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to create mutex")
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	info, apiErr := p.getGitHubUserInfoForInstance(userID, instance)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	update(info)
+	return p.storeGitHubUserInfo(info)
+}
+
+// refreshUserToken refreshes the token of a user and stores it. A cluster mutex makes sure a refresh token is
+// used only once, and the token refreshed by another plugin instance is used if there is one.
+func (p *Plugin) refreshUserToken(ctx context.Context, info *GitHubUserInfo) (*oauth2.Token, error) {
+	m, err := cluster.NewMutex(p.API, getUserInfoKey(info.UserID, info.Instance)+githubTokenRefreshMutexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create mutex")
+	}
+	if err = m.LockWithContext(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to lock mutex")
+	}
+	defer m.Unlock()
+
+	storedInfo, apiErr := p.getGitHubUserInfoForInstance(info.UserID, info.Instance)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if storedInfo.Token.Valid() {
+		// another plugin instance already refreshed the token.
+		return storedInfo.Token, nil
+	}
+
+	config, err := p.getConfiguration().forInstance(storedInfo.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := p.getOAuthConfigWithConfig(config, storedInfo.AllowedPrivateRepos)
+	token, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: storedInfo.Token.RefreshToken}).Token()
+	if err != nil {
+		if isRefreshTokenRejected(err) {
+			// the user needs to connect again.
+			p.notifyTokenRefreshFailed(storedInfo)
+		}
+		return nil, errors.Wrap(err, "failed to refresh the token")
+	}
+
+	storedInfo.Token = token
+	refreshed := *token
+	if err := p.storeGitHubUserInfo(storedInfo); err != nil {
+		return nil, errors.Wrap(err, "failed to store the refreshed token")
+	}
+
+	return &refreshed, nil
+}
+
+// isRefreshTokenRejected returns true if GitHub refused to refresh the token for good. A failure of the token
+// endpoint, like a 5xx response, isn't a rejection.
+func isRefreshTokenRejected(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return false
+	}
+
+	if retrieveErr.Response != nil && retrieveErr.Response.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	return retrieveErr.ErrorCode == "bad_refresh_token" || retrieveErr.ErrorCode == "invalid_grant"
+}
+
+// getTokenRefreshFailedKey returns the key set when the token of a user on a GitHub instance couldn't be refreshed.
+func getTokenRefreshFailedKey(userID, instance string) string {
+	if instance == "" {
+		return userID + githubTokenRefreshFailedKey
+	}
+
+	return userID + "_" + instance + githubTokenRefreshFailedKey
+}
+
+// notifyTokenRefreshFailed asks the user to reconnect their account. The user is notified at most once a day.
+func (p *Plugin) notifyTokenRefreshFailed(info *GitHubUserInfo) {
+	notify, err := p.store.Set(getTokenRefreshFailedKey(info.UserID, info.Instance), []byte("1"), pluginapi.SetAtomic(nil), pluginapi.SetExpiry(tokenRefreshFailedNotificationTTL))
+	if err != nil {
+		p.client.Log.Warn("Failed to store the token refresh failure", "userID", info.UserID, "instance", info.Instance, "error", err.Error())
+		return
+	}
+	if !notify {
+		return
+	}
+
+	connectCommand := "/github connect"
+	if info.AllowedPrivateRepos {
+		connectCommand = "/github connect private"
+	}
+	instanceSuffix := getInstanceCommandSuffix(info.Instance)
+	message := "Your GitHub access token expired and couldn't be renewed. To reconnect your account, use the following slash commands: `/github disconnect" + instanceSuffix + "` followed by `" + connectCommand + instanceSuffix + "`."
+	p.CreateBotDMPost(info.UserID, message, "custom_git_token_refresh_failed")
+}
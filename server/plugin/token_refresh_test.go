@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestRefreshUserToken(t *testing.T) {
+	tcs := []struct {
+		name           string
+		storedToken    *oauth2.Token
+		tokenResponse  string
+		tokenStatus    int
+		expectErr      bool
+		expectToken    string
+		expectDMs      int
+		expectRequests int
+	}{
+		{
+			name:           "expired token is refreshed",
+			storedToken:    &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)},
+			tokenResponse:  `{"access_token":"new","refresh_token":"new-refresh","expires_in":28800,"token_type":"bearer"}`,
+			tokenStatus:    http.StatusOK,
+			expectToken:    "new",
+			expectRequests: 1,
+		},
+		{
+			name:        "token already refreshed by another instance",
+			storedToken: &oauth2.Token{AccessToken: "refreshed", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)},
+			expectToken: "refreshed",
+		},
+		{
+			name:           "rejected refresh token notifies the user once",
+			storedToken:    &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)},
+			tokenResponse:  `{"error":"bad_refresh_token"}`,
+			tokenStatus:    http.StatusBadRequest,
+			expectErr:      true,
+			expectDMs:      1,
+			expectRequests: 2,
+		},
+		{
+			name:           "failure of the token endpoint doesn't notify the user",
+			storedToken:    &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)},
+			tokenResponse:  `bad gateway`,
+			tokenStatus:    http.StatusBadGateway,
+			expectErr:      true,
+			expectRequests: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/login/oauth/access_token", r.URL.Path)
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.tokenStatus)
+				_, _ = w.Write([]byte(tc.tokenResponse))
+			}))
+			defer ts.Close()
+
+			p := NewPlugin()
+			p.setConfiguration(&Configuration{
+				EncryptionKey:     "abcdefghijklmnopqrstuvwxyz012345",
+				EnterpriseBaseURL: ts.URL,
+			})
+			p.BotUserID = "bot"
+			p.store = &pluginapi.MemoryStore{}
+
+			api := &plugintest.API{}
+			api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+			api.On("GetDirectChannel", "user1", "bot").Return(&model.Channel{Id: "dm"}, nil).Maybe()
+			api.On("CreatePost", mock.Anything).Return(&model.Post{}, nil).Maybe()
+			p.SetAPI(api)
+			p.client = pluginapi.NewClient(p.API, p.Driver)
+
+			require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{UserID: "user1", Token: tc.storedToken}))
+			info := &GitHubUserInfo{UserID: "user1", Token: &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}}
+
+			// refresh twice to check that the user is notified only once.
+			for i := 0; i < 2; i++ {
+				token, err := p.refreshUserToken(context.Background(), info)
+				if tc.expectErr {
+					assert.Error(t, err)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectToken, token.AccessToken)
+			}
+
+			assert.Equal(t, tc.expectRequests, requests)
+			api.AssertNumberOfCalls(t, "CreatePost", tc.expectDMs)
+
+			if tc.expectErr {
+				return
+			}
+			storedInfo, apiErr := p.getGitHubUserInfo("user1")
+			require.Nil(t, apiErr)
+			assert.Equal(t, tc.expectToken, storedInfo.Token.AccessToken)
+		})
+	}
+}
+
+func TestUpdateGitHubUserInfoAfterRefresh(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new","refresh_token":"new-refresh","expires_in":28800,"token_type":"bearer"}`))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{
+		EncryptionKey:     "abcdefghijklmnopqrstuvwxyz012345",
+		EnterpriseBaseURL: ts.URL,
+	})
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	expired := &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+	require.NoError(t, p.storeGitHubUserInfo(&GitHubUserInfo{UserID: "user1", Token: expired}))
+	info, apiErr := p.getGitHubUserInfo("user1")
+	require.Nil(t, apiErr)
+
+	token, err := p.getUserTokenSource(context.Background(), info).Token()
+	require.NoError(t, err)
+	assert.Equal(t, "new", token.AccessToken)
+
+	// the user info shared by the callers isn't changed, updating it keeps the new refresh token.
+	assert.Equal(t, "refresh", info.Token.RefreshToken)
+	require.NoError(t, p.updateGitHubUserInfo("user1", "", func(info *GitHubUserInfo) {
+		info.LastToDoPostAt = 1
+	}))
+	storedInfo, apiErr := p.getGitHubUserInfo("user1")
+	require.Nil(t, apiErr)
+	assert.Equal(t, "new-refresh", storedInfo.Token.RefreshToken)
+	assert.Equal(t, int64(1), storedInfo.LastToDoPostAt)
+}
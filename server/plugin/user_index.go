@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/pkg/errors"
+)
+
+// userIndexShards is the number of keys an index is split into, so a single key doesn't hold all the users
+// and the users added at the same time don't all update the same key.
+const userIndexShards = 16
+
+func getUserIndexShardKey(indexKey string, shard uint32) string {
+	return fmt.Sprintf("%s_%d", indexKey, shard)
+}
+
+func getUserIndexShard(userID string) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(userID))
+	return hash.Sum32() % userIndexShards
+}
+
+// listIndexedUserIDs returns the users stored in the index with the given key. The indexes list the users
+// a background job works for, so the job doesn't go through all the keys of the plugin.
+func (p *Plugin) listIndexedUserIDs(indexKey string) ([]string, error) {
+	var userIDs []string
+	for shard := uint32(0); shard < userIndexShards; shard++ {
+		var shardUserIDs []string
+		if err := p.store.Get(getUserIndexShardKey(indexKey, shard), &shardUserIDs); err != nil {
+			return nil, errors.Wrap(err, "failed to get the indexed users")
+		}
+		userIDs = append(userIDs, shardUserIDs...)
+	}
+
+	return userIDs, nil
+}
+
+// setUserIndexed adds the user to the index with the given key, or removes them.
+func (p *Plugin) setUserIndexed(indexKey, userID string, indexed bool) error {
+	shardKey := getUserIndexShardKey(indexKey, getUserIndexShard(userID))
+	err := p.store.SetAtomicWithRetries(shardKey, func(oldValue []byte) (interface{}, error) {
+		var userIDs []string
+		if len(oldValue) > 0 {
+			if err := json.Unmarshal(oldValue, &userIDs); err != nil {
+				return nil, err
+			}
+		}
+
+		for i, id := range userIDs {
+			if id != userID {
+				continue
+			}
+			if indexed {
+				return userIDs, nil
+			}
+			return append(userIDs[:i], userIDs[i+1:]...), nil
+		}
+
+		if indexed {
+			userIDs = append(userIDs, userID)
+		}
+		return userIDs, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the indexed users")
+	}
+
+	return nil
+}
@@ -2,7 +2,6 @@ package plugin
 
 import (
 	"bufio"
-	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -23,18 +22,6 @@ func getMentionSearchQuery(username string, orgs []string) string {
 	return buildSearchQuery("is:open mentions:%v archived:false %v", username, orgs)
 }
 
-func getReviewSearchQuery(username string, orgs []string) string {
-	return buildSearchQuery("is:pr is:open review-requested:%v archived:false %v", username, orgs)
-}
-
-func getYourPrsSearchQuery(username string, orgs []string) string {
-	return buildSearchQuery("is:pr is:open author:%v archived:false %v", username, orgs)
-}
-
-func getYourAssigneeSearchQuery(username string, orgs []string) string {
-	return buildSearchQuery("is:open assignee:%v archived:false %v", username, orgs)
-}
-
 func getIssuesSearchQuery(org, searchTerm string) string {
 	query := "is:open is:issue archived:false %v %v"
 	orgField := ""
@@ -56,14 +43,15 @@ func buildSearchQuery(query, username string, orgs []string) string {
 	return fmt.Sprintf(query, username, orgField)
 }
 
-func pad(src []byte) []byte {
-	padding := aes.BlockSize - len(src)%aes.BlockSize
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(src, padtext...)
-}
+// encryptionPrefixGCM marks the values encrypted with AES-GCM. Values without prefix were encrypted
+// with AES-CFB by previous versions of the plugin and are only decrypted.
+const encryptionPrefixGCM = "v2:"
 
 func unpad(src []byte) ([]byte, error) {
 	length := len(src)
+	if length == 0 {
+		return nil, errors.New("unpad error. The message is empty")
+	}
 	unpadding := int(src[length-1])
 
 	if unpadding > length {
@@ -79,20 +67,56 @@ func encrypt(key []byte, text string) (string, error) {
 		return "", errors.Wrap(err, "could not create a cipher block, check key")
 	}
 
-	msg := pad([]byte(text))
-	ciphertext := make([]byte, aes.BlockSize+len(msg))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", errors.Wrap(err, "could not create the GCM cipher")
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", errors.Wrap(err, "readFull was unsuccessful, check buffer size")
 	}
 
-	cfb := cipher.NewCFBEncrypter(block, iv)
-	cfb.XORKeyStream(ciphertext[aes.BlockSize:], msg)
-	finalMsg := base64.URLEncoding.EncodeToString(ciphertext)
-	return finalMsg, nil
+	ciphertext := gcm.Seal(nonce, nonce, []byte(text), nil)
+	return encryptionPrefixGCM + base64.URLEncoding.EncodeToString(ciphertext), nil
 }
 
 func decrypt(key []byte, text string) (string, error) {
+	if !isEncryptedWithGCM(text) {
+		return decryptCFB(key, text)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", errors.Wrap(err, "could not create a cipher block, check key")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", errors.Wrap(err, "could not create the GCM cipher")
+	}
+
+	decodedMsg, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(text, encryptionPrefixGCM))
+	if err != nil {
+		return "", errors.Wrap(err, "could not decode the message")
+	}
+
+	if len(decodedMsg) < gcm.NonceSize() {
+		return "", errors.New("the message is shorter than the nonce")
+	}
+
+	nonce, ciphertext := decodedMsg[:gcm.NonceSize()], decodedMsg[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "could not decrypt the message, check key")
+	}
+
+	return string(plaintext), nil
+}
+
+// decryptCFB decrypts the values encrypted with AES-CFB by previous versions of the plugin.
+// As the values aren't authenticated, a wrong key isn't always detected.
+func decryptCFB(key []byte, text string) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", errors.Wrap(err, "could not create a cipher block, check key")
@@ -103,7 +127,7 @@ func decrypt(key []byte, text string) (string, error) {
 		return "", errors.Wrap(err, "could not decode the message")
 	}
 
-	if (len(decodedMsg) % aes.BlockSize) != 0 {
+	if len(decodedMsg) < aes.BlockSize || (len(decodedMsg)%aes.BlockSize) != 0 {
 		return "", errors.New("blocksize must be multiple of decoded message length")
 	}
 
@@ -121,6 +145,10 @@ func decrypt(key []byte, text string) (string, error) {
 	return string(unpadMsg), nil
 }
 
+func isEncryptedWithGCM(text string) bool {
+	return strings.HasPrefix(text, encryptionPrefixGCM)
+}
+
 func parseOwnerAndRepo(full, baseURL string) (string, string) {
 	full = strings.TrimSuffix(strings.TrimSpace(strings.Replace(full, baseURL, "", 1)), "/")
 	splitStr := strings.Split(full, "/")
@@ -135,6 +163,26 @@ func parseOwnerAndRepo(full, baseURL string) (string, string) {
 	return owner, repo
 }
 
+// parseIssueReference parses an issue or pull request reference of the form owner/repo#number.
+func parseIssueReference(ref string) (owner, repo string, number int, err error) {
+	repoPart, numberPart, found := strings.Cut(strings.TrimSpace(ref), "#")
+	if !found {
+		return "", "", 0, errors.New("reference must be of the form owner/repo#number")
+	}
+
+	owner, repo, err = parseRepo(repoPart)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	number, err = strconv.Atoi(numberPart)
+	if err != nil || number <= 0 {
+		return "", "", 0, errors.New("invalid issue or pull request number")
+	}
+
+	return owner, repo, number, nil
+}
+
 func parseGitHubUsernamesFromText(text string) []string {
 	usernameMap := map[string]bool{}
 	usernames := []string{}
@@ -298,12 +346,17 @@ func isInsideLink(msg string, index int) bool {
 	return false
 }
 
-// getCodeMarkdown returns the constructed markdown for a permalink.
-func getCodeMarkdown(user, repo, repoPath, word, lines string, isTruncated bool) string {
+// getCodeMarkdown returns the constructed markdown for a permalink. The note, if any, is shown after the
+// link to the file.
+func getCodeMarkdown(user, repo, repoPath, word, lines, note string, isTruncated bool) string {
 	user = strings.ReplaceAll(user, "_", "\\_")
 	repo = strings.ReplaceAll(repo, "_", "\\_")
 	repoPath = strings.ReplaceAll(repoPath, "_", "\\_")
-	final := fmt.Sprintf("\n[%s/%s/%s](%s)\n", user, repo, repoPath, word)
+	final := fmt.Sprintf("\n[%s/%s/%s](%s)", user, repo, repoPath, word)
+	if note != "" {
+		final += " " + note
+	}
+	final += "\n"
 	ext := path.Ext(repoPath)
 	// remove the preceding dot
 	if len(ext) > 1 {
@@ -87,6 +87,39 @@ func TestParseOwnerAndRepo(t *testing.T) {
 	}
 }
 
+func TestParseIssueReference(t *testing.T) {
+	tcs := []struct {
+		Ref            string
+		ExpectedOwner  string
+		ExpectedRepo   string
+		ExpectedNumber int
+		ExpectError    bool
+	}{
+		{Ref: "mattermost/mattermost-server#123", ExpectedOwner: "mattermost", ExpectedRepo: "mattermost-server", ExpectedNumber: 123},
+		{Ref: " mattermost/mattermost-server#1 ", ExpectedOwner: "mattermost", ExpectedRepo: "mattermost-server", ExpectedNumber: 1},
+		{Ref: "mattermost/mattermost-server", ExpectError: true},
+		{Ref: "mattermost#123", ExpectError: true},
+		{Ref: "mattermost/mattermost-server#abc", ExpectError: true},
+		{Ref: "mattermost/mattermost-server#0", ExpectError: true},
+		{Ref: "#123", ExpectError: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Ref, func(t *testing.T) {
+			owner, repo, number, err := parseIssueReference(tc.Ref)
+			if tc.ExpectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedOwner, owner)
+			assert.Equal(t, tc.ExpectedRepo, repo)
+			assert.Equal(t, tc.ExpectedNumber, number)
+		})
+	}
+}
+
 func TestIsFlag(t *testing.T) {
 	tcs := []struct {
 		Text     string
@@ -0,0 +1,180 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/pkg/errors"
+)
+
+// handleWatch manages the watches of the user. A watch is a subscription of the direct message channel
+// between the user and the bot, so its events are delivered like the ones of any subscription. The events
+// of private repositories are only delivered while the user can access them with their own account.
+func (p *Plugin) handleWatch(_ *plugin.Context, args *model.CommandArgs, parameters []string, userInfo *GitHubUserInfo) string {
+	if len(parameters) == 0 {
+		return "Please specify a repository or the 'list' or 'delete' command."
+	}
+
+	switch parameters[0] {
+	case "add":
+		return p.handleWatchAdd(args.UserId, parameters[1:], userInfo)
+	case "list":
+		return p.handleWatchList(args.UserId)
+	case "delete":
+		return p.handleWatchDelete(args.UserId, parameters[1:])
+	default:
+		return p.handleWatchAdd(args.UserId, parameters, userInfo)
+	}
+}
+
+// getWatchChannelID returns the ID of the direct message channel the watches of the user are delivered to.
+func (p *Plugin) getWatchChannelID(userID string) (string, error) {
+	channel, err := p.client.Channel.GetDirect(userID, p.BotUserID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the bot's DM channel")
+	}
+
+	return channel.Id, nil
+}
+
+func (p *Plugin) handleWatchAdd(userID string, parameters []string, userInfo *GitHubUserInfo) string {
+	const usage = "Please use the correct format: `/github watch owner[/repo] [features] [--<name> <value>]`"
+	if len(parameters) == 0 || isFlag(parameters[0]) {
+		return usage
+	}
+
+	features := defaultSubscriptionFeatures
+	flagParams := parameters[1:]
+	if len(flagParams) > 0 && !isFlag(flagParams[0]) {
+		features = Features(flagParams[0])
+		flagParams = flagParams[1:]
+	}
+
+	// the filters of a watch are the ones of the subscriptions.
+	flags := SubscriptionFlags{}
+	if len(flagParams)%2 != 0 {
+		return usage
+	}
+	for i := 0; i < len(flagParams); i += 2 {
+		flag, value := flagParams[i], flagParams[i+1]
+		if !isFlag(flag) {
+			return usage
+		}
+
+		parsedFlag := parseFlag(flag)
+		if parsedFlag == flagFeatures {
+			features = Features(value)
+			continue
+		}
+		if err := flags.AddFlag(parsedFlag, value); err != nil {
+			return fmt.Sprintf("Unsupported value for flag %s", flag)
+		}
+	}
+
+	if msg := checkFeatures(features); msg != "" {
+		return msg
+	}
+
+	instance := flags.Instance
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		return fmt.Sprintf("Unknown GitHub instance `%s`.", instance)
+	}
+
+	// the repositories of an additional GitHub instance are watched with the account connected to that instance.
+	if instance != "" {
+		instanceInfo, apiErr := p.getGitHubUserInfoForInstance(userID, instance)
+		if apiErr != nil {
+			return fmt.Sprintf("You must connect your account to the `%s` GitHub instance first with `/github connect%s`.", instance, getInstanceCommandSuffix(instance))
+		}
+		userInfo = instanceInfo
+	}
+
+	owner, repo := parseOwnerAndRepo(parameters[0], config.getBaseURL())
+	if owner == "" {
+		return "Please specify a repository."
+	}
+
+	channelID, err := p.getWatchChannelID(userID)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the watch channel", "userID", userID, "error", err.Error())
+		return "Encountered an error watching the repository."
+	}
+
+	ctx := context.Background()
+	githubClient := p.githubConnectUser(ctx, userInfo)
+	if err = p.Subscribe(ctx, githubClient, userID, owner, repo, channelID, features, flags); err != nil {
+		return errors.Wrap(err, "failed to watch the repository").Error()
+	}
+
+	name := fullNameFromOwnerAndRepo(strings.ToLower(owner), strings.ToLower(repo))
+	link := config.getBaseURL() + strings.Trim(name, "/")
+	return fmt.Sprintf("You are now watching [%s](%s) with the following events: %s. They will be sent to you in a direct message.", strings.Trim(name, "/"), link, features.FormattedString())
+}
+
+func (p *Plugin) handleWatchList(userID string) string {
+	channelID, err := p.getWatchChannelID(userID)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the watch channel", "userID", userID, "error", err.Error())
+		return "Encountered an error listing the watched repositories."
+	}
+
+	subs, err := p.GetSubscriptionsByChannel(channelID)
+	if err != nil {
+		return err.Error()
+	}
+
+	if len(subs) == 0 {
+		return "You are not watching any repository."
+	}
+
+	txt := "### Watched repositories\n"
+	for _, sub := range subs {
+		txt += fmt.Sprintf("* `%s` - %s", strings.Trim(sub.Repository, "/"), sub.Features.String())
+		if subFlags := sub.Flags.String(); subFlags != "" {
+			txt += " " + subFlags
+		}
+		txt += "\n"
+	}
+
+	return txt
+}
+
+func (p *Plugin) handleWatchDelete(userID string, parameters []string) string {
+	instance, parameters, err := parseInstanceFlag(parameters)
+	if err != nil {
+		return err.Error()
+	}
+
+	if len(parameters) == 0 {
+		return "Please specify a repository."
+	}
+
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		return fmt.Sprintf("Unknown GitHub instance `%s`.", instance)
+	}
+
+	owner, repo := parseOwnerAndRepo(parameters[0], config.getBaseURL())
+	if owner == "" {
+		return "Please specify a repository."
+	}
+
+	channelID, err := p.getWatchChannelID(userID)
+	if err != nil {
+		p.client.Log.Warn("Failed to get the watch channel", "userID", userID, "error", err.Error())
+		return "Encountered an error while removing the watch."
+	}
+
+	owner = strings.ToLower(owner)
+	repo = strings.ToLower(repo)
+	if err := p.Unsubscribe(channelID, repo, owner, instance); err != nil {
+		p.client.Log.Warn("Failed to stop watching", "repo", repo, "error", err.Error())
+		return "Encountered an error while removing the watch. Please try again."
+	}
+
+	return fmt.Sprintf("You are no longer watching %s.", strings.Trim(fullNameFromOwnerAndRepo(owner, repo), "/"))
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestHandleWatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/owner/repo" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"full_name": "owner/repo"}`))
+	}))
+	defer ts.Close()
+
+	p := NewPlugin()
+	p.setConfiguration(&Configuration{
+		EncryptionKey:       "abcdefghijklmnopqrstuvwxyz012345",
+		EnterpriseBaseURL:   ts.URL,
+		EnterpriseUploadURL: ts.URL,
+	})
+	p.BotUserID = "bot"
+	p.store = &pluginapi.MemoryStore{}
+
+	api := &plugintest.API{}
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("GetDirectChannel", "user1", "bot").Return(&model.Channel{Id: "dm"}, nil)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(p.API, p.Driver)
+
+	info := &GitHubUserInfo{
+		UserID:         "user1",
+		GitHubUsername: "gh-user",
+		Token:          &oauth2.Token{AccessToken: "token"},
+	}
+	require.NoError(t, p.storeGitHubUserInfo(info))
+
+	args := &model.CommandArgs{UserId: "user1", ChannelId: "town-square"}
+
+	assert.Equal(t, "You are not watching any repository.", p.handleWatch(nil, args, []string{"list"}, info))
+	assert.Equal(t, "Invalid feature(s) provided: unknown", p.handleWatch(nil, args, []string{"owner/repo", "unknown"}, info))
+	assert.Contains(t, p.handleWatch(nil, args, []string{"owner/missing"}, info), "unknown repository owner/missing")
+
+	msg := p.handleWatch(nil, args, []string{"owner/repo", "pulls,pushes"}, info)
+	assert.Contains(t, msg, "You are now watching [owner/repo]")
+	assert.Equal(t, "### Watched repositories\n* `owner/repo` - pulls,pushes\n", p.handleWatch(nil, args, []string{"list"}, info))
+
+	// the events are delivered to the DM channel, not to the channel the command was run in.
+	subs := p.GetSubscribedChannelsForRepository(&github.Repository{FullName: github.String("owner/repo")}, "")
+	require.Len(t, subs, 1)
+	assert.Equal(t, "dm", subs[0].ChannelID)
+	assert.Equal(t, "user1", subs[0].CreatorID)
+	assert.True(t, subs[0].Pushes())
+
+	assert.Equal(t, "You are no longer watching owner/repo.", p.handleWatch(nil, args, []string{"delete", "owner/repo"}, info))
+	assert.Equal(t, "You are not watching any repository.", p.handleWatch(nil, args, []string{"list"}, info))
+
+	// the filters of the subscriptions are stored on the watch.
+	msg = p.handleWatch(nil, args, []string{"owner/repo", "--features", `issues,label:"bug"`, "--render-style", "collapsed"}, info)
+	assert.Contains(t, msg, "You are now watching [owner/repo]")
+	assert.Equal(t, "### Watched repositories\n* `owner/repo` - issues,label:\"bug\" --render-style collapsed\n", p.handleWatch(nil, args, []string{"list"}, info))
+
+	subs = p.GetSubscribedChannelsForRepository(&github.Repository{FullName: github.String("owner/repo")}, "")
+	require.Len(t, subs, 1)
+	assert.Equal(t, "bug", subs[0].Label())
+	assert.Equal(t, "collapsed", subs[0].Flags.RenderStyle)
+
+	assert.Contains(t, p.handleWatch(nil, args, []string{"owner/repo", "--render-style"}, info), "Please use the correct format")
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v54/github"
+	"github.com/gorilla/mux"
 	"github.com/microcosm-cc/bluemonday"
 
 	"github.com/mattermost/mattermost/server/public/model"
@@ -107,9 +108,11 @@ func GetEventWithRenderConfig(event interface{}, sub *Subscription) *EventWithRe
 func ConvertPushEventRepositoryToRepository(pushRepo *github.PushEventRepository) *github.Repository {
 	repoName := pushRepo.GetFullName()
 	private := pushRepo.GetPrivate()
+	htmlURL := pushRepo.GetHTMLURL()
 	return &github.Repository{
 		FullName: &repoName,
 		Private:  &private,
+		HTMLURL:  &htmlURL,
 	}
 }
 
@@ -185,7 +188,14 @@ func (wb *WebhookBroker) Close() {
 }
 
 func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request) {
-	config := p.getConfiguration()
+	// the webhooks of the additional GitHub instances are delivered to /webhook/{instance}.
+	instance := mux.Vars(r)["instance"]
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		http.Error(w, "Unknown GitHub instance", http.StatusNotFound)
+		return
+	}
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "Bad request body", http.StatusBadRequest)
@@ -222,8 +232,12 @@ func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request) {
 		p.client.Log.Debug("Webhook Event Log", "event", string(bodyByte))
 	}
 
+	p.invalidateGitHubCacheForEvent(event)
+
 	var repo *github.Repository
 	var handler func()
+	// notify sends the DM notifications to the users connected to the GitHub instance.
+	var notify func()
 
 	switch event := event.(type) {
 	case *github.PingEvent:
@@ -233,69 +247,77 @@ func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	case *github.PullRequestEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postPullRequestEvent(event)
-			p.handlePullRequestNotification(event)
-			p.handlePRDescriptionMentionNotification(event)
+			p.postPullRequestEvent(event, instance)
+		}
+		notify = func() {
+			p.handlePullRequestNotification(event, instance)
+			p.handlePRDescriptionMentionNotification(event, instance)
 		}
 	case *github.IssuesEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postIssueEvent(event)
-			p.handleIssueNotification(event)
+			p.postIssueEvent(event, instance)
+		}
+		notify = func() {
+			p.handleIssueNotification(event, instance)
 		}
 	case *github.IssueCommentEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postIssueCommentEvent(event)
-			p.handleCommentMentionNotification(event)
-			p.handleCommentAuthorNotification(event)
-			p.handleCommentAssigneeNotification(event)
+			p.postIssueCommentEvent(event, instance)
+		}
+		notify = func() {
+			p.handleCommentMentionNotification(event, instance)
+			p.handleCommentAuthorNotification(event, instance)
+			p.handleCommentAssigneeNotification(event, instance)
 		}
 	case *github.PullRequestReviewEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postPullRequestReviewEvent(event)
-			p.handlePullRequestReviewNotification(event)
+			p.postPullRequestReviewEvent(event, instance)
+		}
+		notify = func() {
+			p.handlePullRequestReviewNotification(event, instance)
 		}
 	case *github.PullRequestReviewCommentEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postPullRequestReviewCommentEvent(event)
+			p.postPullRequestReviewCommentEvent(event, instance)
 		}
 	case *github.PushEvent:
 		repo = ConvertPushEventRepositoryToRepository(event.GetRepo())
 		handler = func() {
-			p.postPushEvent(event)
+			p.postPushEvent(event, instance)
 		}
 	case *github.CreateEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postCreateEvent(event)
+			p.postCreateEvent(event, instance)
 		}
 	case *github.DeleteEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postDeleteEvent(event)
+			p.postDeleteEvent(event, instance)
 		}
 	case *github.StarEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postStarEvent(event)
+			p.postStarEvent(event, instance)
 		}
 	case *github.ReleaseEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postReleaseEvent(event)
+			p.postReleaseEvent(event, instance)
 		}
 	case *github.DiscussionEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postDiscussionEvent(event)
+			p.postDiscussionEvent(event, instance)
 		}
 	case *github.DiscussionCommentEvent:
 		repo = event.GetRepo()
 		handler = func() {
-			p.postDiscussionCommentEvent(event)
+			p.postDiscussionCommentEvent(event, instance)
 		}
 	}
 
@@ -308,14 +330,22 @@ func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	handler()
+
+	if notify != nil {
+		notify()
+	}
 }
 
-func (p *Plugin) permissionToRepo(userID string, ownerAndRepo string) bool {
+// permissionToRepoForInstance checks that the user connected to the GitHub instance has access to the repository.
+func (p *Plugin) permissionToRepoForInstance(userID, instance, ownerAndRepo string) bool {
 	if userID == "" {
 		return false
 	}
 
-	config := p.getConfiguration()
+	config, err := p.getConfiguration().forInstance(instance)
+	if err != nil {
+		return false
+	}
 
 	owner, repo := parseOwnerAndRepo(ownerAndRepo, config.getBaseURL())
 
@@ -323,15 +353,28 @@ func (p *Plugin) permissionToRepo(userID string, ownerAndRepo string) bool {
 		return false
 	}
 
-	if err := p.checkOrg(owner); err != nil {
+	if err := config.checkOrg(owner); err != nil {
 		return false
 	}
 
-	info, apiErr := p.getGitHubUserInfo(userID)
+	info, apiErr := p.getGitHubUserInfoForInstance(userID, instance)
 	if apiErr != nil {
 		return false
 	}
 	ctx := context.Background()
+
+	if config.IsGitHubAppConfigured() {
+		// check the access of the user with the installation token, so the check doesn't depend on the user token.
+		if installationClient, err := p.getInstallationClient(ctx, owner, repo); err == nil {
+			permission, _, err := installationClient.Repositories.GetPermissionLevel(ctx, owner, repo, info.GitHubUsername)
+			if err != nil {
+				p.client.Log.Warn("Failed to get the permission level of the user", "error", err.Error())
+				return false
+			}
+			return permission.GetPermission() != "none"
+		}
+	}
+
 	githubClient := p.githubConnectUser(ctx, info)
 
 	if result, _, err := githubClient.Repositories.Get(ctx, owner, repo); result == nil || err != nil {
@@ -349,22 +392,33 @@ func (p *Plugin) excludeConfigOrgMember(user *github.User, subscription *Subscri
 		return false
 	}
 
-	info, err := p.getGitHubUserInfo(subscription.CreatorID)
+	info, err := p.getGitHubUserInfoForInstance(subscription.CreatorID, subscription.Flags.Instance)
 	if err != nil {
 		p.client.Log.Warn("Failed to exclude org member", "error", err.Message)
 		return false
 	}
 
+	config, cErr := p.getConfiguration().forInstance(subscription.Flags.Instance)
+	if cErr != nil {
+		p.client.Log.Warn("Failed to exclude org member", "error", cErr.Error())
+		return false
+	}
+
+	organization := config.GitHubOrg
 	githubClient := p.githubConnectUser(context.Background(), info)
-	organization := p.getConfiguration().GitHubOrg
+	if config.IsGitHubAppConfigured() {
+		if installationClient, err := p.getInstallationClient(context.Background(), organization, ""); err == nil {
+			githubClient = installationClient
+		}
+	}
 
 	return p.isUserOrganizationMember(githubClient, user, organization)
 }
 
-func (p *Plugin) postPullRequestEvent(event *github.PullRequestEvent) {
+func (p *Plugin) postPullRequestEvent(event *github.PullRequestEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subs) == 0 {
 		return
 	}
@@ -459,6 +513,7 @@ func (p *Plugin) postPullRequestEvent(event *github.PullRequestEvent) {
 			}
 
 			post.Message = p.sanitizeDescription(newPRMessage)
+			p.addPostActions(post, repoName, pr.GetNumber(), true, instance)
 		}
 
 		if action == actionReopened {
@@ -501,7 +556,7 @@ func (p *Plugin) sanitizeDescription(description string) string {
 	return strings.TrimSpace(description)
 }
 
-func (p *Plugin) handlePRDescriptionMentionNotification(event *github.PullRequestEvent) {
+func (p *Plugin) handlePRDescriptionMentionNotification(event *github.PullRequestEvent, instance string) {
 	action := event.GetAction()
 	if action != actionOpened {
 		return
@@ -528,12 +583,12 @@ func (p *Plugin) handlePRDescriptionMentionNotification(event *github.PullReques
 			continue
 		}
 
-		userID := p.getGitHubToUserIDMapping(username)
+		userID := p.getGitHubToUserIDMappingForInstance(username, instance)
 		if userID == "" {
 			continue
 		}
 
-		if event.GetRepo().GetPrivate() && !p.permissionToRepo(userID, event.GetRepo().GetFullName()) {
+		if event.GetRepo().GetPrivate() && !p.permissionToRepoForInstance(userID, instance, event.GetRepo().GetFullName()) {
 			continue
 		}
 
@@ -553,7 +608,7 @@ func (p *Plugin) handlePRDescriptionMentionNotification(event *github.PullReques
 	}
 }
 
-func (p *Plugin) postIssueEvent(event *github.IssuesEvent) {
+func (p *Plugin) postIssueEvent(event *github.IssuesEvent, instance string) {
 	repo := event.GetRepo()
 	issue := event.GetIssue()
 	action := event.GetAction()
@@ -564,7 +619,7 @@ func (p *Plugin) postIssueEvent(event *github.IssuesEvent) {
 		return
 	}
 
-	subscribedChannels := p.GetSubscribedChannelsForRepository(repo)
+	subscribedChannels := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subscribedChannels) == 0 {
 		return
 	}
@@ -622,6 +677,10 @@ func (p *Plugin) postIssueEvent(event *github.IssuesEvent) {
 		post.AddProp(postPropGithubObjectID, issueNumber)
 		post.AddProp(postPropGithubObjectType, githubObjectTypeIssue)
 
+		if action == actionOpened {
+			p.addPostActions(post, repoName, issue.GetNumber(), false, instance)
+		}
+
 		label := sub.Label()
 
 		contained := false
@@ -648,10 +707,10 @@ func (p *Plugin) postIssueEvent(event *github.IssuesEvent) {
 	}
 }
 
-func (p *Plugin) postPushEvent(event *github.PushEvent) {
+func (p *Plugin) postPushEvent(event *github.PushEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(ConvertPushEventRepositoryToRepository(repo))
+	subs := p.GetSubscribedChannelsForRepository(ConvertPushEventRepositoryToRepository(repo), instance)
 
 	if len(subs) == 0 {
 		return
@@ -687,10 +746,10 @@ func (p *Plugin) postPushEvent(event *github.PushEvent) {
 	}
 }
 
-func (p *Plugin) postCreateEvent(event *github.CreateEvent) {
+func (p *Plugin) postCreateEvent(event *github.CreateEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subs) == 0 {
 		return
 	}
@@ -724,10 +783,10 @@ func (p *Plugin) postCreateEvent(event *github.CreateEvent) {
 	}
 }
 
-func (p *Plugin) postDeleteEvent(event *github.DeleteEvent) {
+func (p *Plugin) postDeleteEvent(event *github.DeleteEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 
 	if len(subs) == 0 {
 		return
@@ -762,10 +821,10 @@ func (p *Plugin) postDeleteEvent(event *github.DeleteEvent) {
 	}
 }
 
-func (p *Plugin) postIssueCommentEvent(event *github.IssueCommentEvent) {
+func (p *Plugin) postIssueCommentEvent(event *github.IssueCommentEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 
 	if len(subs) == 0 {
 		return
@@ -841,10 +900,10 @@ func (p *Plugin) senderMutedByReceiver(userID string, sender string) bool {
 	return strings.Contains(mutedUsernames, sender)
 }
 
-func (p *Plugin) postPullRequestReviewEvent(event *github.PullRequestReviewEvent) {
+func (p *Plugin) postPullRequestReviewEvent(event *github.PullRequestReviewEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subs) == 0 {
 		return
 	}
@@ -905,10 +964,10 @@ func (p *Plugin) postPullRequestReviewEvent(event *github.PullRequestReviewEvent
 	}
 }
 
-func (p *Plugin) postPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent) {
+func (p *Plugin) postPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subs) == 0 {
 		return
 	}
@@ -962,7 +1021,7 @@ func (p *Plugin) postPullRequestReviewCommentEvent(event *github.PullRequestRevi
 	}
 }
 
-func (p *Plugin) handleCommentMentionNotification(event *github.IssueCommentEvent) {
+func (p *Plugin) handleCommentMentionNotification(event *github.IssueCommentEvent, instance string) {
 	action := event.GetAction()
 	if action == actionEdited || action == actionDeleted {
 		return
@@ -1009,12 +1068,12 @@ func (p *Plugin) handleCommentMentionNotification(event *github.IssueCommentEven
 			continue
 		}
 
-		userID := p.getGitHubToUserIDMapping(username)
+		userID := p.getGitHubToUserIDMappingForInstance(username, instance)
 		if userID == "" {
 			continue
 		}
 
-		if event.GetRepo().GetPrivate() && !p.permissionToRepo(userID, event.GetRepo().GetFullName()) {
+		if event.GetRepo().GetPrivate() && !p.permissionToRepoForInstance(userID, instance, event.GetRepo().GetFullName()) {
 			continue
 		}
 
@@ -1034,7 +1093,7 @@ func (p *Plugin) handleCommentMentionNotification(event *github.IssueCommentEven
 	}
 }
 
-func (p *Plugin) handleCommentAuthorNotification(event *github.IssueCommentEvent) {
+func (p *Plugin) handleCommentAuthorNotification(event *github.IssueCommentEvent, instance string) {
 	author := event.GetIssue().GetUser().GetLogin()
 	if author == event.GetSender().GetLogin() {
 		return
@@ -1045,12 +1104,12 @@ func (p *Plugin) handleCommentAuthorNotification(event *github.IssueCommentEvent
 		return
 	}
 
-	authorUserID := p.getGitHubToUserIDMapping(author)
+	authorUserID := p.getGitHubToUserIDMappingForInstance(author, instance)
 	if authorUserID == "" {
 		return
 	}
 
-	if event.GetRepo().GetPrivate() && !p.permissionToRepo(authorUserID, event.GetRepo().GetFullName()) {
+	if event.GetRepo().GetPrivate() && !p.permissionToRepoForInstance(authorUserID, instance, event.GetRepo().GetFullName()) {
 		return
 	}
 
@@ -1085,7 +1144,7 @@ func (p *Plugin) handleCommentAuthorNotification(event *github.IssueCommentEvent
 	p.sendRefreshEvent(authorUserID)
 }
 
-func (p *Plugin) handleCommentAssigneeNotification(event *github.IssueCommentEvent) {
+func (p *Plugin) handleCommentAssigneeNotification(event *github.IssueCommentEvent, instance string) {
 	author := event.GetIssue().GetUser().GetLogin()
 	assignees := event.GetIssue().Assignees
 	repoName := event.GetRepo().GetFullName()
@@ -1128,7 +1187,7 @@ func (p *Plugin) handleCommentAssigneeNotification(event *github.IssueCommentEve
 			}
 		}
 
-		userID := p.getGitHubToUserIDMapping(assignee.GetLogin())
+		userID := p.getGitHubToUserIDMappingForInstance(assignee.GetLogin(), instance)
 		if userID == "" {
 			continue
 		}
@@ -1140,11 +1199,11 @@ func (p *Plugin) handleCommentAssigneeNotification(event *github.IssueCommentEve
 			continue
 		}
 
-		if !p.permissionToRepo(userID, repoName) {
+		if !p.permissionToRepoForInstance(userID, instance, repoName) {
 			continue
 		}
 
-		assigneeID := p.getGitHubToUserIDMapping(assignee.GetLogin())
+		assigneeID := p.getGitHubToUserIDMappingForInstance(assignee.GetLogin(), instance)
 		if assigneeID == "" {
 			continue
 		}
@@ -1164,7 +1223,7 @@ func (p *Plugin) handleCommentAssigneeNotification(event *github.IssueCommentEve
 	}
 }
 
-func (p *Plugin) handlePullRequestNotification(event *github.PullRequestEvent) {
+func (p *Plugin) handlePullRequestNotification(event *github.PullRequestEvent, instance string) {
 	author := event.GetPullRequest().GetUser().GetLogin()
 	sender := event.GetSender().GetLogin()
 	repoName := event.GetRepo().GetFullName()
@@ -1181,24 +1240,24 @@ func (p *Plugin) handlePullRequestNotification(event *github.PullRequestEvent) {
 		if requestedReviewer == sender {
 			return
 		}
-		requestedUserID = p.getGitHubToUserIDMapping(requestedReviewer)
-		if isPrivate && !p.permissionToRepo(requestedUserID, repoName) {
+		requestedUserID = p.getGitHubToUserIDMappingForInstance(requestedReviewer, instance)
+		if isPrivate && !p.permissionToRepoForInstance(requestedUserID, instance, repoName) {
 			requestedUserID = ""
 		}
 	case actionClosed:
 		if author == sender {
 			return
 		}
-		authorUserID = p.getGitHubToUserIDMapping(author)
-		if isPrivate && !p.permissionToRepo(authorUserID, repoName) {
+		authorUserID = p.getGitHubToUserIDMappingForInstance(author, instance)
+		if isPrivate && !p.permissionToRepoForInstance(authorUserID, instance, repoName) {
 			authorUserID = ""
 		}
 	case actionReopened:
 		if author == sender {
 			return
 		}
-		authorUserID = p.getGitHubToUserIDMapping(author)
-		if isPrivate && !p.permissionToRepo(authorUserID, repoName) {
+		authorUserID = p.getGitHubToUserIDMappingForInstance(author, instance)
+		if isPrivate && !p.permissionToRepoForInstance(authorUserID, instance, repoName) {
 			authorUserID = ""
 		}
 	case actionAssigned:
@@ -1206,8 +1265,8 @@ func (p *Plugin) handlePullRequestNotification(event *github.PullRequestEvent) {
 		if assignee == sender {
 			return
 		}
-		assigneeUserID = p.getGitHubToUserIDMapping(assignee)
-		if isPrivate && !p.permissionToRepo(assigneeUserID, repoName) {
+		assigneeUserID = p.getGitHubToUserIDMappingForInstance(assignee, instance)
+		if isPrivate && !p.permissionToRepoForInstance(assigneeUserID, instance, repoName) {
 			assigneeUserID = ""
 		}
 	default:
@@ -1229,7 +1288,7 @@ func (p *Plugin) handlePullRequestNotification(event *github.PullRequestEvent) {
 	p.postIssueNotification(message, authorUserID, assigneeUserID)
 }
 
-func (p *Plugin) handleIssueNotification(event *github.IssuesEvent) {
+func (p *Plugin) handleIssueNotification(event *github.IssuesEvent, instance string) {
 	author := event.GetIssue().GetUser().GetLogin()
 	sender := event.GetSender().GetLogin()
 	if author == sender {
@@ -1244,13 +1303,13 @@ func (p *Plugin) handleIssueNotification(event *github.IssuesEvent) {
 
 	switch event.GetAction() {
 	case actionClosed:
-		authorUserID = p.getGitHubToUserIDMapping(author)
-		if isPrivate && !p.permissionToRepo(authorUserID, repoName) {
+		authorUserID = p.getGitHubToUserIDMappingForInstance(author, instance)
+		if isPrivate && !p.permissionToRepoForInstance(authorUserID, instance, repoName) {
 			authorUserID = ""
 		}
 	case actionReopened:
-		authorUserID = p.getGitHubToUserIDMapping(author)
-		if isPrivate && !p.permissionToRepo(authorUserID, repoName) {
+		authorUserID = p.getGitHubToUserIDMappingForInstance(author, instance)
+		if isPrivate && !p.permissionToRepoForInstance(authorUserID, instance, repoName) {
 			authorUserID = ""
 		}
 	case actionAssigned:
@@ -1258,8 +1317,8 @@ func (p *Plugin) handleIssueNotification(event *github.IssuesEvent) {
 		if assignee == sender {
 			return
 		}
-		assigneeUserID = p.getGitHubToUserIDMapping(assignee)
-		if isPrivate && !p.permissionToRepo(assigneeUserID, repoName) {
+		assigneeUserID = p.getGitHubToUserIDMappingForInstance(assignee, instance)
+		if isPrivate && !p.permissionToRepoForInstance(assigneeUserID, instance, repoName) {
 			assigneeUserID = ""
 		}
 	default:
@@ -1288,7 +1347,7 @@ func (p *Plugin) postIssueNotification(message, authorUserID, assigneeUserID str
 	}
 }
 
-func (p *Plugin) handlePullRequestReviewNotification(event *github.PullRequestReviewEvent) {
+func (p *Plugin) handlePullRequestReviewNotification(event *github.PullRequestReviewEvent, instance string) {
 	author := event.GetPullRequest().GetUser().GetLogin()
 	if author == event.GetSender().GetLogin() {
 		return
@@ -1298,12 +1357,12 @@ func (p *Plugin) handlePullRequestReviewNotification(event *github.PullRequestRe
 		return
 	}
 
-	authorUserID := p.getGitHubToUserIDMapping(author)
+	authorUserID := p.getGitHubToUserIDMappingForInstance(author, instance)
 	if authorUserID == "" {
 		return
 	}
 
-	if event.GetRepo().GetPrivate() && !p.permissionToRepo(authorUserID, event.GetRepo().GetFullName()) {
+	if event.GetRepo().GetPrivate() && !p.permissionToRepoForInstance(authorUserID, instance, event.GetRepo().GetFullName()) {
 		return
 	}
 
@@ -1317,10 +1376,10 @@ func (p *Plugin) handlePullRequestReviewNotification(event *github.PullRequestRe
 	p.sendRefreshEvent(authorUserID)
 }
 
-func (p *Plugin) postStarEvent(event *github.StarEvent) {
+func (p *Plugin) postStarEvent(event *github.StarEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 
 	if len(subs) == 0 {
 		return
@@ -1358,13 +1417,13 @@ func (p *Plugin) makeBotPost(message, postType string) *model.Post {
 	}
 }
 
-func (p *Plugin) postReleaseEvent(event *github.ReleaseEvent) {
+func (p *Plugin) postReleaseEvent(event *github.ReleaseEvent, instance string) {
 	if event.GetAction() != actionCreated && event.GetAction() != actionDeleted {
 		return
 	}
 
 	repo := event.GetRepo()
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 
 	if len(subs) == 0 {
 		return
@@ -1394,10 +1453,10 @@ func (p *Plugin) postReleaseEvent(event *github.ReleaseEvent) {
 	}
 }
 
-func (p *Plugin) postDiscussionEvent(event *github.DiscussionEvent) {
+func (p *Plugin) postDiscussionEvent(event *github.DiscussionEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subs) == 0 {
 		return
 	}
@@ -1432,10 +1491,10 @@ func (p *Plugin) postDiscussionEvent(event *github.DiscussionEvent) {
 	}
 }
 
-func (p *Plugin) postDiscussionCommentEvent(event *github.DiscussionCommentEvent) {
+func (p *Plugin) postDiscussionCommentEvent(event *github.DiscussionCommentEvent, instance string) {
 	repo := event.GetRepo()
 
-	subs := p.GetSubscribedChannelsForRepository(repo)
+	subs := p.GetSubscribedChannelsForRepository(repo, instance)
 	if len(subs) == 0 {
 		return
 	}
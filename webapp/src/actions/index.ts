@@ -2,6 +2,7 @@
 // See LICENSE.txt for license information.
 
 import {DispatchFunc} from 'mattermost-redux/types/actions';
+import {getPost} from 'mattermost-redux/selectors/entities/posts';
 
 import {getPluginState} from '../selectors';
 
@@ -11,11 +12,11 @@ import Client from '../client';
 import ActionTypes from '../action_types';
 import {APIError, PrsDetailsData, ShowRhsPluginActionData} from '../types/github_types';
 
-export function getConnected(reminder = false) {
+export function getConnected() {
     return async (dispatch: DispatchFunc) => {
         let data;
         try {
-            data = await Client.getConnected(reminder);
+            data = await Client.getConnected();
         } catch (error) {
             return {error};
         }
@@ -280,20 +281,38 @@ export function updateRhsState(rhsState: string) {
 }
 
 export function openCreateIssueModal(postId: string) {
-    return {
-        type: ActionTypes.OPEN_CREATE_ISSUE_MODAL,
-        data: {
-            postId,
-        },
+    return async (dispatch: DispatchFunc, getState: GetStateFunc) => {
+        // The default repository of the channel is preselected, as for `/github issue create`.
+        let repo;
+        const post = getPost(getState(), postId);
+        if (post) {
+            try {
+                const data = await Client.getChannelRepo(post.channel_id);
+                repo = data.repo;
+            } catch (error) {
+                // The issue is created without a preselected repository.
+            }
+        }
+
+        dispatch({
+            type: ActionTypes.OPEN_CREATE_ISSUE_MODAL,
+            data: {
+                postId,
+                repo,
+            },
+        });
+
+        return {data: true};
     };
 }
 
-export function openCreateIssueModalWithoutPost(title: string, channelId: string) {
+export function openCreateIssueModalWithoutPost(title: string, channelId: string, repo?: string) {
     return {
         type: ActionTypes.OPEN_CREATE_ISSUE_MODAL_WITHOUT_POST,
         data: {
             title,
             channelId,
+            repo,
         },
     };
 }
@@ -11,8 +11,8 @@ export default class Client {
         this.url = url + `/plugins/${manifest.id}/api/v1`;
     }
 
-    getConnected = async (reminder = false) => {
-        return this.doGet(`${this.url}/connected?reminder=${reminder}`);
+    getConnected = async () => {
+        return this.doGet(`${this.url}/connected`);
     }
 
     getSidebarContent = async () => {
@@ -35,6 +35,10 @@ export default class Client {
         return this.doGet(`${this.url}/repositories`);
     }
 
+    getChannelRepo = async (channelId) => {
+        return this.doGet(`${this.url}/channel-repo?channel_id=${channelId}`);
+    }
+
     getLabels = async (repo) => {
         return this.doGet(`${this.url}/labels?repo=${repo}`);
     }
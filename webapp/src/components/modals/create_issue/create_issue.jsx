@@ -36,6 +36,7 @@ export default class CreateIssueModal extends PureComponent {
         post: PropTypes.object,
         title: PropTypes.string,
         channelId: PropTypes.string,
+        defaultRepo: PropTypes.string,
         theme: PropTypes.object.isRequired,
         visible: PropTypes.bool.isRequired,
     };
@@ -48,10 +49,12 @@ export default class CreateIssueModal extends PureComponent {
 
     componentDidUpdate(prevProps) {
         if (this.props.post && !prevProps.post) {
-            this.setState({issueDescription: this.props.post.message}); //eslint-disable-line react/no-did-update-set-state
+            const repo = this.props.defaultRepo ? {name: this.props.defaultRepo} : null;
+            this.setState({issueDescription: this.props.post.message, repo}); //eslint-disable-line react/no-did-update-set-state
         } else if (this.props.channelId && (this.props.channelId !== prevProps.channelId || this.props.title !== prevProps.title)) {
             const title = this.props.title.substring(0, MAX_TITLE_LENGTH);
-            this.setState({issueTitle: title}); // eslint-disable-line react/no-did-update-set-state
+            const repo = this.props.defaultRepo ? {name: this.props.defaultRepo} : null;
+            this.setState({issueTitle: title, repo}); // eslint-disable-line react/no-did-update-set-state
         }
     }
 
@@ -12,7 +12,7 @@ import CreateIssueModal from './create_issue';
 
 const mapStateToProps = (state) => {
     const {id: pluginId} = manifest;
-    const {postId, title, channelId} = state[`plugins-${pluginId}`].createIssueModal;
+    const {postId, title, channelId, repo} = state[`plugins-${pluginId}`].createIssueModal;
     const post = (postId) ? getPost(state, postId) : null;
 
     return {
@@ -20,6 +20,7 @@ const mapStateToProps = (state) => {
         post,
         title,
         channelId,
+        defaultRepo: repo,
     };
 };
 
@@ -5,6 +5,7 @@ import {connect} from 'react-redux';
 import {bindActionCreators} from 'redux';
 
 import {getConnected, updateRhsState, getSidebarContent} from '../../actions';
+import {getTotal} from '../../selectors';
 
 import manifest from '../../manifest';
 
@@ -12,13 +13,14 @@ import SidebarButtons from './sidebar_buttons.jsx';
 
 function mapStateToProps(state) {
     const {id: pluginId} = manifest;
+    const {sidebarContent} = state[`plugins-${pluginId}`];
     return {
         connected: state[`plugins-${pluginId}`].connected,
         clientId: state[`plugins-${pluginId}`].clientId,
-        reviews: state[`plugins-${pluginId}`].sidebarContent.reviews,
-        yourPrs: state[`plugins-${pluginId}`].sidebarContent.prs,
-        yourAssignments: state[`plugins-${pluginId}`].sidebarContent.assignments,
-        unreads: state[`plugins-${pluginId}`].sidebarContent.unreads,
+        reviewsTotal: getTotal(sidebarContent.reviews_total, sidebarContent.reviews),
+        yourPrsTotal: getTotal(sidebarContent.prs_total, sidebarContent.prs),
+        yourAssignmentsTotal: getTotal(sidebarContent.assignments_total, sidebarContent.assignments),
+        unreads: sidebarContent.unreads,
         enterpriseURL: state[`plugins-${pluginId}`].enterpriseURL,
         showRHSPlugin: state[`plugins-${pluginId}`].rhsPluginAction,
     };
@@ -14,10 +14,10 @@ export default class SidebarButtons extends React.PureComponent {
         connected: PropTypes.bool,
         clientId: PropTypes.string,
         enterpriseURL: PropTypes.string,
-        reviews: PropTypes.arrayOf(PropTypes.object),
         unreads: PropTypes.arrayOf(PropTypes.object),
-        yourPrs: PropTypes.arrayOf(PropTypes.object),
-        yourAssignments: PropTypes.arrayOf(PropTypes.object),
+        reviewsTotal: PropTypes.number,
+        yourPrsTotal: PropTypes.number,
+        yourAssignmentsTotal: PropTypes.number,
         isTeamSidebar: PropTypes.bool,
         showRHSPlugin: PropTypes.func.isRequired,
         actions: PropTypes.shape({
@@ -41,7 +41,7 @@ export default class SidebarButtons extends React.PureComponent {
             return;
         }
 
-        this.props.actions.getConnected(true);
+        this.props.actions.getConnected();
     }
 
     componentDidUpdate(prevProps) {
@@ -117,10 +117,7 @@ export default class SidebarButtons extends React.PureComponent {
             return null;
         }
 
-        const reviews = this.props.reviews || [];
-        const yourPrs = this.props.yourPrs || [];
         const unreads = this.props.unreads || [];
-        const yourAssignments = this.props.yourAssignments || [];
         const refreshClass = this.state.refreshing ? ' fa-spin' : '';
 
         let baseURL = 'https://github.com';
@@ -149,7 +146,7 @@ export default class SidebarButtons extends React.PureComponent {
                         onClick={() => this.openRHS(RHSStates.PRS)}
                     >
                         <i className='fa fa-compress'/>
-                        {' ' + yourPrs.length}
+                        {' ' + (this.props.yourPrsTotal || 0)}
                     </a>
                 </OverlayTrigger>
                 <OverlayTrigger
@@ -162,7 +159,7 @@ export default class SidebarButtons extends React.PureComponent {
                         style={button}
                     >
                         <i className='fa fa-code-fork'/>
-                        {' ' + reviews.length}
+                        {' ' + (this.props.reviewsTotal || 0)}
                     </a>
                 </OverlayTrigger>
                 <OverlayTrigger
@@ -175,7 +172,7 @@ export default class SidebarButtons extends React.PureComponent {
                         style={button}
                     >
                         <i className='fa fa-list-ol'/>
-                        {' ' + yourAssignments.length}
+                        {' ' + (this.props.yourAssignmentsTotal || 0)}
                     </a>
                 </OverlayTrigger>
                 <OverlayTrigger
@@ -222,6 +222,42 @@ function GithubItems(props: GithubItemsProps) {
             );
         }
 
+        let draft: JSX.Element | null = null;
+        if (item.draft) {
+            draft = (
+                <span
+                    className='light'
+                    style={style.draft}
+                >
+                    {'Draft'}
+                </span>
+            );
+        }
+
+        let blocked: JSX.Element | null = null;
+        if (item.blocked) {
+            const blockedText = 'Merging is blocked by required reviews or checks';
+            blocked = (
+                <OverlayTrigger
+                    key='githubRHSPRBlockedIndicator'
+                    placement='top'
+                    overlay={
+                        <Tooltip
+                            id='githubRHSPRBlockedTooltip'
+                            aria-label={blockedText}
+                        >
+                            {blockedText}
+                        </Tooltip>
+                    }
+                >
+                    <i
+                        style={style.conflictIcon}
+                        className='icon icon-lock-outline'
+                    />
+                </OverlayTrigger>
+            );
+        }
+
         let labels: JSX.Element[] | null = null;
         if (item.labels) {
             labels = getGithubLabels(item.labels);
@@ -234,7 +270,7 @@ function GithubItems(props: GithubItemsProps) {
             >
                 <div>
                     <strong>
-                        {title}{hasConflict}{status}
+                        {title}{draft}{hasConflict}{blocked}{status}
                     </strong>
                 </div>
                 <div>
@@ -303,6 +339,10 @@ const getStyle = makeStyleFromTheme((theme) => {
         conflictIcon: {
             color: theme.dndIndicator,
         },
+        draft: {
+            marginLeft: '6px',
+            fontWeight: 'normal',
+        },
         milestoneIcon: {
             top: 3,
             position: 'relative',
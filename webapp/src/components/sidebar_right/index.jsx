@@ -11,13 +11,16 @@ import {getSidebarData} from 'src/selectors';
 import SidebarRight from './sidebar_right.jsx';
 
 function mapStateToProps(state) {
-    const {username, reviews, yourPrs, yourAssignments, unreads, enterpriseURL, orgs, rhsState} = getSidebarData(state);
+    const {username, reviews, yourPrs, yourAssignments, unreads, reviewsTotal, yourPrsTotal, yourAssignmentsTotal, enterpriseURL, orgs, rhsState} = getSidebarData(state);
     return {
         username,
         reviews,
         yourPrs,
         yourAssignments,
         unreads,
+        reviewsTotal,
+        yourPrsTotal,
+        yourAssignmentsTotal,
         enterpriseURL,
         orgs,
         rhsState,
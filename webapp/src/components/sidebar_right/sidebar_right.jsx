@@ -72,6 +72,9 @@ export default class SidebarRight extends React.PureComponent {
         unreads: PropTypes.arrayOf(PropTypes.object),
         yourPrs: PropTypes.arrayOf(PropTypes.object),
         yourAssignments: PropTypes.arrayOf(PropTypes.object),
+        reviewsTotal: PropTypes.number,
+        yourPrsTotal: PropTypes.number,
+        yourAssignmentsTotal: PropTypes.number,
         rhsState: PropTypes.string,
         theme: PropTypes.object.isRequired,
         actions: PropTypes.shape({
@@ -111,12 +114,14 @@ export default class SidebarRight extends React.PureComponent {
 
         let title = '';
         let githubItems = [];
+        let total = 0;
         let listUrl = '';
 
         switch (rhsState) {
         case RHSStates.PRS:
 
             githubItems = yourPrs;
+            total = this.props.yourPrsTotal;
             title = 'Your Open Pull Requests';
             listUrl = baseURL + '/pulls?q=is%3Aopen+is%3Apr+author%3A' + username + '+archived%3Afalse' + orgQuery;
 
@@ -124,6 +129,7 @@ export default class SidebarRight extends React.PureComponent {
         case RHSStates.REVIEWS:
 
             githubItems = reviews;
+            total = this.props.reviewsTotal;
             listUrl = baseURL + '/pulls?q=is%3Aopen+is%3Apr+review-requested%3A' + username + '+archived%3Afalse' + orgQuery;
             title = 'Pull Requests Needing Review';
 
@@ -137,6 +143,7 @@ export default class SidebarRight extends React.PureComponent {
         case RHSStates.ASSIGNMENTS:
 
             githubItems = yourAssignments;
+            total = this.props.yourAssignmentsTotal;
             title = 'Your Assignments';
             listUrl = baseURL + '/pulls?q=is%3Aopen+archived%3Afalse+assignee%3A' + username + orgQuery;
             break;
@@ -162,6 +169,11 @@ export default class SidebarRight extends React.PureComponent {
                                 rel='noopener noreferrer'
                             >{title}</a>
                         </strong>
+                        {total > githubItems.length && (
+                            <div className='light'>
+                                {`Showing ${githubItems.length} of ${total} items.`}
+                            </div>
+                        )}
                     </div>
                     <div>
                         <GithubItems
@@ -27,7 +27,7 @@ class PluginClass {
         registry.registerReducer(Reducer);
         Client.setServerRoute(getServerRoute(store.getState()));
 
-        await getConnected(true)(store.dispatch, store.getState);
+        await getConnected()(store.dispatch, store.getState);
 
         registry.registerLeftSidebarHeaderComponent(SidebarHeader);
         registry.registerBottomTeamSidebarComponent(TeamSidebar);
@@ -51,7 +51,7 @@ class PluginClass {
         activityFunc = () => {
             const now = new Date().getTime();
             if (now - lastActivityTime > activityTimeout) {
-                handleReconnect(store, true)();
+                handleReconnect(store)();
             }
             lastActivityTime = now;
         };
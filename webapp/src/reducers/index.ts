@@ -52,7 +52,7 @@ function username(state = '', action: {type: string, data: ConnectedData}) {
 
 function userSettings(state = {
     sidebar_buttons: Constants.SETTING_BUTTONS_TEAM,
-    daily_reminder: true,
+    daily_reminder: false,
     notifications: true,
 } as UserSettingsData, action: {type: string, data: ConnectedData}) {
     switch (action.type) {
@@ -195,6 +195,7 @@ const createIssueModal = (state = {} as CreateIssueModalData, action: {type: str
             postId: action.data.postId,
             title: action.data.title,
             channelId: action.data.channelId,
+            repo: action.data.repo,
         };
     case ActionTypes.CLOSE_CREATE_ISSUE_MODAL:
         return {};
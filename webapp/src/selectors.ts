@@ -42,12 +42,21 @@ function mapPrsToDetails(prs: GithubIssueData[], details: PrsDetailsData[]) {
             ...pr,
             status: foundDetails.status,
             mergeable: foundDetails.mergeable,
+            reviewDecision: foundDetails.reviewDecision,
             requestedReviewers: foundDetails.requestedReviewers,
             reviews: foundDetails.reviews,
         };
     });
 }
 
+// getTotal returns the number of matching items, which can be more than the items fetched for the sidebar.
+export function getTotal(total: number | undefined, items: unknown[] | undefined): number {
+    if (typeof total === 'number') {
+        return total;
+    }
+    return items ? items.length : 0;
+}
+
 export const getSidebarData = createSelector(
     getPluginState,
     (pluginState): SidebarData => {
@@ -58,6 +67,9 @@ export const getSidebarData = createSelector(
             yourPrs: mapPrsToDetails(sidebarContent.prs || emptyArray, yourPrDetails),
             yourAssignments: sidebarContent.assignments || emptyArray,
             unreads: sidebarContent.unreads || emptyArray,
+            reviewsTotal: getTotal(sidebarContent.reviews_total, sidebarContent.reviews),
+            yourPrsTotal: getTotal(sidebarContent.prs_total, sidebarContent.prs),
+            yourAssignmentsTotal: getTotal(sidebarContent.assignments_total, sidebarContent.assignments),
             orgs: organizations,
             rhsState,
         };
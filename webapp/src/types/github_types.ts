@@ -34,6 +34,10 @@ export type GithubItem = PrsDetailsData & {
     }
     labels?: GithubLabel[];
 
+    // Sidebar pull requests
+    draft?: boolean;
+    blocked?: boolean;
+
     // Assignments
     pullRequest?: unknown;
 
@@ -74,6 +78,7 @@ export type PrsDetailsData = {
     number: number;
     status?: string;
     mergeable?: boolean;
+    reviewDecision?: string;
     requestedReviewers?: string[];
     reviews?: Review[];
 }
@@ -97,6 +102,9 @@ export type SidebarContentData = {
     reviews: GithubIssueData[];
     assignments: GithubIssueData[];
     unreads: UnreadsData[];
+    prs_total?: number;
+    reviews_total?: number;
+    assignments_total?: number;
 }
 
 export type MentionsData = {
@@ -118,6 +126,7 @@ export type CreateIssueModalData = {
     title: string;
     channelId: string;
     postId: string;
+    repo?: string;
 }
 
 export type AttachCommentToIssueModalForPostIdData = {
@@ -136,6 +145,9 @@ export type SidebarData = {
     yourPrs: GithubIssueData[];
     yourAssignments: GithubIssueData[],
     unreads: UnreadsData[]
+    reviewsTotal: number,
+    yourPrsTotal: number,
+    yourAssignmentsTotal: number,
     orgs: string[],
     rhsState?: string | null
 }
@@ -25,7 +25,7 @@ export function handleConnect(store) {
                 ...msg.data,
                 user_settings: {
                     sidebar_buttons: Constants.SETTING_BUTTONS_TEAM,
-                    daily_reminder: true,
+                    daily_reminder: false,
                     ...msg.data.user_settings,
                 },
             },
@@ -34,7 +34,12 @@ export function handleConnect(store) {
 }
 
 export function handleDisconnect(store) {
-    return () => {
+    return (msg) => {
+        // The webapp only tracks the connection of the default GitHub instance.
+        if (msg && msg.data && msg.data.instance) {
+            return;
+        }
+
         store.dispatch({
             type: ActionTypes.RECEIVED_CONNECTED,
             data: {
@@ -61,9 +66,9 @@ export function handleConfigurationUpdate(store) {
     };
 }
 
-export function handleReconnect(store, reminder = false) {
+export function handleReconnect(store) {
     return async () => {
-        const {data} = await getConnected(reminder)(store.dispatch, store.getState);
+        const {data} = await getConnected()(store.dispatch, store.getState);
         if (data && data.connected) {
             if (typeof timeoutId === 'number') {
                 clearTimeout(timeoutId);
@@ -96,6 +101,6 @@ export function handleOpenCreateIssueModal(store) {
         if (!msg.data) {
             return;
         }
-        store.dispatch(openCreateIssueModalWithoutPost(msg.data.title, msg.data.channel_id));
+        store.dispatch(openCreateIssueModalWithoutPost(msg.data.title, msg.data.channel_id, msg.data.repo));
     };
 }